@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [topic]",
+	Short: "Approve a reviewed outline and continue the book",
+	Long: `Approve the outline of a book that was paused with --approve-outline or --approve-chapters.
+The OUTLINE.yaml files are read back into the book state, including any edits, and
+processing continues from there.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cleanedTopic := utils.CleanName(args[0])
+
+		logger := logger.NewSimpleLogger()
+		requireAPIKey(logger)
+		bookHandler := newBookHandler(logger)
+
+		logger.Info(fmt.Sprintf("Approving outline for book with topic: %s", cleanedTopic))
+		err := bookHandler.ApproveBook(cleanedTopic)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to approve book: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	addApprovalFlags(approveCmd)
+	rootCmd.AddCommand(approveCmd)
+}
@@ -8,17 +8,27 @@ import (
 	"go-book-ai/internal/handlers"
 	"go-book-ai/internal/logger"
 	"go-book-ai/internal/models"
+	"go-book-ai/internal/store"
 	"go-book-ai/internal/utils"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	approveOutline  bool
+	approveChapters bool
+	rebuildStale    bool
+)
+
 var bookCmd = &cobra.Command{
-	Use:   "book [topic]",
-	Short: "Create or continue a book with the specified topic",
+	Use:     "book [topic]",
+	Aliases: []string{"run"},
+	Short:   "Create or continue a book with the specified topic",
 	Long: `Create a new book or continue an existing book by specifying the book topic.
-If a book with the given topic already exists, the command will pick up where it left off.`,
+If a book with the given topic already exists, the command will pick up where it left off.
+With --rebuild-stale, chapter outlines and drafts whose inputs changed since they were
+generated are regenerated first.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		topic := args[0]
@@ -26,23 +36,17 @@ If a book with the given topic already exists, the command will pick up where it
 		// Clean the topic name
 		cleanedTopic := utils.CleanName(topic)
 
-		apiKey := os.Getenv("OPENAI_API_KEY")
 		logger := logger.NewSimpleLogger()
-		if apiKey == "" {
-			logger.Error("API key not set. Please set the OPENAI_API_KEY environment variable.")
-			os.Exit(1)
-		}
-
-		errorHandler := errors.NewErrorHandler(3)
-		fileManager := file.NewFileManager(logger)
-
-		chatGPTModel := models.NewChatGPTModel(errorHandler)
-		writingAgent := agents.NewWritingAgent(chatGPTModel)
-		reviewingAgent := agents.NewMockReviewingAgent()
-		bookHandler := handlers.NewBookCommandHandler(writingAgent, reviewingAgent, fileManager, errorHandler, logger)
+		requireAPIKey(logger)
+		bookHandler := newBookHandler(logger)
 
 		logger.Info(fmt.Sprintf("Starting process for book with topic: %s", cleanedTopic))
-		err := bookHandler.ProcessBook(cleanedTopic)
+		var err error
+		if rebuildStale {
+			err = bookHandler.RebuildStale(cleanedTopic)
+		} else {
+			err = bookHandler.ProcessBook(cleanedTopic)
+		}
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to process book: %v", err))
 			os.Exit(1)
@@ -50,6 +54,50 @@ If a book with the given topic already exists, the command will pick up where it
 	},
 }
 
+// requireAPIKey exits if no API key is configured. Commands that call the
+// language model check this before doing any work.
+func requireAPIKey(logger logger.Logger) {
+	if cfg.OpenAIKey == "" {
+		logger.Error("API key not set. Please set the OPENAI_API_KEY environment variable or openai_key in the configuration.")
+		os.Exit(1)
+	}
+}
+
+// newBookHandler wires up a BookCommandHandler backed by ChatGPT and the
+// effective configuration.
+func newBookHandler(logger logger.Logger) *handlers.BookCommandHandler {
+	errorHandler := errors.NewErrorHandler(cfg.Retries)
+	fileManager := file.NewFileManager(logger)
+
+	chatGPTModel := models.NewChatGPTModel(errorHandler)
+	chatGPTModel.Model = cfg.Model
+	chatGPTModel.APIKey = cfg.OpenAIKey
+	writingAgent := agents.NewWritingAgent(chatGPTModel)
+	reviewingAgent := agents.NewMockReviewingAgent()
+	bookHandler := handlers.NewBookCommandHandler(writingAgent, reviewingAgent, fileManager, errorHandler, logger)
+	bookHandler.RequireOutlineApproval = approveOutline
+	bookHandler.RequireChapterApproval = approveChapters
+	bookHandler.Workspace = cfg.BookDir
+	bookHandler.Config = cfg
+	bookHandler.ConfigLoader = cfgLoader
+
+	stateStore, err := store.NewAutoStore(cfg.StateBackend)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Invalid configuration: %v", err))
+		os.Exit(1)
+	}
+	bookHandler.StateStore = stateStore
+	return bookHandler
+}
+
+// addApprovalFlags registers the outline approval gate flags on a command.
+func addApprovalFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&approveOutline, "approve-outline", false, "pause after the book outline so OUTLINE.yaml can be reviewed")
+	cmd.Flags().BoolVar(&approveChapters, "approve-chapters", false, "pause after the chapter outlines so each chapter OUTLINE.yaml can be reviewed")
+}
+
 func init() {
+	bookCmd.Flags().BoolVar(&rebuildStale, "rebuild-stale", false, "regenerate chapter outlines and drafts whose inputs have changed")
+	addApprovalFlags(bookCmd)
 	rootCmd.AddCommand(bookCmd)
 }
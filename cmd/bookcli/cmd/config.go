@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var configShowBook string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration and where each value comes from",
+	Long: `Show the effective configuration and where each value comes from. Settings are
+layered, each layer overriding the ones before it:
+
+  default          built-in defaults
+  user config      config.yaml in the user config directory (~/.config/bookcli)
+  project config   bookcli.yaml in the current directory
+  book config      book.yaml in the book directory, with --book
+  env              BOOKCLI_<SETTING>, e.g. BOOKCLI_MODEL, and OPENAI_API_KEY
+  flag             command line flags, e.g. --model`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+
+		effective := cfg
+		if configShowBook != "" {
+			var err error
+			effective, err = cfgLoader.Load(filepath.Join(cfg.BookDir, utils.CleanName(configShowBook)))
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+				os.Exit(1)
+			}
+		}
+
+		for _, setting := range effective.Settings() {
+			fmt.Printf("%-22s = %-32s (%s)\n", setting.Key, setting.Value, setting.Source)
+		}
+	},
+}
+
+func init() {
+	configShowCmd.Flags().StringVar(&configShowBook, "book", "", "include the book.yaml of this book")
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/export"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat   string
+	exportOutput   string
+	exportCover    string
+	exportCSS      string
+	exportTemplate string
+	exportMinted   bool
+	exportSplit    bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [topic]",
+	Short: "Export a book as a finished manuscript",
+	Long: `Export a book as a finished manuscript. The md format writes one Markdown file
+with title page front matter, a linked table of contents, and every chapter and
+section in outline order, with the headings of each draft nested under its
+section. Sections without a draft are marked as such.
+
+The epub format writes an EPUB 3 book for e-readers with a title page, table
+of contents and one chapter per file, styled by a built-in theme or --css. The
+cover is --cover, or cover.jpg or cover.png in the book directory if present.
+
+The html format writes a static website to a directory: an index page, one
+page per chapter with a sidebar of the outline and previous and next links,
+highlighted code blocks, and a search index for searching in the browser.
+
+The latex format writes a LaTeX project to a directory: main.tex, one file per
+chapter under chapters/, and a Makefile that builds the PDF with latexmk.
+Chapters, sections and the headings inside drafts become \chapter, \section
+and \subsection. Code blocks use listings, or minted with --minted. The main
+document comes from a built-in template, or from --template, a Go template
+with << and >> as delimiters that is given Title, Date, Draft, Preamble and
+Chapters.
+
+The docx format writes a Word document with the title page, chapters and
+sections in Word heading styles, and the lists, tables, code blocks, emphasis
+and footnotes of the drafts. With --split it writes one document per chapter
+to a directory instead.
+
+The mdbook format writes an mdBook project to a directory: book.toml, and
+under src/ a SUMMARY.md, a title page, and a folder per chapter with a
+README.md and one page per section. Build it with mdbook build.
+
+The hugo format writes a Hugo content tree to a directory, to be placed under
+the content directory of a site: an _index.md for the book and each chapter,
+and one page per section, with weights in outline order. Sections without a
+draft are marked as Hugo drafts.
+
+Exports are written to the export directory of the book unless --output is
+given. Files that have not changed since the last export are not rewritten,
+so tools watching the export see only what changed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		path, err := bookHandler.Export(utils.CleanName(args[0]), exportFormat, exportOutput, export.Options{
+			Cover:      exportCover,
+			Stylesheet: exportCSS,
+			Template:   exportTemplate,
+			Minted:     exportMinted,
+			Split:      exportSplit,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to export book: %v", err))
+			os.Exit(1)
+		}
+		fmt.Println(path)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "md", fmt.Sprintf("export format (%s)", strings.Join(export.Formats(), ", ")))
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file, or directory for html, latex, mdbook, hugo and split docx, to write instead of the export directory of the book")
+	exportCmd.Flags().StringVar(&exportCover, "cover", "", "cover image (epub, html, mdbook)")
+	exportCmd.Flags().StringVar(&exportCSS, "css", "", "stylesheet to use instead of the built-in theme (epub, html), or in addition to it (mdbook)")
+	exportCmd.Flags().StringVar(&exportTemplate, "template", "", "template of the main document (latex)")
+	exportCmd.Flags().BoolVar(&exportMinted, "minted", false, "typeset code blocks with minted instead of listings (latex)")
+	exportCmd.Flags().BoolVar(&exportSplit, "split", false, "write one document per chapter (docx)")
+	rootCmd.AddCommand(exportCmd)
+}
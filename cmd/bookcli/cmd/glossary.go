@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var glossaryCmd = &cobra.Command{
+	Use:   "glossary [topic]",
+	Short: "Add definitions of the terms the drafts introduce to the glossary",
+	Long: `Find the terms the drafts of a book set in bold or italics, ask the model for
+concise definitions consistent with how the book uses them, and add them to
+glossary.yaml in the book directory. Edit the file freely: terms already in it,
+and terms in its ignore list, are never proposed again. This also runs after
+the drafts when a book is generated.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cleanedTopic := utils.CleanName(args[0])
+
+		logger := logger.NewSimpleLogger()
+		requireAPIKey(logger)
+		bookHandler := newBookHandler(logger)
+
+		err := bookHandler.Glossary(cleanedTopic)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to generate glossary: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(glossaryCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var importTopic string
+
+var importCmd = &cobra.Command{
+	Use:   "import [dir|file.md]",
+	Short: "Import an existing Markdown manuscript as a book",
+	Long: `Import an existing Markdown manuscript, either a single file or a directory of
+Markdown files read in name order. The manuscript is split by headings into
+chapters and sections, each section is saved as a draft, and the outline and
+state are reconstructed so the book command can fill in missing sections.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		logger.Info(fmt.Sprintf("Importing manuscript from: %s", args[0]))
+		topic, err := bookHandler.ImportManuscript(args[0], importTopic)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to import manuscript: %v", err))
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Continue the book with: bookcli book %q", topic))
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importTopic, "topic", "", "topic to store the book under (defaults to the manuscript title)")
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index [topic]",
+	Short: "Add the key terms and proper nouns of the drafts to the index",
+	Long: `Find candidate index terms in the drafts of a book: phrases set in bold or
+italics, identifiers in code spans and proper nouns used more than once, leaving
+out terms most sections mention. The model picks the ones readers would look up,
+and they are added to index.yaml in the book directory. Edit the file freely:
+terms already in it, and terms in its ignore list, are never proposed again.
+Exports look up the sections that mention each term, so the index follows edits
+to the drafts. This also runs after the glossary when a book is generated.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cleanedTopic := utils.CleanName(args[0])
+
+		logger := logger.NewSimpleLogger()
+		requireAPIKey(logger)
+		bookHandler := newBookHandler(logger)
+
+		err := bookHandler.Index(cleanedTopic)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to generate index: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+}
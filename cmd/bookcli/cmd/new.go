@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var fromOutline string
+
+var newCmd = &cobra.Command{
+	Use:   "new [topic]",
+	Short: "Create a new book with the specified topic",
+	Long: `Create a new book with the specified topic.
+With --from-outline, the outline is imported from a Markdown table of contents
+(heading hierarchy) or an OUTLINE.yaml style file instead of being generated,
+and the book goes straight to drafting.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cleanedTopic := utils.CleanName(args[0])
+
+		logger := logger.NewSimpleLogger()
+		requireAPIKey(logger)
+		bookHandler := newBookHandler(logger)
+
+		logger.Info(fmt.Sprintf("Starting new book with topic: %s", cleanedTopic))
+		err := bookHandler.NewBook(cleanedTopic, fromOutline)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to create book: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	newCmd.Flags().StringVar(&fromOutline, "from-outline", "", "import the outline from a Markdown (.md) or YAML (.yaml) file")
+	addApprovalFlags(newCmd)
+	rootCmd.AddCommand(newCmd)
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/handlers"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/utils"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	outlineAddChapter  string
+	outlineAddPosition int
+	regenerateFrom     string
+	regenerateApply    bool
+)
+
+var outlineCmd = &cobra.Command{
+	Use:   "outline",
+	Short: "View and edit the outline of a book",
+	Long: `View and edit the outline of a book. Chapters and sections are referenced by
+position ("3" for chapter 3, "3.2" for its second section) or by ID ("ch3",
+"ch3/section2"). IDs never change, so drafts stay attached to their sections
+when the outline is reordered.`,
+}
+
+var outlineShowCmd = &cobra.Command{
+	Use:   "show [topic]",
+	Short: "Print the outline with positions and IDs",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		bookState, err := bookHandler.OutlineTree(utils.CleanName(args[0]))
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to load outline: %v", err))
+			os.Exit(1)
+		}
+
+		fmt.Println(bookState.Title)
+		printMatter(bookState.Matter, false)
+		for i, chapter := range bookState.Chapters {
+			fmt.Printf("%d. %s [%s]\n", i+1, chapter.Title, chapter.ID)
+			for j, section := range chapter.Sections {
+				fmt.Printf("   %d.%d %s [%s/%s]\n", i+1, j+1, section.Title, chapter.ID, section.ID)
+			}
+		}
+		printMatter(bookState.Matter, true)
+	},
+}
+
+// printMatter prints the front matter, or the back matter with back set, of
+// a book.
+func printMatter(matter []state.MatterState, back bool) {
+	for _, m := range matter {
+		if m.Back() != back {
+			continue
+		}
+		status := ""
+		if !m.Generated {
+			status = " (not written)"
+		}
+		fmt.Printf("-  %s [matter/%s]%s\n", m.Title, m.ID, status)
+	}
+}
+
+var outlineAddCmd = &cobra.Command{
+	Use:   "add [topic] [title]",
+	Short: "Add a chapter, or a section with --chapter",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutlineEdit("add", func(h *handlers.BookCommandHandler) error {
+			return h.AddOutlineItem(utils.CleanName(args[0]), outlineAddChapter, args[1], outlineAddPosition)
+		})
+	},
+}
+
+var outlineRemoveCmd = &cobra.Command{
+	Use:   "rm [topic] [ref]",
+	Short: "Remove a chapter or section, moving its drafts to removed/",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutlineEdit("remove", func(h *handlers.BookCommandHandler) error {
+			return h.RemoveOutlineItem(utils.CleanName(args[0]), args[1])
+		})
+	},
+}
+
+var outlineMoveCmd = &cobra.Command{
+	Use:   "mv [topic] [ref] [destination]",
+	Short: "Move a chapter to a position, or a section to a chapter",
+	Long: `Move a chapter to a new position ("bookcli outline mv topic 3 1"), or a section
+to another chapter and optional position ("bookcli outline mv topic 3.2 1.4").`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutlineEdit("move", func(h *handlers.BookCommandHandler) error {
+			return h.MoveOutlineItem(utils.CleanName(args[0]), args[1], args[2])
+		})
+	},
+}
+
+var outlineRenameCmd = &cobra.Command{
+	Use:   "rename [topic] [ref] [title]",
+	Short: "Rename a chapter or section",
+	Args:  cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutlineEdit("rename", func(h *handlers.BookCommandHandler) error {
+			return h.RenameOutlineItem(utils.CleanName(args[0]), args[1], strings.Join(args[2:], " "))
+		})
+	},
+}
+
+var outlineRegenerateCmd = &cobra.Command{
+	Use:   "regenerate [topic]",
+	Short: "Regenerate the book outline and merge it with the existing one",
+	Long: `Regenerate the book outline and merge it with the existing one. Chapters and
+sections are matched to existing ones by ID or by title similarity, and matched
+items keep their drafts. New items are left pending and removed items are
+reported. Without --apply only a preview is printed; the proposed outline is
+saved to OUTLINE.proposed.yaml, where it can be edited, and --apply merges it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		if regenerateFrom == "" {
+			requireAPIKey(logger)
+		}
+		bookHandler := newBookHandler(logger)
+
+		changes, err := bookHandler.RegenerateOutline(utils.CleanName(args[0]), regenerateFrom, regenerateApply)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to regenerate outline: %v", err))
+			os.Exit(1)
+		}
+
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+		if !regenerateApply {
+			fmt.Println("\nPreview only. Run again with --apply to merge this outline.")
+		}
+	},
+}
+
+// runOutlineEdit runs an outline edit and exits on failure.
+func runOutlineEdit(action string, edit func(h *handlers.BookCommandHandler) error) {
+	logger := logger.NewSimpleLogger()
+	bookHandler := newBookHandler(logger)
+
+	err := edit(bookHandler)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to %s outline item: %v", action, err))
+		os.Exit(1)
+	}
+}
+
+func init() {
+	outlineAddCmd.Flags().StringVar(&outlineAddChapter, "chapter", "", "add a section to this chapter instead of adding a chapter")
+	outlineAddCmd.Flags().IntVar(&outlineAddPosition, "at", 0, "1-based position to insert at (default: append)")
+
+	outlineRegenerateCmd.Flags().StringVar(&regenerateFrom, "from", "", "merge this Markdown or YAML outline instead of generating one")
+	outlineRegenerateCmd.Flags().BoolVar(&regenerateApply, "apply", false, "apply the merge instead of only previewing it")
+
+	outlineCmd.AddCommand(outlineShowCmd, outlineAddCmd, outlineRemoveCmd, outlineMoveCmd, outlineRenameCmd, outlineRegenerateCmd)
+	rootCmd.AddCommand(outlineCmd)
+}
@@ -2,20 +2,91 @@ package cmd
 
 import (
 	"fmt"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/logger"
+	"io"
+	"log"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	// cfgLoader builds the configuration from its layers, with the flags
+	// given on the command line on top.
+	cfgLoader = config.NewLoader()
+	// cfg is the effective configuration, loaded before any command runs.
+	cfg = config.Defaults()
+
+	logFile *os.File
+)
+
+// configFlags maps root flags to the settings they override.
+var configFlags = map[string]string{
+	"workspace":     "book_dir",
+	"model":         "model",
+	"retries":       "retries",
+	"concurrency":   "concurrency",
+	"log-level":     "log_level",
+	"log-file":      "log_file",
+	"state-backend": "state_backend",
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "bookcli",
 	Short: "bookcli is a CLI tool for generating book chapters using AI.",
 	Long:  `bookcli leverages AI to create detailed outlines and content for book chapters.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cfgLoader.Flags = map[string]string{}
+		cfgLoader.FlagNames = map[string]string{}
+		for name, key := range configFlags {
+			if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+				cfgLoader.Flags[key] = flag.Value.String()
+				cfgLoader.FlagNames[key] = name
+			}
+		}
+
+		loaded, err := cfgLoader.Load("")
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+		logger.SetLevel(cfg.LogLevel)
+
+		if cfg.LogFile != "" {
+			logFile, err = os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+			if err != nil {
+				return fmt.Errorf("failed to open log file: %w", err)
+			}
+			log.SetOutput(io.MultiWriter(os.Stdout, logFile))
+		}
+		return nil
+	},
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if logFile != nil {
+		logFile.Close()
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.String("workspace", "", "directory holding the books (setting book_dir)")
+	flags.String("model", "", "model used for generation")
+	flags.Int("retries", 0, "retries for failed model calls")
+	flags.Int("concurrency", 0, "number of section drafts generated at once")
+	flags.String("log-level", "", "log level: debug, info or error")
+	flags.String("log-file", "", "file the log is also written to")
+	flags.String("state-backend", "", "state backend for new books: yaml or db")
+}
+
+// workspaceDir returns the configured workspace directory.
+func workspaceDir() string {
+	return cfg.BookDir
+}
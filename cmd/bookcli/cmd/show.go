@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/handlers"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	showChapter string
+	showSection string
+	showMatter  string
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show [topic]",
+	Short: "Show how the parts of a book were produced",
+	Long: `Show the provenance of a book: which provider, model, parameters and prompt
+template version produced each part, in which run, when, and with how many
+tokens, and whether it was imported or edited by hand since. Without flags the
+book outline is shown with a summary per chapter; --chapter shows a chapter
+outline with a summary per section; --chapter with --section shows one draft;
+--matter shows an item of front or back matter, such as "preface".
+Chapters and sections are given by position ("3") or ID ("ch3", "section2").`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		var report *handlers.ProvenanceReport
+		var err error
+		switch {
+		case showMatter != "" && (showChapter != "" || showSection != ""):
+			err = fmt.Errorf("--matter cannot be combined with --chapter or --section")
+		case showMatter != "":
+			report, err = bookHandler.MatterProvenance(utils.CleanName(args[0]), showMatter)
+		default:
+			report, err = bookHandler.Provenance(utils.CleanName(args[0]), showChapter, showSection)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to show provenance: %v", err))
+			os.Exit(1)
+		}
+		printProvenance(report)
+	},
+}
+
+func printProvenance(report *handlers.ProvenanceReport) {
+	fmt.Printf("%s: %s\n", report.Item, report.Title)
+	if report.Path != "" {
+		fmt.Printf("  file:           %s\n", report.Path)
+	}
+
+	p := report.Provenance
+	if p == nil {
+		fmt.Println("  source:         unknown (produced before provenance was recorded)")
+	} else {
+		fmt.Printf("  source:         %s\n", p.Source)
+		if p.Provider != "" {
+			fmt.Printf("  provider:       %s\n", p.Provider)
+		}
+		if p.Model != "" {
+			fmt.Printf("  model:          %s\n", p.Model)
+		}
+		if len(p.Parameters) > 0 {
+			keys := make([]string, 0, len(p.Parameters))
+			for key := range p.Parameters {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			pairs := make([]string, len(keys))
+			for i, key := range keys {
+				pairs[i] = key + "=" + p.Parameters[key]
+			}
+			fmt.Printf("  parameters:     %s\n", strings.Join(pairs, ", "))
+		}
+		if p.PromptVersion != "" {
+			fmt.Printf("  prompt version: %s\n", p.PromptVersion)
+		}
+		fmt.Printf("  run:            %s\n", p.RunID)
+		fmt.Printf("  started:        %s\n", p.StartedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("  finished:       %s\n", p.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+		if p.Usage.TotalTokens > 0 {
+			fmt.Printf("  tokens:         %d (%d prompt, %d completion)\n", p.Usage.TotalTokens, p.Usage.PromptTokens, p.Usage.CompletionTokens)
+		}
+	}
+	switch {
+	case p != nil && !p.EditedAt.IsZero():
+		fmt.Printf("  modified:       yes, edited by hand %s\n", p.EditedAt.Local().Format("2006-01-02 15:04:05"))
+	case report.Modified:
+		fmt.Println("  modified:       yes, the file changed since it was written")
+	}
+
+	for _, child := range report.Children {
+		source := "no provenance recorded"
+		if child.Provenance != nil {
+			source = child.Provenance.Source
+			if child.Provenance.Model != "" {
+				source += " by " + child.Provenance.Model
+			}
+		}
+		if child.Modified {
+			source += ", modified"
+		}
+		fmt.Printf("  - %s %q: %s\n", child.Item, child.Title, source)
+	}
+}
+
+func init() {
+	showCmd.Flags().StringVar(&showChapter, "chapter", "", "chapter to show, by position or ID")
+	showCmd.Flags().StringVar(&showSection, "section", "", "section of the chapter to show, by position or ID")
+	showCmd.Flags().StringVar(&showMatter, "matter", "", "front or back matter to show, such as preface")
+	rootCmd.AddCommand(showCmd)
+}
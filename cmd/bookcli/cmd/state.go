@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/store"
+	"go-book-ai/internal/utils"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var stateMigrateTo string
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage how book state is stored",
+}
+
+var stateMigrateCmd = &cobra.Command{
+	Use:   "migrate [topic]",
+	Short: "Move the state of a book to another storage backend",
+	Long: `Move the state of a book to another storage backend. The yaml backend keeps the
+whole state in state.yaml; the db backend keeps it in state.db, an embedded
+database that only writes what changed, for books with many sections and long
+message histories. The old state file is kept with a .migrated.bak suffix.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		err := bookHandler.MigrateStateBackend(utils.CleanName(args[0]), stateMigrateTo)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to migrate state: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	stateMigrateCmd.Flags().StringVar(&stateMigrateTo, "to", store.BackendDB, fmt.Sprintf("backend to move the state to (%s or %s)", store.BackendYAML, store.BackendDB))
+
+	stateCmd.AddCommand(stateMigrateCmd)
+	rootCmd.AddCommand(stateCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-book-ai/internal/handlers"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusStale bool
+	statusJSON  bool
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [topic]",
+	Short: "Show the status of a book",
+	Long: `Show the status of a book: a tree of its chapters and sections with the state
+of each outline and draft, word counts from the draft files, tokens spent, and
+an estimate of the time left based on the measured latency of earlier calls.
+Use --json for the same information as JSON.
+
+With --stale, list the chapter outlines and drafts whose inputs (outline,
+prompt template, model parameters or upstream outline) changed since they were
+generated. Rebuild them with: bookcli run [topic] --rebuild-stale`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+		topic := utils.CleanName(args[0])
+
+		if statusStale {
+			stale, err := bookHandler.StaleItems(topic)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to read book status: %v", err))
+				os.Exit(1)
+			}
+			if len(stale) == 0 {
+				fmt.Println("Nothing is stale.")
+			}
+			for _, item := range stale {
+				fmt.Println(item)
+			}
+			return
+		}
+
+		status, err := bookHandler.Status(topic)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to read book status: %v", err))
+			os.Exit(1)
+		}
+
+		if statusJSON {
+			data, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to encode book status: %v", err))
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		printStatus(status)
+	},
+}
+
+func printStatus(status *handlers.BookStatus) {
+	fmt.Printf("%s (%s)\n", status.Title, status.Path)
+	fmt.Printf("Outline: %s, %d/%d sections drafted, %d words, %d tokens in %d calls", status.Outline, status.Drafted, status.Sections, status.Words, status.Tokens, status.Calls)
+	if status.Errors > 0 {
+		fmt.Printf(" (%d failed)", status.Errors)
+	}
+	if status.Stale > 0 {
+		fmt.Printf(", %d stale", status.Stale)
+	}
+	fmt.Println()
+
+	switch {
+	case status.ETAKnown && status.ETASeconds == 0:
+		fmt.Println("Nothing left to generate.")
+	case status.ETAKnown:
+		eta := time.Duration(status.ETASeconds * float64(time.Second)).Round(time.Second)
+		fmt.Printf("About %s left at %.1fs per draft.\n", eta, status.AvgDraftSeconds)
+	default:
+		fmt.Println("Time left unknown until more calls have been measured.")
+	}
+
+	for _, chapter := range status.Chapters {
+		fmt.Printf("\n%-10s %s\n", chapter.ID, chapter.Title)
+		fmt.Printf("           outline %s, %d/%d drafted, %d words, %d tokens\n", chapter.Outline, chapter.Drafted, len(chapter.Sections), chapter.Words, chapter.Tokens)
+		for _, section := range chapter.Sections {
+			fmt.Printf("  %-10s %-40s %-10s %6d words %7d tokens\n", section.ID, truncate(section.Title, 40), section.Draft, section.Words, section.Tokens)
+		}
+	}
+}
+
+// truncate shortens s to n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusStale, "stale", false, "list artifacts whose inputs changed since they were generated")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "print the status as JSON")
+	rootCmd.AddCommand(statusCmd)
+}
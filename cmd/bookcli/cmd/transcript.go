@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/transcript"
+	"go-book-ai/internal/utils"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	transcriptStage  string
+	transcriptItem   string
+	transcriptErrors bool
+	transcriptSince  time.Duration
+	transcriptLast   int
+	transcriptFull   bool
+	transcriptJSON   bool
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript [topic]",
+	Short: "Show the language model calls made for a book",
+	Long: `Show the language model calls made for a book, as recorded in its
+transcript.jsonl: stage, item, model, token usage, latency and any error.
+Use --full to print the messages sent and the response received, or --json to
+print the matching entries as JSON lines.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		filter := transcript.Filter{
+			Stage:      transcriptStage,
+			ItemID:     transcriptItem,
+			ErrorsOnly: transcriptErrors,
+			Last:       transcriptLast,
+		}
+		if transcriptSince > 0 {
+			filter.Since = time.Now().Add(-transcriptSince)
+		}
+
+		entries, err := bookHandler.Transcript(utils.CleanName(args[0]), filter)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to read transcript: %v", err))
+			os.Exit(1)
+		}
+
+		for _, entry := range entries {
+			if transcriptJSON {
+				data, _ := json.Marshal(entry)
+				fmt.Println(string(data))
+				continue
+			}
+			printTranscriptEntry(entry)
+		}
+	},
+}
+
+func printTranscriptEntry(entry transcript.Entry) {
+	item := entry.ItemID
+	if item == "" {
+		item = "-"
+	}
+	status := "ok"
+	if entry.Error != "" {
+		status = "error: " + entry.Error
+	}
+	fmt.Printf("%s  %-15s %-16s %-8s %6d tokens %6dms  %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Stage, item, entry.Model, entry.Usage.TotalTokens, entry.LatencyMS, status)
+	if !transcriptFull {
+		return
+	}
+
+	for _, message := range entry.Messages {
+		fmt.Printf("\n--- %s ---\n%s\n", message.Role, message.Content)
+	}
+	if len(entry.Parameters) > 0 {
+		data, _ := json.Marshal(entry.Parameters)
+		fmt.Printf("\n--- parameters ---\n%s\n", data)
+	}
+	fmt.Printf("\n--- response ---\n%s\n\n", entry.Response)
+}
+
+func init() {
+	transcriptCmd.Flags().StringVar(&transcriptStage, "stage", "", fmt.Sprintf("only show calls of one stage (%s, %s, %s, %s, %s or %s)", transcript.StageBookOutline, transcript.StageChapterOutline, transcript.StageDraft, transcript.StageMatter, transcript.StageGlossary, transcript.StageIndex))
+	transcriptCmd.Flags().StringVar(&transcriptItem, "item", "", `only show calls for an item and its children, e.g. "ch2" or "ch2/section1"`)
+	transcriptCmd.Flags().BoolVar(&transcriptErrors, "errors", false, "only show failed calls")
+	transcriptCmd.Flags().DurationVar(&transcriptSince, "since", 0, `only show calls made within this long, e.g. "2h"`)
+	transcriptCmd.Flags().IntVar(&transcriptLast, "last", 0, "only show the last n matching calls")
+	transcriptCmd.Flags().BoolVar(&transcriptFull, "full", false, "print the messages, parameters and response of each call")
+	transcriptCmd.Flags().BoolVar(&transcriptJSON, "json", false, "print matching entries as JSON lines")
+	rootCmd.AddCommand(transcriptCmd)
+}
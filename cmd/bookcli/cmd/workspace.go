@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/utils"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listJSON      bool
+	archiveOutput string
+	archiveRemove bool
+	rmYes         bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the books in the workspace",
+	Long: `List the books in the workspace with a summary of their status. Use --json for
+the full status of every book.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		books, err := bookHandler.ListBooks()
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to list books: %v", err))
+			os.Exit(1)
+		}
+
+		if listJSON {
+			data, err := json.MarshalIndent(books, "", "  ")
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to encode books: %v", err))
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if len(books) == 0 {
+			fmt.Printf("No books in %s.\n", workspaceDir())
+			return
+		}
+		fmt.Printf("%-30s %-40s %-18s %9s %8s %6s\n", "NAME", "TITLE", "OUTLINE", "DRAFTED", "WORDS", "STALE")
+		for _, book := range books {
+			if book.Status == nil {
+				fmt.Printf("%-30s error: %s\n", book.Name, book.Error)
+				continue
+			}
+			s := book.Status
+			fmt.Printf("%-30s %-40s %-18s %9s %8d %6d\n", truncate(book.Name, 30), truncate(s.Title, 40), s.Outline, fmt.Sprintf("%d/%d", s.Drafted, s.Sections), s.Words, s.Stale)
+		}
+	},
+}
+
+var renameCmd = &cobra.Command{
+	Use:   "rename [topic] [new topic]",
+	Short: "Rename a book",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		newName, err := bookHandler.RenameBook(utils.CleanName(args[0]), args[1])
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to rename book: %v", err))
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Continue the book with: bookcli book %q", newName))
+	},
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive [topic]",
+	Short: "Archive a book to a .tar.gz file",
+	Long: `Archive a book to a .tar.gz file, by default in the archive directory of the
+workspace. With --remove the book is deleted once the archive is written.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+
+		_, err := bookHandler.ArchiveBook(utils.CleanName(args[0]), archiveOutput, archiveRemove)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to archive book: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+var rmCmd = &cobra.Command{
+	Use:   "rm [topic]",
+	Short: "Delete a book",
+	Long: `Delete a book and everything in its directory. You are asked to type the book
+name to confirm unless --yes is given. Consider bookcli archive --remove to keep
+a copy.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := logger.NewSimpleLogger()
+		bookHandler := newBookHandler(logger)
+		topic := utils.CleanName(args[0])
+
+		if !rmYes {
+			fmt.Printf("This deletes the book %s and all its drafts. Type the book name to confirm: ", topic)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(answer) != topic {
+				fmt.Println("Aborted.")
+				os.Exit(1)
+			}
+		}
+
+		err := bookHandler.RemoveBook(topic)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to remove book: %v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the books and their status as JSON")
+	archiveCmd.Flags().StringVarP(&archiveOutput, "output", "o", "", "path of the archive to write")
+	archiveCmd.Flags().BoolVar(&archiveRemove, "remove", false, "delete the book after archiving it")
+	rmCmd.Flags().BoolVarP(&rmYes, "yes", "y", false, "do not ask for confirmation")
+
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(rmCmd)
+}
@@ -1,29 +1,21 @@
 package main
 
 import (
+	"errors"
 	"go-book-ai/cmd/bookcli/cmd"
-	"io"
+	"io/fs"
 	"log"
-	"os"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables from .env file
+	// Load environment variables from .env file, if there is one
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	logFile, err := os.OpenFile("bookcli.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("Failed to open log file: %v", err)
-	}
-	defer logFile.Close()
-
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-	log.SetOutput(multiWriter)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
 	cmd.Execute()
@@ -2,19 +2,38 @@ package agents
 
 import (
 	"fmt"
+	"go-book-ai/internal/bookindex"
+	"go-book-ai/internal/glossary"
 	"go-book-ai/internal/models"
 	"go-book-ai/internal/outline"
+	"strings"
+	"sync"
 )
 
 type WritingAgent interface {
 	GenerateOutline(topic string) (string, error)
 	GenerateChapterOutline(chapterTitle string) (string, error)
 	GenerateSectionContent(section outline.Section) (string, error)
+	// GenerateMatter returns the prompt for an item of front or back
+	// matter, like a preface, given the book title and its chapter titles.
+	GenerateMatter(kind, title, bookTitle string, chapters []string) (string, error)
+	// GenerateGlossary returns the prompt asking for definitions of the
+	// candidate glossary terms of a book.
+	GenerateGlossary(bookTitle string, candidates []glossary.Candidate) (string, error)
+	// GenerateIndex returns the prompt asking which candidate terms belong
+	// in the index of a book.
+	GenerateIndex(bookTitle string, candidates []bookindex.Candidate) (string, error)
 	SendMessage(prompt string) (string, error)
+	ModelName() string
+	// Exchange sends a prompt like SendMessage and also returns a record of
+	// the call, or nil when the model does not keep one.
+	Exchange(prompt string, opts models.Options) (string, *models.CallInfo, error)
 }
 
 type writingAgent struct {
 	LanguageModel models.LanguageModel
+
+	mu sync.Mutex
 }
 
 func NewWritingAgent(model models.LanguageModel) WritingAgent {
@@ -94,6 +113,71 @@ Make sure the content is engaging, informative, and suitable for a book. Write i
 	return prompt, nil
 }
 
+// matterBriefs describes what each generated kind of front and back matter
+// should cover.
+var matterBriefs = map[string]string{
+	"preface":      "a preface in which the author explains why they wrote the book, who it is for, and what readers will gain from it",
+	"introduction": "an introduction that sets out the subject of the book and the problems it addresses, and gives an overview of the chapters",
+	"how_to_read":  "a short guide to reading the book: how the chapters build on each other, which can be read out of order or skipped, and the conventions used",
+	"conclusion":   "a concluding chapter that draws together the main ideas of the chapters and suggests where readers can go next",
+}
+
+func (agent *writingAgent) GenerateMatter(kind, title, bookTitle string, chapters []string) (string, error) {
+	brief, ok := matterBriefs[kind]
+	if !ok {
+		return "", fmt.Errorf("no prompt for front or back matter %q", kind)
+	}
+	contents := ""
+	for _, chapter := range chapters {
+		contents += fmt.Sprintf("\n- %s", chapter)
+	}
+
+	prompt := fmt.Sprintf(`You are writing the "%s" of a book titled "%s". The book has the following chapters:
+%s
+
+Please write %s, in Markdown format. Do not repeat "%s" as a heading. Write in a clear and professional tone that matches the rest of the book, and refer to the chapters by their titles where it helps the reader.`, title, bookTitle, contents, brief, title)
+
+	return prompt, nil
+}
+
+func (agent *writingAgent) GenerateGlossary(bookTitle string, candidates []glossary.Candidate) (string, error) {
+	terms := ""
+	for _, candidate := range candidates {
+		terms += fmt.Sprintf("\n- %s: \"%s\"", candidate.Term, candidate.Context)
+	}
+
+	prompt := fmt.Sprintf(`You are writing the glossary of a book titled "%s". The following candidate terms are emphasized in the book, each with the passage that introduces it:
+%s
+
+For each candidate that is a technical term a reader might need to look up, write a concise definition of one or two sentences, consistent with how the book uses the term. Leave out candidates that are not technical terms. Format the glossary strictly in YAML as follows:
+
+- term: "[Term]"
+  definition: "[Definition]"
+
+Please ensure the output is valid YAML and do not include any additional text or explanations.`, bookTitle, terms)
+
+	return prompt, nil
+}
+
+func (agent *writingAgent) GenerateIndex(bookTitle string, candidates []bookindex.Candidate) (string, error) {
+	terms := ""
+	for _, candidate := range candidates {
+		terms += fmt.Sprintf("\n- %s (in: %s)", candidate.Term, strings.Join(candidate.Sections, "; "))
+	}
+
+	prompt := fmt.Sprintf(`You are preparing the back-of-book index of a book titled "%s". The following candidate terms were found in the book, each with the sections that mention it:
+%s
+
+Choose the key terms and proper nouns a reader would look up in the index, and leave out generic words and phrases. Give each term as it should appear in the index, keeping its spelling from the list. Format the terms strictly as a YAML list of strings, like:
+
+- "[Term]"
+- "[Term]"
+
+Please ensure the output is valid YAML and do not include any additional text or explanations.`, bookTitle, terms)
+
+	return prompt, nil
+}
+
 func (agent *writingAgent) SendMessage(prompt string) (string, error) {
 	agent.LanguageModel.SetParameters(map[string]interface{}{
 		"messages": []map[string]string{
@@ -102,3 +186,30 @@ func (agent *writingAgent) SendMessage(prompt string) (string, error) {
 	})
 	return agent.LanguageModel.Generate(prompt)
 }
+
+// ModelName returns the name of the underlying language model, or an empty
+// string when the model does not report one.
+func (agent *writingAgent) ModelName() string {
+	if named, ok := agent.LanguageModel.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+// chatModel is implemented by models that can make a chat call without
+// keeping state between calls.
+type chatModel interface {
+	Chat(messages []models.Message, opts models.Options) (string, *models.CallInfo, error)
+}
+
+// Exchange is safe for concurrent use. Models without stateless chat calls are
+// called one at a time and their calls are not recorded.
+func (agent *writingAgent) Exchange(prompt string, opts models.Options) (string, *models.CallInfo, error) {
+	if chat, ok := agent.LanguageModel.(chatModel); ok {
+		return chat.Chat([]models.Message{{Role: "user", Content: prompt}}, opts)
+	}
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	content, err := agent.SendMessage(prompt)
+	return content, nil, err
+}
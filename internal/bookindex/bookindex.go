@@ -0,0 +1,223 @@
+// Package bookindex finds the key terms and proper nouns of a book for its
+// back-of-book index and keeps them in index.yaml, which authors can edit.
+// Which sections an entry points at is worked out from the drafts at export
+// time, so the index follows edits to the drafts.
+package bookindex
+
+import (
+	"fmt"
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/markdown"
+	"go-book-ai/internal/utils"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the name of the index file inside a book directory.
+const FileName = "index.yaml"
+
+// MaxCandidates caps the candidate terms sent to the model in one call.
+const MaxCandidates = 150
+
+// Index holds the terms of a book's index in alphabetical order.
+type Index struct {
+	Terms []string `yaml:"terms"`
+	// Ignore lists candidate terms left out of the index, so they are not
+	// proposed again.
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+// Load reads an index file. A missing file is an empty index.
+func Load(path string) (*Index, error) {
+	var x Index
+	err := utils.LoadYAML(path, &x)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index %s: %w", path, err)
+	}
+	return &x, nil
+}
+
+// Save writes the index file.
+func (x *Index) Save(path string) error {
+	err := utils.SaveYAML(path, x)
+	if err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return nil
+}
+
+// Has reports whether a term is in the index or its ignore list, ignoring
+// case.
+func (x *Index) Has(term string) bool {
+	return glossary.Listed(x.Terms, term) || glossary.Listed(x.Ignore, term)
+}
+
+// Add adds the terms that are not in the index yet, keeping it sorted. It
+// returns how many were added.
+func (x *Index) Add(terms ...string) int {
+	added := 0
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" || x.Has(term) {
+			continue
+		}
+		x.Terms = append(x.Terms, term)
+		added++
+	}
+	sort.SliceStable(x.Terms, func(i, j int) bool {
+		return strings.ToLower(x.Terms[i]) < strings.ToLower(x.Terms[j])
+	})
+	return added
+}
+
+// Draft is the parsed draft of a section.
+type Draft struct {
+	Title string
+	Doc   *markdown.Node
+}
+
+// Candidate is a term that may belong in the index.
+type Candidate struct {
+	Term string
+	// Sections are the titles of the sections that mention the term.
+	Sections []string
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*(\(\))?$`)
+
+// Candidates returns the terms of the drafts that are not in the index or its
+// ignore list yet: phrases set in bold or italics, identifiers in code spans
+// and proper nouns used more than once. Terms most sections mention are left
+// out, being too common to look up. The terms found in the most sections come
+// first, at most MaxCandidates of them.
+func (x *Index) Candidates(drafts []Draft) []Candidate {
+	found := map[string]string{}
+	var order []string
+	add := func(term string) {
+		key := strings.ToLower(term)
+		if _, ok := found[key]; ok || x.Has(term) {
+			return
+		}
+		found[key] = term
+		order = append(order, key)
+	}
+
+	texts := make([]string, len(drafts))
+	// marked holds the terms the drafts set apart with emphasis or as code,
+	// which are kept even when used once.
+	marked := map[string]bool{}
+	for i, draft := range drafts {
+		if draft.Doc == nil {
+			continue
+		}
+		texts[i] = draft.Doc.PlainText()
+		markdown.Walk(draft.Doc, func(n *markdown.Node) bool {
+			switch n.Kind {
+			case markdown.Heading, markdown.CodeBlock, markdown.Link:
+				return false
+			case markdown.Strong, markdown.Emphasis:
+				if term := strings.TrimSpace(n.PlainText()); glossary.TermLike(term) {
+					marked[strings.ToLower(term)] = true
+					add(term)
+				}
+				return false
+			case markdown.Code:
+				if identifier.MatchString(n.Literal) && len(n.Literal) > 1 && len(n.Literal) <= 40 {
+					marked[strings.ToLower(n.Literal)] = true
+					add(n.Literal)
+				}
+			case markdown.Paragraph:
+				for _, noun := range properNouns(n.PlainText()) {
+					add(noun)
+				}
+			}
+			return true
+		})
+	}
+
+	var candidates []Candidate
+	for _, key := range order {
+		candidate := Candidate{Term: found[key]}
+		uses := 0
+		for i, text := range texts {
+			if n := count(text, candidate.Term); n > 0 {
+				uses += n
+				candidate.Sections = append(candidate.Sections, drafts[i].Title)
+			}
+		}
+		if !marked[key] && uses < 2 {
+			continue
+		}
+		if len(drafts) >= 4 && len(candidate.Sections)*2 > len(drafts) {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Sections) > len(candidates[j].Sections)
+	})
+	if len(candidates) > MaxCandidates {
+		candidates = candidates[:MaxCandidates]
+	}
+	return candidates
+}
+
+// properNouns returns the runs of capitalized words in text that do not start
+// a sentence, like "Kubernetes" or "Google Cloud".
+func properNouns(text string) []string {
+	var nouns, run []string
+	flush := func() {
+		if len(run) > 0 {
+			nouns = append(nouns, strings.Join(run, " "))
+			run = nil
+		}
+	}
+	sentenceStart := true
+	for _, word := range strings.Fields(text) {
+		// Punctuation around a word ends a run.
+		core := strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if !strings.HasPrefix(word, core) {
+			flush()
+		}
+		if len(core) > 1 && unicode.IsUpper([]rune(core)[0]) && !sentenceStart {
+			run = append(run, core)
+		} else {
+			flush()
+		}
+		if !strings.HasSuffix(word, core) {
+			flush()
+		}
+		sentenceStart = strings.ContainsAny(word[len(word)-1:], ".!?:")
+	}
+	flush()
+	return nouns
+}
+
+// count returns the number of whole-word uses of term in text, ignoring
+// case.
+func count(text, term string) int {
+	n := 0
+	for {
+		i := glossary.Find(text, term)
+		if i < 0 {
+			return n
+		}
+		n++
+		text = text[i+len(term):]
+	}
+}
+
+// ParseTerms reads the terms the model kept, a YAML list, optionally in a
+// code fence.
+func ParseTerms(response string) ([]string, error) {
+	var terms []string
+	err := yaml.Unmarshal([]byte(glossary.StripFence(response)), &terms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index terms: %w", err)
+	}
+	return terms, nil
+}
@@ -0,0 +1,62 @@
+package bookindex
+
+import (
+	"go-book-ai/internal/markdown"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCandidates(t *testing.T) {
+	x := &Index{Terms: []string{"goroutine"}, Ignore: []string{"worker"}}
+	drafts := []Draft{
+		{Title: "Concurrency", Doc: markdown.Parse("A **goroutine** is cheap, and a *worker pool* reuses them. Kubernetes runs the service.\n")},
+		{Title: "Deploying", Doc: markdown.Parse("# Deploying to Google Cloud\n\nDeploy with Kubernetes on Google Cloud. Call `http.ListenAndServe` once.\n")},
+		{Title: "Testing", Doc: markdown.Parse("Use the testing package. Go Modules are covered later.\n")},
+	}
+
+	got := map[string][]string{}
+	for _, c := range x.Candidates(drafts) {
+		got[c.Term] = c.Sections
+	}
+	want := map[string][]string{
+		"worker pool":         {"Concurrency"},
+		"Kubernetes":          {"Concurrency", "Deploying"},
+		"Google Cloud":        {"Deploying"},
+		"http.ListenAndServe": {"Deploying"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected candidates %v", got)
+	}
+}
+
+func TestProperNouns(t *testing.T) {
+	got := properNouns(`Install it. Then run "Google Cloud" with Kubernetes, and Docker Compose.`)
+	if !reflect.DeepEqual(got, []string{"Google Cloud", "Kubernetes", "Docker Compose"}) {
+		t.Errorf("Unexpected proper nouns %v", got)
+	}
+}
+
+func TestSaveAndAdd(t *testing.T) {
+	terms, err := ParseTerms("```yaml\n- slice\n- \"Go modules\"\n- goroutine\n```\n")
+	if err != nil {
+		t.Fatalf("ParseTerms returned error: %v", err)
+	}
+	x := &Index{Terms: []string{"goroutine"}}
+	if added := x.Add(terms...); added != 2 {
+		t.Errorf("Expected 2 terms added, got %d", added)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	err = x.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Terms, []string{"Go modules", "goroutine", "slice"}) {
+		t.Errorf("Unexpected index %v", loaded.Terms)
+	}
+}
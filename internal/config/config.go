@@ -1,35 +1,485 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
+// File names of the configuration layers.
+const (
+	ProjectFileName = "bookcli.yaml"
+	BookFileName    = "book.yaml"
+)
+
+// Config is the effective configuration of bookcli. It is built from layers,
+// each overriding the ones before it: built-in defaults, the user config file,
+// the project bookcli.yaml, the book's book.yaml, environment variables and
+// command line flags.
 type Config struct {
-	BookDir   string `yaml:"book_dir"`
-	OpenAIKey string `yaml:"openai_key"`
-	LogLevel  string `yaml:"log_level"`
+	BookDir      string `yaml:"book_dir"`
+	OpenAIKey    string `yaml:"openai_key"`
+	LogLevel     string `yaml:"log_level"`
+	LogFile      string `yaml:"log_file"`
+	Model        string `yaml:"model"`
+	Retries      int    `yaml:"retries"`
+	Concurrency  int    `yaml:"concurrency"`
+	StateBackend string `yaml:"state_backend"`
+
+	// Temperature is the sampling temperature; nil keeps the model default.
+	Temperature *float64 `yaml:"temperature"`
+	// Chapters is the range of chapters asked for in the book outline.
+	Chapters ChapterRange `yaml:"chapters"`
+	// SectionWords is the target length of section drafts in words.
+	SectionWords int `yaml:"section_words"`
+	// Stages overrides settings per generation stage. Layers override whole
+	// stages, so a book can set the draft model without repeating the other
+	// stages.
+	Stages map[string]Stage `yaml:"stages"`
+	// Prompts replaces the built-in prompt of a stage with a Go template.
+	Prompts map[string]string `yaml:"prompts"`
+
+	// Sources names the layer each setting came from, by setting key.
+	Sources map[string]string `yaml:"-"`
 }
 
-func LoadConfig(configPath string) (*Config, error) {
-	var config Config
-	absPath, err := filepath.Abs(configPath)
+// Generation stages that can be configured in Stages and Prompts.
+const (
+	StageBookOutline    = "book_outline"
+	StageChapterOutline = "chapter_outline"
+	StageDraft          = "draft"
+	StageMatter         = "matter"
+	StageGlossary       = "glossary"
+	StageIndex          = "index"
+)
+
+// StageNames lists the generation stages in the order they run.
+var StageNames = []string{StageBookOutline, StageChapterOutline, StageDraft, StageMatter, StageGlossary, StageIndex}
+
+// Providers lists the supported language model providers.
+var Providers = []string{"openai"}
+
+// Stage holds the settings of one generation stage. Empty fields fall back
+// to the global settings.
+type Stage struct {
+	Provider    string   `yaml:"provider,omitempty"`
+	Model       string   `yaml:"model,omitempty"`
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	// Enabled turns the stage off when false.
+	Enabled *bool `yaml:"enabled,omitempty"`
+}
+
+func (s Stage) String() string {
+	var parts []string
+	if s.Provider != "" {
+		parts = append(parts, "provider="+s.Provider)
+	}
+	if s.Model != "" {
+		parts = append(parts, "model="+s.Model)
+	}
+	if s.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature=%v", *s.Temperature))
+	}
+	if s.Enabled != nil {
+		parts = append(parts, fmt.Sprintf("enabled=%v", *s.Enabled))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ChapterRange is an inclusive range of chapter counts. Zero bounds are open.
+type ChapterRange struct {
+	Min int `yaml:"min,omitempty"`
+	Max int `yaml:"max,omitempty"`
+}
+
+func (r ChapterRange) String() string {
+	if r.Min == 0 && r.Max == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// Stage returns the effective settings of a generation stage, with the
+// global model and temperature filled in.
+func (c *Config) Stage(name string) Stage {
+	stage := c.Stages[name]
+	if stage.Provider == "" {
+		stage.Provider = Providers[0]
+	}
+	if stage.Model == "" {
+		stage.Model = c.Model
+	}
+	if stage.Temperature == nil {
+		stage.Temperature = c.Temperature
+	}
+	if stage.Enabled == nil {
+		enabled := true
+		stage.Enabled = &enabled
+	}
+	return stage
+}
+
+// StageEnabled reports whether a generation stage runs.
+func (c *Config) StageEnabled(name string) bool {
+	return *c.Stage(name).Enabled
+}
+
+// Setting is one configuration value as shown by the config show command.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Settings lists every setting with its value and source. Map settings are
+// listed per entry, as "stages.draft".
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	v := reflect.ValueOf(c).Elem()
+	for _, key := range Keys() {
+		field, _ := fieldByKey(v, key)
+		if field.Kind() != reflect.Map {
+			settings = append(settings, Setting{key, c.Get(key), c.Sources[key]})
+			continue
+		}
+		var entries []string
+		for _, k := range field.MapKeys() {
+			entries = append(entries, k.String())
+		}
+		sort.Strings(entries)
+		for _, entry := range entries {
+			value := fmt.Sprint(field.MapIndex(reflect.ValueOf(entry)).Interface())
+			if i := strings.IndexByte(value, '\n'); i >= 0 {
+				value = value[:i] + " ..."
+			}
+			settings = append(settings, Setting{key + "." + entry, value, c.Sources[key+"."+entry]})
+		}
+	}
+	return settings
+}
+
+// Defaults returns the built-in configuration.
+func Defaults() *Config {
+	c := &Config{
+		BookDir:      "books",
+		LogLevel:     "info",
+		LogFile:      "bookcli.log",
+		Model:        "gpt-4",
+		Retries:      3,
+		Concurrency:  1,
+		StateBackend: "yaml",
+		Sources:      map[string]string{},
+	}
+	for _, key := range Keys() {
+		c.Sources[key] = "default"
+	}
+	return c
+}
+
+// Keys returns the setting keys in the order they are declared.
+func Keys() []string {
+	t := reflect.TypeOf(Config{})
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		if key := yamlKey(t.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Get returns a setting formatted for display. Secrets are masked.
+func (c *Config) Get(key string) string {
+	field, ok := fieldByKey(reflect.ValueOf(c).Elem(), key)
+	if !ok {
+		return ""
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	value := fmt.Sprint(field.Interface())
+	if key == "openai_key" && value != "" {
+		if len(value) > 4 {
+			return "****" + value[len(value)-4:]
+		}
+		return "****"
+	}
+	return value
+}
+
+// Validate checks that settings have usable values.
+func (c *Config) Validate() error {
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "error":
+	default:
+		return fmt.Errorf("log_level %q must be debug, info or error (from %s)", c.LogLevel, c.Sources["log_level"])
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("retries must not be negative (from %s)", c.Sources["retries"])
+	}
+	if c.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1 (from %s)", c.Sources["concurrency"])
+	}
+	if c.StateBackend != "yaml" && c.StateBackend != "db" {
+		return fmt.Errorf("state_backend %q must be yaml or db (from %s)", c.StateBackend, c.Sources["state_backend"])
+	}
+	if c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2 (from %s)", c.Sources["temperature"])
+	}
+	if c.Chapters.Min < 0 || c.Chapters.Max < 0 || (c.Chapters.Max > 0 && c.Chapters.Min > c.Chapters.Max) {
+		return fmt.Errorf("chapters range %d-%d is invalid (from %s)", c.Chapters.Min, c.Chapters.Max, c.Sources["chapters"])
+	}
+	if c.SectionWords < 0 {
+		return fmt.Errorf("section_words must not be negative (from %s)", c.Sources["section_words"])
+	}
+	for name, stage := range c.Stages {
+		if !contains(StageNames, name) {
+			return fmt.Errorf("unknown stage %q, expected one of %s (from %s)", name, strings.Join(StageNames, ", "), c.Sources["stages."+name])
+		}
+		if stage.Provider != "" && !contains(Providers, stage.Provider) {
+			return fmt.Errorf("stage %s: unsupported provider %q, expected one of %s (from %s)", name, stage.Provider, strings.Join(Providers, ", "), c.Sources["stages."+name])
+		}
+		if stage.Temperature != nil && (*stage.Temperature < 0 || *stage.Temperature > 2) {
+			return fmt.Errorf("stage %s: temperature must be between 0 and 2 (from %s)", name, c.Sources["stages."+name])
+		}
+	}
+	for name := range c.Prompts {
+		if !contains(StageNames, name) {
+			return fmt.Errorf("unknown prompt %q, expected one of %s (from %s)", name, strings.Join(StageNames, ", "), c.Sources["prompts."+name])
+		}
+	}
+	if c.BookDir == "" {
+		return fmt.Errorf("book_dir must not be empty (from %s)", c.Sources["book_dir"])
+	}
+	return nil
+}
+
+// apply overrides the settings present in a YAML document and records source
+// as where they came from. Unknown settings are an error, so typos do not go
+// unnoticed.
+func (c *Config) apply(data []byte, source string) error {
+	var present map[string]interface{}
+	err := yaml.Unmarshal(data, &present)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to parse %s: %w", source, err)
 	}
-	data, err := os.ReadFile(absPath)
+	var layer Config
+	err = yaml.UnmarshalStrict(data, &layer)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to parse %s: %w", source, err)
 	}
-	if err = yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
+
+	keys := make([]string, 0, len(present))
+	for key := range present {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		from, _ := fieldByKey(reflect.ValueOf(&layer).Elem(), key)
+		to, _ := fieldByKey(reflect.ValueOf(c).Elem(), key)
+		if to.Kind() != reflect.Map {
+			to.Set(from)
+			c.Sources[key] = source
+			continue
+		}
+		// Map settings are merged entry by entry.
+		if to.IsNil() {
+			to.Set(reflect.MakeMap(to.Type()))
+		}
+		for _, k := range from.MapKeys() {
+			to.SetMapIndex(k, from.MapIndex(k))
+			c.Sources[key+"."+k.String()] = source
+		}
 	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
-	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
-		config.OpenAIKey = apiKey
+// applyFile applies a layer from a YAML file, if it exists.
+func (c *Config) applyFile(path, source string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return c.apply(data, fmt.Sprintf("%s %s", source, path))
+}
+
+// globalKeys are the settings read once for the whole process, before any
+// book is opened, so a book config cannot change them.
+var globalKeys = []string{"book_dir", "openai_key", "log_level", "log_file", "state_backend"}
+
+// applyBookFile applies a book's book.yaml, if it exists, rejecting the
+// settings that cannot differ per book.
+func (c *Config) applyBookFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var present map[string]interface{}
+	if yaml.Unmarshal(data, &present) == nil {
+		for _, key := range globalKeys {
+			if _, ok := present[key]; ok {
+				return fmt.Errorf("%s applies to every book and cannot be set in book config %s; set it in bookcli.yaml, the user config, the environment or a flag", key, path)
+			}
+		}
+	}
+	return c.apply(data, "book config "+path)
+}
+
+// applyValue applies a single setting given as a string, as from an
+// environment variable or flag, parsing it by the type of the setting.
+func (c *Config) applyValue(key, value, source string) error {
+	field, ok := fieldByKey(reflect.ValueOf(c).Elem(), key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q in %s", key, source)
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s must be a number, got %q", source, key, value)
+		}
+		field.SetInt(int64(n))
+	default:
+		return c.apply([]byte(key+": "+value), source)
+	}
+	c.Sources[key] = source
+	return nil
+}
+
+func yamlKey(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if key == "-" {
+		return ""
+	}
+	return key
+}
 
-	return &config, nil
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Loader builds the effective configuration from its layers.
+type Loader struct {
+	// UserFile is the per-user config file, skipped when empty or missing.
+	UserFile string
+	// ProjectFile is the project config file, skipped when empty or missing.
+	ProjectFile string
+	// Getenv looks up environment variables; nil skips the environment.
+	Getenv func(string) string
+	// Flags holds settings given on the command line, by setting key.
+	Flags map[string]string
+	// FlagNames holds the flag each setting in Flags came from, for sources.
+	FlagNames map[string]string
+}
+
+// NewLoader returns a Loader reading the user config from the user config
+// directory, the project config from the current directory and the process
+// environment.
+func NewLoader() *Loader {
+	loader := &Loader{ProjectFile: ProjectFileName, Getenv: os.Getenv}
+	if dir, err := os.UserConfigDir(); err == nil {
+		loader.UserFile = filepath.Join(dir, "bookcli", "config.yaml")
+	}
+	return loader
+}
+
+// envAliases are environment variables read besides BOOKCLI_<KEY>, for
+// compatibility with earlier releases.
+var envAliases = map[string][]string{
+	"openai_key": {"OPENAI_API_KEY"},
+	"book_dir":   {"BOOKCLI_WORKSPACE"},
+}
+
+// Load returns the effective configuration. When bookPath is set, the book's
+// book.yaml is layered between the project config and the environment.
+func (l *Loader) Load(bookPath string) (*Config, error) {
+	c := Defaults()
+
+	if l.UserFile != "" {
+		if err := c.applyFile(l.UserFile, "user config"); err != nil {
+			return nil, err
+		}
+	}
+	if l.ProjectFile != "" {
+		if err := c.applyFile(l.ProjectFile, "project config"); err != nil {
+			return nil, err
+		}
+	}
+	if bookPath != "" {
+		if err := c.applyBookFile(filepath.Join(bookPath, BookFileName)); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.Getenv != nil {
+		if l.Getenv("DEBUG") == "true" {
+			c.LogLevel = "debug"
+			c.Sources["log_level"] = "env DEBUG"
+		}
+		for _, key := range Keys() {
+			names := append(append([]string{}, envAliases[key]...), "BOOKCLI_"+strings.ToUpper(key))
+			for _, name := range names {
+				if value := l.Getenv(name); value != "" {
+					if err := c.applyValue(key, value, "env "+name); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	for _, key := range Keys() {
+		if value, ok := l.Flags[key]; ok {
+			if err := c.applyValue(key, value, "flag --"+l.FlagNames[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c, c.Validate()
+}
+
+// LoadConfig returns the configuration with configPath as the project config
+// file and the process environment applied on top.
+func LoadConfig(configPath string) (*Config, error) {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return nil, err
+	}
+	return (&Loader{ProjectFile: absPath, Getenv: os.Getenv}).Load("")
 }
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoaderPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "user.yaml")
+	projectFile := filepath.Join(dir, "bookcli.yaml")
+	bookPath := filepath.Join(dir, "books", "go")
+	os.MkdirAll(bookPath, os.ModePerm)
+	os.WriteFile(userFile, []byte("model: user-model\nretries: 1\nlog_level: error\n"), 0644)
+	os.WriteFile(projectFile, []byte("model: project-model\nconcurrency: 2\n"), 0644)
+	os.WriteFile(filepath.Join(bookPath, BookFileName), []byte("model: book-model\n"), 0644)
+
+	env := map[string]string{"BOOKCLI_RETRIES": "4", "OPENAI_API_KEY": "sk-test"}
+	loader := &Loader{
+		UserFile:    userFile,
+		ProjectFile: projectFile,
+		Getenv:      func(name string) string { return env[name] },
+		Flags:       map[string]string{"concurrency": "3"},
+		FlagNames:   map[string]string{"concurrency": "concurrency"},
+	}
+
+	c, err := loader.Load(bookPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	tests := []struct {
+		key, value, source string
+	}{
+		{"book_dir", "books", "default"},
+		{"log_level", "error", "user config " + userFile},
+		{"model", "book-model", "book config " + filepath.Join(bookPath, BookFileName)},
+		{"retries", "4", "env BOOKCLI_RETRIES"},
+		{"concurrency", "3", "flag --concurrency"},
+		{"openai_key", "****test", "env OPENAI_API_KEY"},
+	}
+	for _, tt := range tests {
+		if got := c.Get(tt.key); got != tt.value {
+			t.Errorf("%s: expected %q, got %q", tt.key, tt.value, got)
+		}
+		if got := c.Sources[tt.key]; got != tt.source {
+			t.Errorf("%s: expected source %q, got %q", tt.key, tt.source, got)
+		}
+	}
+
+	c, err = loader.Load("")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if c.Model != "project-model" {
+		t.Errorf("Expected the project model without a book, got %q", c.Model)
+	}
+}
+
+func TestLoaderRejectsBadSettings(t *testing.T) {
+	dir := t.TempDir()
+	projectFile := filepath.Join(dir, "bookcli.yaml")
+
+	for _, content := range []string{"modle: gpt-4\n", "concurrency: 0\n", "log_level: loud\n"} {
+		os.WriteFile(projectFile, []byte(content), 0644)
+		if _, err := (&Loader{ProjectFile: projectFile}).Load(""); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+
+	loader := &Loader{Flags: map[string]string{"retries": "many"}, FlagNames: map[string]string{"retries": "retries"}}
+	if _, err := loader.Load(""); err == nil {
+		t.Errorf("Expected an error for a non-numeric retries flag")
+	}
+}
+
+func TestBookRejectsGlobalSettings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, BookFileName), []byte("model: book-model\nstate_backend: db\n"), 0644)
+
+	_, err := (&Loader{}).Load(dir)
+	if err == nil || !strings.Contains(err.Error(), "state_backend applies to every book") {
+		t.Errorf("Expected state_backend to be rejected in book.yaml, got %v", err)
+	}
+}
+
+func TestBookOverridesStages(t *testing.T) {
+	dir := t.TempDir()
+	projectFile := filepath.Join(dir, "bookcli.yaml")
+	os.WriteFile(projectFile, []byte("temperature: 0.5\nstages:\n  chapter_outline: {model: outline-model}\n  draft: {model: draft-model}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, BookFileName), []byte("stages:\n  draft: {temperature: 0.9, enabled: false}\nprompts:\n  draft: \"Write {{.Title}}\"\n"), 0644)
+
+	c, err := (&Loader{ProjectFile: projectFile}).Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	outline := c.Stage(StageChapterOutline)
+	if outline.Model != "outline-model" || *outline.Temperature != 0.5 || !c.StageEnabled(StageChapterOutline) {
+		t.Errorf("Expected the project chapter outline stage with the global temperature, got %s", outline)
+	}
+	draft := c.Stage(StageDraft)
+	if draft.Model != "gpt-4" || *draft.Temperature != 0.9 || c.StageEnabled(StageDraft) {
+		t.Errorf("Expected the book draft stage to replace the project one, got %s", draft)
+	}
+	if c.Stage(StageBookOutline).Model != "gpt-4" {
+		t.Errorf("Expected the default model for an unconfigured stage")
+	}
+	if c.Prompts[StageDraft] != "Write {{.Title}}" {
+		t.Errorf("Expected the draft prompt override, got %q", c.Prompts[StageDraft])
+	}
+
+	os.WriteFile(filepath.Join(dir, BookFileName), []byte("stages:\n  review: {model: x}\n"), 0644)
+	if _, err := (&Loader{ProjectFile: projectFile}).Load(dir); err == nil {
+		t.Errorf("Expected an error for an unknown stage")
+	}
+}
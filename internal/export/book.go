@@ -0,0 +1,215 @@
+package export
+
+import (
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/markdown"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Book is the document model every export format is written from: the
+// chapters and sections of a book in outline order with their parsed drafts.
+type Book struct {
+	Title    string
+	Date     time.Time
+	Chapters []Chapter
+	// Assets holds the images drafts refer to by their path in an export,
+	// like "images/ch1-section2-diagram.png".
+	Assets map[string][]byte
+}
+
+// Options adjust an export. Formats ignore the options they have no use for.
+type Options struct {
+	// Cover is the path of a cover image.
+	Cover string
+	// Stylesheet is the path of a CSS file used instead of the built-in
+	// theme.
+	Stylesheet string
+	// Template is the path of a LaTeX template used instead of the built-in
+	// one for the main document.
+	Template string
+	// Minted typesets LaTeX code blocks with minted instead of listings.
+	Minted bool
+	// Split writes one Word document per chapter.
+	Split bool
+}
+
+// Chapter is a numbered chapter, or an unnumbered item of front or back
+// matter when Matter is set.
+type Chapter struct {
+	ID string
+	// Number is the position among the numbered chapters, zero for front
+	// and back matter.
+	Number      int
+	Title       string
+	Description string
+	Sections    []Section
+	// Matter is the kind of front or back matter, like "preface".
+	Matter string
+	// Back places front or back matter after the numbered chapters.
+	Back bool
+	// Body is the text of front or back matter, which has no sections.
+	Body Section
+	// Terms are the entries of the glossary chapter.
+	Terms []glossary.Term
+	// Index holds the entries of the index chapter.
+	Index []IndexEntry
+}
+
+// Section holds a section's draft with the section heading removed and the
+// remaining headings shifted so the shallowest is at level one. Content is
+// empty and Doc nil when the section has not been drafted.
+type Section struct {
+	ID      string
+	Title   string
+	Content string
+	// Doc is the parsed draft. Exporters must not modify it.
+	Doc *markdown.Node
+	// Notes holds the footnote definitions of the draft by label.
+	Notes map[string]*markdown.Node
+	// Images lists the assets of the book the draft refers to.
+	Images []string
+}
+
+// NewSection normalizes and parses a section's draft, which may be empty.
+func NewSection(id, title, draft string) Section {
+	sec := Section{ID: id, Title: title, Content: NormalizeSection(title, draft)}
+	if sec.Content == "" {
+		return sec
+	}
+	sec.Doc = markdown.Parse(sec.Content)
+	sec.Notes = map[string]*markdown.Node{}
+	markdown.Walk(sec.Doc, func(n *markdown.Node) bool {
+		if n.Kind == markdown.FootnoteDef {
+			sec.Notes[n.Label] = n
+		}
+		return true
+	})
+	return sec
+}
+
+// Markdown returns the draft with its headings moved down by offset levels
+// and links to assets made relative to a page base levels below the root of
+// the export.
+func (s Section) Markdown(offset, base int) string {
+	content := markdown.ShiftHeadings(s.Content, offset)
+	if base > 0 {
+		up := strings.Repeat("../", base)
+		for _, name := range s.Images {
+			content = strings.ReplaceAll(content, "]("+name, "]("+up+name)
+		}
+	}
+	return content
+}
+
+// matterMarkdown returns the text of front or back matter as Markdown, with
+// its headings at level offset+1 and asset links base directories up.
+func matterMarkdown(ch Chapter, offset, base int) string {
+	var parts []string
+	if ch.Body.Content != "" {
+		parts = append(parts, ch.Body.Markdown(offset, base))
+	}
+	for _, t := range ch.Terms {
+		parts = append(parts, "**"+t.Term+"**: "+t.Definition)
+	}
+	if len(ch.Index) > 0 {
+		var lines []string
+		for _, entry := range ch.Index {
+			lines = append(lines, "- "+entry.Term+", "+entry.Labels())
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+var chapterPrefix = regexp.MustCompile(`(?i)^chapter\s+[0-9ivxlc]+\s*[:.\-]?\s*`)
+
+// Name returns the chapter title without a "Chapter N" prefix.
+func (c Chapter) Name() string {
+	if c.Matter != "" {
+		return c.Title
+	}
+	title := strings.TrimSpace(chapterPrefix.ReplaceAllString(c.Title, ""))
+	if title == "" {
+		return c.Title
+	}
+	return title
+}
+
+// Heading returns the chapter heading, "Chapter N: Title", without repeating
+// a number the title already carries.
+func (c Chapter) Heading() string {
+	if c.Matter != "" {
+		return c.Title
+	}
+	return "Chapter " + strconv.Itoa(c.Number) + ": " + c.Name()
+}
+
+// Anchor is the link target of the chapter within an export.
+func (c Chapter) Anchor() string {
+	return c.ID
+}
+
+// SectionAnchor is the link target of a section of the chapter within an
+// export.
+func (c Chapter) SectionAnchor(s Section) string {
+	return c.ID + "-" + s.ID
+}
+
+// Drafted reports whether every section of the book has a draft.
+func (b *Book) Drafted() bool {
+	for _, ch := range b.Chapters {
+		for _, sec := range ch.Sections {
+			if sec.Content == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NormalizeSection prepares a draft for a Section: a leading heading that
+// repeats the section title is dropped, and the remaining headings are
+// shifted so the shallowest is at level one.
+func NormalizeSection(title, content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	doc := markdown.Parse(content)
+	if len(doc.Children) > 0 {
+		first := doc.Children[0]
+		if first.Kind == markdown.Heading && sameTitle(first.PlainText(), title) {
+			lines := strings.Split(content, "\n")
+			content = strings.Join(lines[first.EndLine:], "\n")
+			doc = markdown.Parse(content)
+		}
+	}
+
+	top := 0
+	for _, n := range doc.Children {
+		if n.Kind == markdown.Heading && (top == 0 || n.Level < top) {
+			top = n.Level
+		}
+	}
+	if top > 1 {
+		content = markdown.ShiftHeadings(content, 1-top)
+	}
+	return strings.TrimSpace(content)
+}
+
+// headingLevel moves a heading of a draft down by offset levels, keeping it
+// at six or above.
+func headingLevel(level, offset int) int {
+	level += offset
+	if level > 6 {
+		return 6
+	}
+	return level
+}
+
+func sameTitle(a, b string) bool {
+	clean := func(s string) string {
+		return strings.ToLower(strings.Trim(strings.TrimSpace(s), "*_`:. "))
+	}
+	return clean(a) == clean(b)
+}
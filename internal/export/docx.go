@@ -0,0 +1,615 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go-book-ai/internal/markdown"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path"
+	"strings"
+)
+
+func init() {
+	Register("docx", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		if opts.Split {
+			files, err := DOCXChapters(b)
+			if err != nil {
+				return nil, err
+			}
+			return &Output{Files: files}, nil
+		}
+		data, err := DOCX(b)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{File: data}, nil
+	}))
+}
+
+// DOCX writes the book as one Word document: a title page, then every
+// chapter starting on a new page. Headings use Word's heading styles, so the
+// navigation pane and a table of contents inserted in Word pick them up.
+// PNG, JPEG and GIF images of the book are embedded.
+func DOCX(b *Book) ([]byte, error) {
+	w := newDocxWriter(b)
+	w.paragraph("Title", "", func() { w.text(b.Title, runProps{}) })
+	if !b.Drafted() {
+		w.paragraph("Subtitle", "", func() { w.text("Draft", runProps{}) })
+	}
+	w.paragraph("Subtitle", "", func() { w.text(b.Date.Format("January 2, 2006"), runProps{}) })
+	for _, ch := range b.Chapters {
+		w.chapter(ch)
+	}
+	return w.pack(b.Title, b)
+}
+
+// DOCXChapters writes one Word document per chapter, named after the chapter
+// ID, for editors who work on chapters separately.
+func DOCXChapters(b *Book) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, ch := range b.Chapters {
+		w := newDocxWriter(b)
+		w.chapter(ch)
+		data, err := w.pack(ch.Heading(), b)
+		if err != nil {
+			return nil, err
+		}
+		files[ch.Anchor()+".docx"] = data
+	}
+	return files, nil
+}
+
+type docxWriter struct {
+	body      bytes.Buffer
+	footnotes bytes.Buffer
+	// out is the buffer being written: the body, or a footnote.
+	out   *bytes.Buffer
+	links []string
+	// lists holds the start number of each numbered list; bullet lists
+	// share one numbering.
+	lists     []int
+	notes     int
+	bookmarks int
+	// offset is the heading level just above the headings of a draft.
+	offset int
+	defs   map[string]*markdown.Node
+	inNote bool
+	assets map[string][]byte
+	// media holds the images embedded so far, in order of their
+	// relationship IDs.
+	media []string
+}
+
+type runProps struct {
+	bold, italic, strike, code bool
+	style                      string
+}
+
+// Numbering IDs: bullets use the first, numbered lists get one each after
+// it so every list restarts.
+const bulletNumID = 1
+
+func newDocxWriter(b *Book) *docxWriter {
+	// Footnote IDs 0 and 1 are the separators Word expects.
+	w := &docxWriter{notes: 1, assets: b.Assets}
+	w.out = &w.body
+	return w
+}
+
+func (w *docxWriter) chapter(ch Chapter) {
+	w.heading(1, ch.Anchor(), ch.Heading())
+	if ch.Matter != "" && ch.Body.Doc != nil {
+		w.offset, w.defs = 1, ch.Body.Notes
+		w.blocks(ch.Body.Doc.Children, "", 0)
+	}
+	for _, t := range ch.Terms {
+		w.paragraph("", "", func() {
+			w.text(t.Term, runProps{bold: true})
+			w.text(": "+t.Definition, runProps{})
+		})
+	}
+	for _, entry := range ch.Index {
+		w.paragraph("", "", func() { w.text(entry.Term+", "+entry.Labels(), runProps{}) })
+	}
+	w.offset = 2
+	for _, sec := range ch.Sections {
+		w.heading(2, ch.SectionAnchor(sec), sec.Title)
+		if sec.Content == "" {
+			w.paragraph("", "", func() { w.text("This section has not been drafted yet.", runProps{italic: true}) })
+			continue
+		}
+		w.defs = sec.Notes
+		w.blocks(sec.Doc.Children, "", 0)
+	}
+}
+
+func (w *docxWriter) heading(level int, anchor, title string) {
+	w.bookmarks++
+	id := w.bookmarks
+	w.paragraph(fmt.Sprintf("Heading%d", level), "", func() {
+		fmt.Fprintf(w.out, `<w:bookmarkStart w:id="%d" w:name="%s"/>`, id, bookmarkName(anchor))
+		w.text(title, runProps{})
+		fmt.Fprintf(w.out, `<w:bookmarkEnd w:id="%d"/>`, id)
+	})
+}
+
+// paragraph writes a paragraph with a style and extra paragraph properties,
+// filling it with content.
+func (w *docxWriter) paragraph(style, props string, content func()) {
+	w.out.WriteString("<w:p>")
+	if style != "" || props != "" {
+		w.out.WriteString("<w:pPr>")
+		if style != "" {
+			fmt.Fprintf(w.out, `<w:pStyle w:val="%s"/>`, style)
+		}
+		w.out.WriteString(props)
+		w.out.WriteString("</w:pPr>")
+	}
+	content()
+	w.out.WriteString("</w:p>\n")
+}
+
+// blocks writes block nodes with style for plain paragraphs, indented to the
+// list level.
+func (w *docxWriter) blocks(nodes []*markdown.Node, style string, level int) {
+	for _, n := range nodes {
+		w.block(n, style, 0, level)
+	}
+}
+
+// block writes a block node. style is the paragraph style of plain
+// paragraphs; a non-zero numID numbers the paragraph as an item of that list
+// at level, otherwise level indents it.
+func (w *docxWriter) block(n *markdown.Node, style string, numID, level int) {
+	props := ""
+	if numID > 0 {
+		props = fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, level, numID)
+		style = "ListParagraph"
+	} else if level > 0 {
+		props = fmt.Sprintf(`<w:ind w:left="%d"/>`, 720*level)
+	}
+
+	switch n.Kind {
+	case markdown.Heading:
+		w.paragraph(fmt.Sprintf("Heading%d", headingLevel(n.Level, w.offset)), "", func() { w.inlines(n.Children, runProps{}) })
+	case markdown.Paragraph:
+		w.paragraph(style, props, func() { w.inlines(n.Children, runProps{}) })
+	case markdown.CodeBlock:
+		w.paragraph("Code", props, func() {
+			lines := strings.Split(strings.TrimSuffix(n.Literal, "\n"), "\n")
+			for i, line := range lines {
+				if i > 0 {
+					w.out.WriteString("<w:r><w:br/></w:r>")
+				}
+				w.text(line, runProps{})
+			}
+		})
+	case markdown.BlockQuote:
+		w.blocks(n.Children, "Quote", level)
+	case markdown.List:
+		id := bulletNumID
+		if n.Ordered {
+			w.lists = append(w.lists, n.Start)
+			id = bulletNumID + len(w.lists)
+		}
+		for _, item := range n.Children {
+			w.listItem(item, id, level)
+		}
+	case markdown.Table:
+		w.table(n)
+	case markdown.ThematicBreak:
+		w.paragraph("", `<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr>`, func() {})
+	case markdown.HTMLBlock, markdown.FootnoteDef:
+		// Raw HTML has no Word equivalent; footnotes are written where
+		// they are referenced.
+	}
+}
+
+func (w *docxWriter) listItem(item *markdown.Node, numID, level int) {
+	first := true
+	for _, child := range item.Children {
+		if child.Kind == markdown.List {
+			w.block(child, "ListParagraph", 0, level+1)
+			continue
+		}
+		if first {
+			w.block(child, "", numID, level)
+			first = false
+		} else {
+			w.block(child, "", 0, level+1)
+		}
+	}
+	if first {
+		w.paragraph("ListParagraph", fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, level, numID), func() {})
+	}
+}
+
+func (w *docxWriter) table(n *markdown.Node) {
+	if len(n.Children) == 0 {
+		return
+	}
+	w.out.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr><w:tblGrid>`)
+	for range n.Children[0].Children {
+		w.out.WriteString("<w:gridCol/>")
+	}
+	w.out.WriteString("</w:tblGrid>\n")
+	for _, row := range n.Children {
+		w.out.WriteString("<w:tr>")
+		if row.Header {
+			w.out.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for _, cell := range row.Children {
+			props := ""
+			switch cell.Align {
+			case markdown.AlignCenter:
+				props = `<w:jc w:val="center"/>`
+			case markdown.AlignRight:
+				props = `<w:jc w:val="right"/>`
+			}
+			w.out.WriteString(`<w:tc><w:tcPr><w:tcW w:w="0" w:type="auto"/></w:tcPr>`)
+			w.paragraph("", props, func() { w.inlines(cell.Children, runProps{bold: row.Header}) })
+			w.out.WriteString("</w:tc>")
+		}
+		w.out.WriteString("</w:tr>\n")
+	}
+	w.out.WriteString("</w:tbl>\n")
+	// Word needs a paragraph between a table and whatever follows it.
+	w.paragraph("", "", func() {})
+}
+
+func (w *docxWriter) inlines(nodes []*markdown.Node, props runProps) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case markdown.Text:
+			w.text(n.Literal, props)
+		case markdown.SoftBreak:
+			w.text(" ", props)
+		case markdown.HardBreak:
+			w.out.WriteString("<w:r><w:br/></w:r>")
+		case markdown.Emphasis:
+			p := props
+			p.italic = true
+			w.inlines(n.Children, p)
+		case markdown.Strong:
+			p := props
+			p.bold = true
+			w.inlines(n.Children, p)
+		case markdown.Strikethrough:
+			p := props
+			p.strike = true
+			w.inlines(n.Children, p)
+		case markdown.Code:
+			p := props
+			p.code = true
+			w.text(n.Literal, p)
+		case markdown.Link:
+			if w.inNote && !strings.HasPrefix(n.Dest, "#") {
+				// Footnotes have no relationships of their own to
+				// point at, so the address is spelled out.
+				w.inlines(n.Children, props)
+				if n.PlainText() != n.Dest {
+					w.text(" ("+n.Dest+")", props)
+				}
+				continue
+			}
+			p := props
+			p.style = "Hyperlink"
+			if strings.HasPrefix(n.Dest, "#") {
+				fmt.Fprintf(w.out, `<w:hyperlink w:anchor="%s">`, bookmarkName(n.Dest[1:]))
+			} else {
+				w.links = append(w.links, n.Dest)
+				fmt.Fprintf(w.out, `<w:hyperlink r:id="rIdLink%d">`, len(w.links))
+			}
+			w.inlines(n.Children, p)
+			w.out.WriteString("</w:hyperlink>")
+		case markdown.Image:
+			if w.image(n.Dest, n.PlainText()) {
+				continue
+			}
+			p := props
+			p.italic = true
+			w.text("[Image: "+n.PlainText()+"]", p)
+		case markdown.FootnoteRef:
+			w.footnoteRef(n.Label, props)
+		}
+	}
+}
+
+func (w *docxWriter) footnoteRef(label string, props runProps) {
+	def, ok := w.defs[label]
+	if !ok || w.inNote {
+		w.text("[^"+label+"]", props)
+		return
+	}
+	w.notes++
+	id := w.notes
+	fmt.Fprintf(w.out, `<w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteReference w:id="%d"/></w:r>`, id)
+
+	// Render the note on its own, then move it to the footnotes.
+	body := w.out
+	var note bytes.Buffer
+	w.out = &note
+	w.inNote = true
+	for i, child := range def.Children {
+		w.out.WriteString(`<w:p><w:pPr><w:pStyle w:val="FootnoteText"/></w:pPr>`)
+		if i == 0 {
+			w.out.WriteString(`<w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteRef/></w:r><w:r><w:t xml:space="preserve"> </w:t></w:r>`)
+		}
+		if child.Kind == markdown.Paragraph {
+			w.inlines(child.Children, runProps{})
+		} else {
+			w.text(child.PlainText(), runProps{})
+		}
+		w.out.WriteString("</w:p>")
+	}
+	w.inNote = false
+	w.out = body
+	fmt.Fprintf(&w.footnotes, "<w:footnote w:id=\"%d\">%s</w:footnote>\n", id, note.String())
+}
+
+// Image sizes in EMUs: a pixel at 96 DPI, and the width between the page
+// margins.
+const (
+	emuPerPixel  = 9525
+	maxImageSize = 5486400
+)
+
+// image embeds an asset of the book as an inline picture, and reports false
+// when it cannot, for images that are not assets, SVGs, or images in
+// footnotes, which have no relationships of their own.
+func (w *docxWriter) image(name, alt string) bool {
+	data, ok := w.assets[name]
+	if !ok || w.inNote {
+		return false
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return false
+	}
+	cx, cy := config.Width*emuPerPixel, config.Height*emuPerPixel
+	if cx > maxImageSize {
+		cx, cy = maxImageSize, cy*maxImageSize/cx
+	}
+
+	id := 0
+	for i, m := range w.media {
+		if m == name {
+			id = i + 1
+		}
+	}
+	if id == 0 {
+		w.media = append(w.media, name)
+		id = len(w.media)
+	}
+	w.bookmarks++
+	fmt.Fprintf(w.out, docxPicture, cx, cy, w.bookmarks, esc(xmlText(alt)), path.Base(name), id)
+	return true
+}
+
+// text writes a run of text with the given formatting.
+func (w *docxWriter) text(s string, props runProps) {
+	w.out.WriteString("<w:r>")
+	if props != (runProps{}) {
+		w.out.WriteString("<w:rPr>")
+		if props.code {
+			w.out.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+		} else if props.style != "" {
+			fmt.Fprintf(w.out, `<w:rStyle w:val="%s"/>`, props.style)
+		}
+		if props.bold {
+			w.out.WriteString("<w:b/>")
+		}
+		if props.italic {
+			w.out.WriteString("<w:i/>")
+		}
+		if props.strike {
+			w.out.WriteString("<w:strike/>")
+		}
+		w.out.WriteString("</w:rPr>")
+	}
+	fmt.Fprintf(w.out, `<w:t xml:space="preserve">%s</w:t></w:r>`, esc(xmlText(s)))
+}
+
+// pack assembles the parts of the document into a .docx package.
+func (w *docxWriter) pack(title string, b *Book) ([]byte, error) {
+	parts := map[string]string{
+		"[Content_Types].xml":          docxContentTypes,
+		"_rels/.rels":                  docxRels,
+		"word/styles.xml":              docxStyles,
+		"word/numbering.xml":           w.numbering(),
+		"word/settings.xml":            docxSettings,
+		"word/document.xml":            docxDocumentStart + w.body.String() + docxDocumentEnd,
+		"word/footnotes.xml":           docxFootnotesStart + w.footnotes.String() + "</w:footnotes>\n",
+		"word/_rels/document.xml.rels": w.documentRels(),
+		"docProps/core.xml": fmt.Sprintf(docxCore, esc(title),
+			b.Date.UTC().Format("2006-01-02T15:04:05Z")),
+	}
+	order := []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml",
+		"word/numbering.xml", "word/settings.xml", "word/footnotes.xml", "word/_rels/document.xml.rels", "docProps/core.xml"}
+
+	for _, name := range w.media {
+		part := "word/media/" + path.Base(name)
+		parts[part] = string(w.assets[name])
+		order = append(order, part)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: b.Date})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write DOCX: %w", err)
+		}
+		_, err = f.Write([]byte(parts[name]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to write DOCX: %w", err)
+		}
+	}
+	err := zw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write DOCX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *docxWriter) documentRels() string {
+	var buf strings.Builder
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+<Relationship Id="rIdSettings" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings" Target="settings.xml"/>
+<Relationship Id="rIdFootnotes" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes" Target="footnotes.xml"/>
+`)
+	for i, link := range w.links {
+		fmt.Fprintf(&buf, "<Relationship Id=\"rIdLink%d\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink\" Target=\"%s\" TargetMode=\"External\"/>\n", i+1, esc(link))
+	}
+	for i, name := range w.media {
+		fmt.Fprintf(&buf, "<Relationship Id=\"rIdImage%d\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/image\" Target=\"media/%s\"/>\n", i+1, esc(path.Base(name)))
+	}
+	buf.WriteString("</Relationships>\n")
+	return buf.String()
+}
+
+func (w *docxWriter) numbering() string {
+	var buf strings.Builder
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+`)
+	bullets := []string{"•", "◦", "▪"}
+	buf.WriteString(`<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="hybridMultilevel"/>`)
+	for level := 0; level < 9; level++ {
+		fmt.Fprintf(&buf, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+			level, bullets[level%len(bullets)], 720*(level+1))
+	}
+	buf.WriteString("</w:abstractNum>\n")
+	formats := []string{"decimal", "lowerLetter", "lowerRoman"}
+	buf.WriteString(`<w:abstractNum w:abstractNumId="1"><w:multiLevelType w:val="hybridMultilevel"/>`)
+	for level := 0; level < 9; level++ {
+		fmt.Fprintf(&buf, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%%%d."/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+			level, formats[level%len(formats)], level+1, 720*(level+1))
+	}
+	buf.WriteString("</w:abstractNum>\n")
+
+	fmt.Fprintf(&buf, "<w:num w:numId=\"%d\"><w:abstractNumId w:val=\"0\"/></w:num>\n", bulletNumID)
+	for i, start := range w.lists {
+		fmt.Fprintf(&buf, "<w:num w:numId=\"%d\"><w:abstractNumId w:val=\"1\"/><w:lvlOverride w:ilvl=\"0\"><w:startOverride w:val=\"%d\"/></w:lvlOverride></w:num>\n", bulletNumID+i+1, start)
+	}
+	buf.WriteString("</w:numbering>\n")
+	return buf.String()
+}
+
+// bookmarkName turns an anchor into a Word bookmark name, which may only hold
+// letters, digits and underscores.
+func bookmarkName(anchor string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, anchor)
+}
+
+// xmlText drops control characters XML does not allow.
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpg" ContentType="image/jpeg"/>
+<Default Extension="jpeg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/word/settings.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml"/>
+<Override PartName="/word/footnotes.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footnotes+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>
+`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>
+`
+
+// docxSettings turns on tracked changes, so edits made in Word are kept as
+// revisions the author can review.
+const docxSettings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:trackRevisions/>
+</w:settings>
+`
+
+const docxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<dc:title>%s</dc:title>
+<dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created>
+</cp:coreProperties>
+`
+
+const docxDocumentStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">
+<w:body>
+`
+
+const docxDocumentEnd = `<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>
+</w:body>
+</w:document>
+`
+
+// docxPicture is an inline picture, given its size, a unique ID, its
+// description and file name, and the number of its relationship.
+const docxPicture = `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%[1]d" cy="%[2]d"/><wp:docPr id="%[3]d" name="Picture %[3]d" descr="%[4]s"/>` +
+	`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic><pic:nvPicPr><pic:cNvPr id="%[3]d" name="%[5]s"/><pic:cNvPicPr/></pic:nvPicPr>` +
+	`<pic:blipFill><a:blip r:embed="rIdImage%[6]d"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>` +
+	`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%[1]d" cy="%[2]d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`
+
+const docxFootnotesStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:footnotes xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<w:footnote w:type="separator" w:id="0"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>
+<w:footnote w:type="continuationSeparator" w:id="1"><w:p><w:r><w:continuationSeparator/></w:r></w:p></w:footnote>
+`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="character" w:default="1" w:styleId="DefaultParagraphFont"><w:name w:val="Default Paragraph Font"/><w:uiPriority w:val="1"/><w:semiHidden/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:before="2400" w:after="240"/><w:jc w:val="center"/></w:pPr><w:rPr><w:sz w:val="56"/><w:szCs w:val="56"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Subtitle"><w:name w:val="Subtitle"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:jc w:val="center"/></w:pPr><w:rPr><w:color w:val="595959"/><w:sz w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:pageBreakBefore/><w:spacing w:before="480" w:after="240"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/><w:szCs w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/><w:szCs w:val="30"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="80"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:i/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720" w:right="720"/></w:pPr><w:rPr><w:i/><w:color w:val="404040"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/><w:spacing w:after="160" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="19"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:basedOn w:val="DefaultParagraphFont"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="60"/><w:contextualSpacing/></w:pPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:basedOn w:val="DefaultParagraphFont"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="FootnoteText"><w:name w:val="footnote text"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="FootnoteReference"><w:name w:val="footnote reference"/><w:basedOn w:val="DefaultParagraphFont"/><w:rPr><w:vertAlign w:val="superscript"/></w:rPr></w:style>
+<w:style w:type="table" w:default="1" w:styleId="TableNormal"><w:name w:val="Normal Table"/><w:semiHidden/><w:tblPr><w:tblInd w:w="0" w:type="dxa"/><w:tblCellMar><w:top w:w="0" w:type="dxa"/><w:left w:w="108" w:type="dxa"/><w:bottom w:w="0" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:basedOn w:val="TableNormal"/><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders></w:tblPr></w:style>
+</w:styles>
+`
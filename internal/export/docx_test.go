@@ -0,0 +1,104 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readDocx(t *testing.T, data []byte) map[string]string {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open DOCX: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+		if strings.HasPrefix(f.Name, "word/media/") {
+			continue
+		}
+
+		d := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s is not well-formed: %v\n%s", f.Name, err, content)
+			}
+		}
+	}
+	return parts
+}
+
+func TestDOCX(t *testing.T) {
+	draft := "Some *new* **terms** & `code`.[^1]\n\n# Steps\n\n1. one\n2. two\n   - nested\n\n" +
+		"| A | B |\n|---|---|\n| 1 | 2 |\n\n```go\nx := 1\ny := 2\n```\n\nSee [Go](https://go.dev).\n\n[^1]: A note.\n"
+	book := &Book{
+		Title: "Learning Go",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{
+			{ID: "ch1", Number: 1, Title: "Basics", Sections: []Section{NewSection("section1", "Terms", draft)}},
+			{ID: "ch2", Number: 2, Title: "Types", Sections: []Section{{ID: "section1", Title: "Structs"}}},
+		},
+	}
+
+	data, err := DOCX(book)
+	if err != nil {
+		t.Fatalf("DOCX returned error: %v", err)
+	}
+	parts := readDocx(t, data)
+	doc := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Learning Go</w:t>`,
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:bookmarkStart w:id="1" w:name="ch1"/>`,
+		`<w:bookmarkStart w:id="2" w:name="ch1_section1"/>`,
+		`<w:pStyle w:val="Heading3"/></w:pPr><w:r><w:t xml:space="preserve">Steps</w:t>`,
+		`<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">new</w:t></w:r>`,
+		`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">terms</w:t></w:r><w:r><w:t xml:space="preserve"> &amp; </w:t></w:r>`,
+		`<w:rStyle w:val="CodeChar"/>`,
+		`<w:footnoteReference w:id="2"/>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr>`,
+		`<w:tblHeader/>`,
+		`<w:pStyle w:val="Code"/></w:pPr><w:r><w:t xml:space="preserve">x := 1</w:t></w:r><w:r><w:br/></w:r>`,
+		`<w:hyperlink r:id="rIdLink1">`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected document to contain %q", want)
+		}
+	}
+	if !strings.Contains(parts["word/footnotes.xml"], `<w:footnote w:id="2">`) || !strings.Contains(parts["word/footnotes.xml"], "A note.") {
+		t.Errorf("Expected the footnote, got:\n%s", parts["word/footnotes.xml"])
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="https://go.dev" TargetMode="External"`) {
+		t.Errorf("Expected a hyperlink relationship, got:\n%s", parts["word/_rels/document.xml.rels"])
+	}
+
+	if !strings.Contains(parts["word/settings.xml"], "<w:trackRevisions/>") || !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="settings.xml"`) {
+		t.Errorf("Expected tracked changes to be on, got:\n%s", parts["word/settings.xml"])
+	}
+
+	files, err := DOCXChapters(book)
+	if err != nil {
+		t.Fatalf("DOCXChapters returned error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected one document per chapter, got %d", len(files))
+	}
+	second := readDocx(t, files["ch2.docx"])["word/document.xml"]
+	if !strings.Contains(second, "Chapter 2: Types") || strings.Contains(second, "Chapter 1: Basics") {
+		t.Errorf("Expected only the second chapter in ch2.docx")
+	}
+}
@@ -0,0 +1,265 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"fmt"
+	"hash/crc32"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed themes/epub.css
+var epubTheme string
+
+const xhtmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%[1]s" lang="%[1]s">
+<head>
+<meta charset="UTF-8"/>
+<title>%[2]s</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+`
+
+var imageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+}
+
+func init() {
+	Register("epub", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		data, err := EPUB(b, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{File: data}, nil
+	}))
+}
+
+type epubItem struct {
+	id, href, mediaType, properties string
+	data                            []byte
+	spine                           bool
+}
+
+// EPUB writes the book as an EPUB 3 package with a cover, title page, a nav
+// document and NCX for older readers, and one XHTML file per chapter.
+func EPUB(b *Book, opts Options) ([]byte, error) {
+	lang := "en"
+	css, err := readStylesheet(opts, epubTheme)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []epubItem
+	items = append(items, epubItem{id: "css", href: "style.css", mediaType: "text/css", data: []byte(css)})
+
+	ext, cover, err := readCover(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cover != nil {
+		href := "images/cover" + ext
+		items = append(items, epubItem{id: "cover-image", href: href, mediaType: imageTypes[ext], properties: "cover-image", data: cover})
+
+		var page bytes.Buffer
+		fmt.Fprintf(&page, xhtmlHeader, lang, "Cover")
+		fmt.Fprintf(&page, "<body class=\"cover\">\n<section epub:type=\"cover\">\n<img src=\"%s\" alt=\"%s\"/>\n</section>\n</body>\n</html>\n", href, esc(b.Title))
+		items = append(items, epubItem{id: "cover", href: "cover.xhtml", mediaType: "application/xhtml+xml", data: page.Bytes(), spine: true})
+	}
+
+	var title bytes.Buffer
+	fmt.Fprintf(&title, xhtmlHeader, lang, esc(b.Title))
+	fmt.Fprintf(&title, "<body>\n<section class=\"title-page\" epub:type=\"titlepage\">\n<h1>%s</h1>\n", esc(b.Title))
+	if !b.Drafted() {
+		title.WriteString("<p>Draft</p>\n")
+	}
+	fmt.Fprintf(&title, "<p>%s</p>\n</section>\n</body>\n</html>\n", b.Date.Format("January 2, 2006"))
+	items = append(items, epubItem{id: "title", href: "title.xhtml", mediaType: "application/xhtml+xml", data: title.Bytes(), spine: true})
+
+	items = append(items, epubItem{id: "nav", href: "nav.xhtml", mediaType: "application/xhtml+xml", properties: "nav", data: epubNav(b, lang), spine: true})
+	items = append(items, epubItem{id: "ncx", href: "toc.ncx", mediaType: "application/x-dtbncx+xml", data: epubNCX(b)})
+
+	names := make([]string, 0, len(b.Assets))
+	for name := range b.Assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		mediaType := imageTypes[strings.ToLower(path.Ext(name))]
+		items = append(items, epubItem{id: fmt.Sprintf("image-%d", i+1), href: name, mediaType: mediaType, data: b.Assets[name]})
+	}
+
+	r := &htmlRenderer{xhtml: true, page: "%s.xhtml"}
+	r.linkTerms(b)
+	for _, ch := range b.Chapters {
+		var page bytes.Buffer
+		fmt.Fprintf(&page, xhtmlHeader, lang, esc(ch.Heading()))
+		page.WriteString("<body>\n")
+		r.chapter(&page, ch)
+		page.WriteString("</body>\n</html>\n")
+		items = append(items, epubItem{id: ch.Anchor(), href: ch.Anchor() + ".xhtml", mediaType: "application/xhtml+xml", data: page.Bytes(), spine: true})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// The mimetype comes first and uncompressed, so readers can identify
+	// the file from its first bytes.
+	mimetype := []byte("application/epub+zip")
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write EPUB: %w", err)
+	}
+	w.Write(mimetype)
+
+	add := func(name string, data []byte) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: b.Date})
+		if err != nil {
+			return fmt.Errorf("failed to write EPUB: %w", err)
+		}
+		_, err = w.Write(data)
+		if err != nil {
+			return fmt.Errorf("failed to write EPUB: %w", err)
+		}
+		return nil
+	}
+	err = add("META-INF/container.xml", []byte(epubContainer))
+	if err != nil {
+		return nil, err
+	}
+	err = add("OEBPS/content.opf", epubPackage(b, lang, items))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		err = add("OEBPS/"+item.href, item.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write EPUB: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+func epubPackage(b *Book, lang string, items []epubItem) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(&buf, "<package xmlns=\"http://www.idpf.org/2007/opf\" version=\"3.0\" unique-identifier=\"book-id\" xml:lang=\"%s\">\n", lang)
+	buf.WriteString("<metadata xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	fmt.Fprintf(&buf, "<dc:identifier id=\"book-id\">urn:uuid:%s</dc:identifier>\n", bookUUID(b.Title))
+	fmt.Fprintf(&buf, "<dc:title>%s</dc:title>\n", esc(b.Title))
+	fmt.Fprintf(&buf, "<dc:language>%s</dc:language>\n", lang)
+	fmt.Fprintf(&buf, "<dc:date>%s</dc:date>\n", b.Date.Format("2006-01-02"))
+	fmt.Fprintf(&buf, "<meta property=\"dcterms:modified\">%s</meta>\n", b.Date.UTC().Format("2006-01-02T15:04:05Z"))
+	for _, item := range items {
+		if item.id == "cover-image" {
+			// For EPUB 2 readers.
+			buf.WriteString("<meta name=\"cover\" content=\"cover-image\"/>\n")
+		}
+	}
+	buf.WriteString("</metadata>\n<manifest>\n")
+	for _, item := range items {
+		fmt.Fprintf(&buf, "<item id=\"%s\" href=\"%s\" media-type=\"%s\"", item.id, item.href, item.mediaType)
+		if item.properties != "" {
+			fmt.Fprintf(&buf, " properties=\"%s\"", item.properties)
+		}
+		buf.WriteString("/>\n")
+	}
+	buf.WriteString("</manifest>\n<spine toc=\"ncx\">\n")
+	for _, item := range items {
+		if item.spine {
+			fmt.Fprintf(&buf, "<itemref idref=\"%s\"/>\n", item.id)
+		}
+	}
+	buf.WriteString("</spine>\n</package>\n")
+	return buf.Bytes()
+}
+
+func epubNav(b *Book, lang string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, xhtmlHeader, lang, "Contents")
+	buf.WriteString("<body>\n<nav epub:type=\"toc\" id=\"toc\">\n<h1>Contents</h1>\n<ol>\n")
+	for _, ch := range b.Chapters {
+		fmt.Fprintf(&buf, "<li><a href=\"%s.xhtml\">%s</a>", ch.Anchor(), esc(ch.Heading()))
+		if len(ch.Sections) > 0 {
+			buf.WriteString("\n<ol>\n")
+			for _, sec := range ch.Sections {
+				fmt.Fprintf(&buf, "<li><a href=\"%s.xhtml#%s\">%s</a></li>\n", ch.Anchor(), ch.SectionAnchor(sec), esc(sec.Title))
+			}
+			buf.WriteString("</ol>\n")
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ol>\n</nav>\n")
+	if len(b.Chapters) > 0 {
+		// The body of the book starts at its first numbered chapter.
+		start := b.Chapters[0]
+		for _, ch := range b.Chapters {
+			if ch.Matter == "" {
+				start = ch
+				break
+			}
+		}
+		buf.WriteString("<nav epub:type=\"landmarks\" hidden=\"hidden\">\n<ol>\n")
+		buf.WriteString("<li><a epub:type=\"toc\" href=\"nav.xhtml\">Contents</a></li>\n")
+		fmt.Fprintf(&buf, "<li><a epub:type=\"bodymatter\" href=\"%s.xhtml\">Start</a></li>\n", start.Anchor())
+		buf.WriteString("</ol>\n</nav>\n")
+	}
+	buf.WriteString("</body>\n</html>\n")
+	return buf.Bytes()
+}
+
+func epubNCX(b *Book) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	buf.WriteString("<ncx xmlns=\"http://www.daisy.org/z3986/2005/ncx/\" version=\"2005-1\">\n<head>\n")
+	fmt.Fprintf(&buf, "<meta name=\"dtb:uid\" content=\"urn:uuid:%s\"/>\n", bookUUID(b.Title))
+	buf.WriteString("<meta name=\"dtb:depth\" content=\"2\"/>\n</head>\n")
+	fmt.Fprintf(&buf, "<docTitle><text>%s</text></docTitle>\n<navMap>\n", esc(b.Title))
+	order := 0
+	for _, ch := range b.Chapters {
+		order++
+		fmt.Fprintf(&buf, "<navPoint id=\"nav-%s\" playOrder=\"%d\">\n<navLabel><text>%s</text></navLabel>\n<content src=\"%s.xhtml\"/>\n", ch.Anchor(), order, esc(ch.Heading()), ch.Anchor())
+		for _, sec := range ch.Sections {
+			order++
+			fmt.Fprintf(&buf, "<navPoint id=\"nav-%s\" playOrder=\"%d\">\n<navLabel><text>%s</text></navLabel>\n<content src=\"%s.xhtml#%s\"/>\n</navPoint>\n", ch.SectionAnchor(sec), order, esc(sec.Title), ch.Anchor(), ch.SectionAnchor(sec))
+		}
+		buf.WriteString("</navPoint>\n")
+	}
+	buf.WriteString("</navMap>\n</ncx>\n")
+	return buf.Bytes()
+}
+
+// bookUUID derives a stable identifier from the title, so re-exports of a
+// book replace the earlier copy on a reader instead of adding another.
+func bookUUID(title string) string {
+	sum := sha1.Sum([]byte("bookcli:" + title))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
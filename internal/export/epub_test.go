@@ -0,0 +1,85 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEPUB(t *testing.T) {
+	book := &Book{
+		Title: "Learning <Go>",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{{
+			ID:     "ch1",
+			Number: 1,
+			Title:  "Basics",
+			Sections: []Section{
+				NewSection("section1", "Variables", "Use `var` & friends.[^1]\n\nLine<br>break.\n\n[^1]: Or `:=`.\n"),
+				{ID: "section2", Title: "Constants"},
+			},
+		}},
+	}
+
+	data, err := EPUB(book, Options{})
+	if err != nil {
+		t.Fatalf("EPUB returned error: %v", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open EPUB: %v", err)
+	}
+
+	first := r.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store {
+		t.Fatalf("Expected an uncompressed mimetype first, got %s", first.Name)
+	}
+
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+
+		if strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".ncx") || strings.HasSuffix(f.Name, ".xml") {
+			d := xml.NewDecoder(bytes.NewReader(content))
+			for {
+				_, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s is not well-formed: %v\n%s", f.Name, err, content)
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/style.css", "OEBPS/ch1.xhtml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the EPUB", name)
+		}
+	}
+	if !strings.Contains(files["OEBPS/nav.xhtml"], `href="ch1.xhtml#ch1-section1"`) {
+		t.Errorf("Expected the nav document to link sections, got:\n%s", files["OEBPS/nav.xhtml"])
+	}
+	chapter := files["OEBPS/ch1.xhtml"]
+	for _, want := range []string{
+		`<h1>Chapter 1: Basics</h1>`,
+		`<code>var</code> &amp; friends.<sup><a href="#fn-ch1-section1-1"`,
+		`Line&lt;br&gt;break.`,
+		`<li id="fn-ch1-section1-1" epub:type="footnote"><p>Or <code>:=</code>.`,
+	} {
+		if !strings.Contains(chapter, want) {
+			t.Errorf("Expected chapter to contain %q, got:\n%s", want, chapter)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Output is what an exporter writes.
+type Output struct {
+	// File is the export of a single-file format.
+	File []byte
+	// Files holds the files of a multi-file format by slash-separated path,
+	// or the files a single-file export refers to, which go beside it.
+	Files map[string][]byte
+}
+
+// Exporter writes a book in one format. A new format is a file that
+// implements Exporter and registers it from init.
+type Exporter interface {
+	Export(b *Book, opts Options) (*Output, error)
+}
+
+// ExporterFunc lets a plain function be used as an Exporter.
+type ExporterFunc func(b *Book, opts Options) (*Output, error)
+
+func (f ExporterFunc) Export(b *Book, opts Options) (*Output, error) {
+	return f(b, opts)
+}
+
+var exporters = map[string]Exporter{}
+
+// Register makes an exporter available under a format name. It panics when
+// the name is already taken.
+func Register(format string, e Exporter) {
+	if _, ok := exporters[format]; ok {
+		panic(fmt.Sprintf("export format %q registered twice", format))
+	}
+	exporters[format] = e
+}
+
+// Lookup returns the exporter registered for a format.
+func Lookup(format string) (Exporter, bool) {
+	e, ok := exporters[format]
+	return e, ok
+}
+
+// Formats returns the names of the registered formats in sorted order.
+func Formats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
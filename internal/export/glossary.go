@@ -0,0 +1,58 @@
+package export
+
+import (
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/state"
+	"strings"
+)
+
+// GlossaryID is the ID of the glossary chapter of a book.
+const GlossaryID = "glossary"
+
+// AddGlossary adds a glossary of terms to the back matter of the book. A book
+// without terms gets no glossary.
+func (b *Book) AddGlossary(terms []glossary.Term) {
+	if len(terms) == 0 {
+		return
+	}
+	b.addBackMatter(Chapter{ID: GlossaryID, Title: "Glossary", Matter: GlossaryID, Back: true, Terms: terms})
+}
+
+// addBackMatter adds a chapter to the end of the back matter, before the page
+// about the author.
+func (b *Book) addBackMatter(ch Chapter) {
+	i := len(b.Chapters)
+	if i > 0 && b.Chapters[i-1].Matter == state.MatterAboutAuthor {
+		i--
+	}
+	b.Chapters = append(b.Chapters[:i], append([]Chapter{ch}, b.Chapters[i:]...)...)
+}
+
+// glossaryPage returns the chapter of the book holding its glossary, if any.
+func (b *Book) glossaryPage() (Chapter, bool) {
+	for _, ch := range b.Chapters {
+		if ch.Matter == GlossaryID {
+			return ch, true
+		}
+	}
+	return Chapter{}, false
+}
+
+// TermAnchor is the link target of a glossary term within an export.
+func TermAnchor(term string) string {
+	var buf strings.Builder
+	buf.WriteString("term")
+	dash := true
+	for _, r := range strings.ToLower(term) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash {
+				buf.WriteByte('-')
+			}
+			buf.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,170 @@
+package export
+
+import (
+	"strings"
+)
+
+// codeLanguage describes enough of a language's lexical syntax to color its
+// keywords, strings, comments and numbers.
+type codeLanguage struct {
+	keywords     []string
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	// multiline quotes may span lines, like Go raw strings.
+	multiline string
+}
+
+var (
+	cLike = codeLanguage{lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`}
+	hash  = codeLanguage{lineComments: []string{"#"}, quotes: `"'`}
+)
+
+func withKeywords(base codeLanguage, multiline string, keywords string) *codeLanguage {
+	base.keywords = strings.Fields(keywords)
+	base.multiline = multiline
+	base.quotes += multiline
+	return &base
+}
+
+var codeLanguages = map[string]*codeLanguage{
+	"go": withKeywords(cLike, "`", `break case chan const continue default defer else fallthrough for func go goto if
+		import interface map package range return select struct switch type var nil true false iota`),
+	"python": withKeywords(hash, "", `and as assert async await break class continue def del elif else except False
+		finally for from global if import in is lambda None nonlocal not or pass raise return True try while with yield`),
+	"javascript": withKeywords(cLike, "`", `async await break case catch class const continue debugger default delete
+		do else export extends false finally for function if import in instanceof let new null of return super switch
+		this throw true try typeof undefined var void while yield interface type enum implements`),
+	"java": withKeywords(cLike, "", `abstract boolean break byte case catch char class const continue default do double
+		else enum extends final finally float for if implements import instanceof int interface long new null package
+		private protected public return short static super switch this throw throws true false try void volatile while var`),
+	"c": withKeywords(cLike, "", `auto break case char class const continue default delete do double else enum extern
+		float for if include define inline int long namespace new nullptr private protected public return short signed
+		sizeof static struct switch template this typedef union unsigned using virtual void volatile while true false`),
+	"rust": withKeywords(cLike, "", `as async await break const continue crate else enum extern false fn for if impl in
+		let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while`),
+	"shell": withKeywords(hash, "", `if then else elif fi case esac for while until do done in function return export
+		local echo cd exit set unset`),
+	"sql": withKeywords(codeLanguage{lineComments: []string{"--"}, blockComment: [2]string{"/*", "*/"}, quotes: `'"`}, "", `select from
+		where and or not insert into values update set delete create table drop alter index join left right inner outer
+		on group by order having limit as null is in primary key foreign references distinct union`),
+	"yaml": withKeywords(hash, "", `true false null yes no`),
+}
+
+func init() {
+	aliases := map[string]string{
+		"golang": "go", "py": "python", "js": "javascript", "ts": "javascript", "typescript": "javascript",
+		"jsx": "javascript", "tsx": "javascript", "json": "javascript", "kotlin": "java", "cpp": "c",
+		"c++": "c", "h": "c", "cs": "c", "csharp": "c", "rs": "rust", "sh": "shell", "bash": "shell",
+		"zsh": "shell", "console": "shell", "yml": "yaml", "toml": "yaml", "ruby": "python", "rb": "python",
+	}
+	for alias, name := range aliases {
+		codeLanguages[alias] = codeLanguages[name]
+	}
+}
+
+// highlight returns code as escaped HTML with its tokens wrapped in spans, and
+// false when the language is not known.
+func highlight(code, lang string) (string, bool) {
+	spec, ok := codeLanguages[strings.ToLower(lang)]
+	if !ok {
+		return "", false
+	}
+	keywords := make(map[string]bool, len(spec.keywords))
+	for _, kw := range spec.keywords {
+		keywords[kw] = true
+	}
+	caseless := spec == codeLanguages["sql"]
+
+	var buf strings.Builder
+	span := func(class, text string) {
+		buf.WriteString(`<span class="tok-` + class + `">` + esc(text) + `</span>`)
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if prefix := hasAnyPrefix(rest, spec.lineComments); prefix != "" {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			span("com", rest[:end])
+			i += end
+			continue
+		}
+		if open := spec.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			end := strings.Index(rest[len(open):], spec.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(open) + len(spec.blockComment[1])
+			}
+			span("com", rest[:end])
+			i += end
+			continue
+		}
+
+		c := rest[0]
+		switch {
+		case strings.IndexByte(spec.quotes, c) >= 0:
+			multiline := strings.IndexByte(spec.multiline, c) >= 0
+			end := 1
+			for end < len(rest) {
+				if rest[end] == '\\' && !multiline {
+					end += 2
+					continue
+				}
+				if rest[end] == c {
+					end++
+					break
+				}
+				if rest[end] == '\n' && !multiline {
+					break
+				}
+				end++
+			}
+			if end > len(rest) {
+				end = len(rest)
+			}
+			span("str", rest[:end])
+			i += end
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(code[i-1])):
+			end := 1
+			for end < len(rest) && (isIdentByte(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			span("num", rest[:end])
+			i += end
+		case isIdentByte(c):
+			end := 1
+			for end < len(rest) && isIdentByte(rest[end]) {
+				end++
+			}
+			word := rest[:end]
+			if keywords[word] || (caseless && keywords[strings.ToLower(word)]) {
+				span("kw", word)
+			} else {
+				buf.WriteString(esc(word))
+			}
+			i += end
+		default:
+			buf.WriteString(esc(rest[:1]))
+			i++
+		}
+	}
+	return buf.String(), true
+}
+
+func hasAnyPrefix(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
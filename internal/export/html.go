@@ -0,0 +1,450 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/markdown"
+	"go-book-ai/internal/state"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// htmlRenderer renders parsed drafts as HTML. With xhtml set the output is
+// well-formed XML for EPUB: raw HTML from drafts that is not well-formed is
+// escaped, and footnotes carry EPUB semantics.
+type htmlRenderer struct {
+	xhtml bool
+	// highlight colors code blocks in known languages.
+	highlight bool
+
+	// offset is the heading level just above the headings of a draft.
+	offset int
+	// prefix keeps footnote IDs of different sections apart.
+	prefix    string
+	defs      map[string]*markdown.Node
+	footnotes []footnote
+	escapeRaw bool
+
+	// page is the file name of a chapter's page, a format taking its
+	// anchor.
+	page string
+	// terms are linked to their entries on glossaryPage where the book
+	// first uses them, outside headings and links.
+	terms        []glossary.Term
+	glossaryPage string
+	linked       map[string]bool
+	plain        bool
+}
+
+// linkTerms makes the renderer link the first use of each glossary term of
+// the book to its entry.
+func (r *htmlRenderer) linkTerms(b *Book) {
+	ch, ok := b.glossaryPage()
+	if !ok {
+		return
+	}
+	r.terms, r.glossaryPage, r.linked = ch.Terms, fmt.Sprintf(r.page, ch.Anchor()), map[string]bool{}
+}
+
+type footnote struct {
+	id   string
+	def  *markdown.Node
+	refs int
+}
+
+// epubTypes gives the EPUB semantics of each kind of front and back matter.
+var epubTypes = map[string]string{
+	state.MatterPreface:          "preface",
+	state.MatterAcknowledgements: "acknowledgments",
+	state.MatterIntroduction:     "introduction",
+	state.MatterHowToRead:        "frontmatter",
+	state.MatterConclusion:       "conclusion",
+	state.MatterAboutAuthor:      "backmatter",
+	GlossaryID:                   "glossary",
+	IndexID:                      "index",
+}
+
+// chapter renders a chapter with its sections and the footnotes of its
+// drafts at the end.
+func (r *htmlRenderer) chapter(w *bytes.Buffer, ch Chapter) {
+	r.footnotes = nil
+	kind, class := "chapter", "chapter"
+	if ch.Matter != "" {
+		kind, class = epubTypes[ch.Matter], "matter"
+		if kind == "" {
+			kind = "frontmatter"
+		}
+	}
+	if r.xhtml {
+		fmt.Fprintf(w, "<section epub:type=\"%s\" id=\"%s\">\n", kind, ch.Anchor())
+	} else {
+		fmt.Fprintf(w, "<section class=\"%s\" id=\"%s\">\n", class, ch.Anchor())
+	}
+	fmt.Fprintf(w, "<h1>%s</h1>\n", esc(ch.Heading()))
+	if ch.Matter != "" && ch.Body.Doc != nil {
+		r.offset = 1
+		r.section(w, ch.Anchor(), ch.Body)
+	}
+	if len(ch.Terms) > 0 {
+		r.glossary(w, ch.Terms)
+	}
+	if len(ch.Index) > 0 {
+		r.index(w, ch.Index)
+	}
+	r.offset = 2
+	for _, sec := range ch.Sections {
+		fmt.Fprintf(w, "<section id=\"%s\">\n<h2>%s</h2>\n", ch.SectionAnchor(sec), esc(sec.Title))
+		if sec.Content == "" {
+			w.WriteString("<p class=\"undrafted\"><em>This section has not been drafted yet.</em></p>\n")
+		} else {
+			r.section(w, ch.SectionAnchor(sec), sec)
+		}
+		w.WriteString("</section>\n")
+	}
+	r.writeFootnotes(w)
+	w.WriteString("</section>\n")
+}
+
+// section renders the draft of a section, whose headings start at level one,
+// below the section heading.
+func (r *htmlRenderer) section(w *bytes.Buffer, prefix string, sec Section) {
+	r.prefix = prefix
+	r.defs = sec.Notes
+
+	for _, block := range sec.Doc.Children {
+		if !r.xhtml {
+			r.block(w, block, false)
+			continue
+		}
+		var buf bytes.Buffer
+		notes := append([]footnote(nil), r.footnotes...)
+		linked := map[string]bool{}
+		for term := range r.linked {
+			linked[term] = true
+		}
+		r.escapeRaw = false
+		r.block(&buf, block, false)
+		if !wellFormed(buf.Bytes()) {
+			buf.Reset()
+			r.footnotes, r.linked = notes, linked
+			r.escapeRaw = true
+			r.block(&buf, block, false)
+		}
+		w.Write(buf.Bytes())
+	}
+}
+
+// glossary renders the entries of a glossary as a definition list.
+func (r *htmlRenderer) glossary(w *bytes.Buffer, terms []glossary.Term) {
+	if r.xhtml {
+		w.WriteString("<dl epub:type=\"glossary\">\n")
+	} else {
+		w.WriteString("<dl class=\"glossary\">\n")
+	}
+	for _, t := range terms {
+		if r.xhtml {
+			fmt.Fprintf(w, "<dt epub:type=\"glossterm\" id=\"%s\"><dfn>%s</dfn></dt>\n<dd epub:type=\"glossdef\">%s</dd>\n", TermAnchor(t.Term), esc(t.Term), esc(t.Definition))
+		} else {
+			fmt.Fprintf(w, "<dt id=\"%s\"><dfn>%s</dfn></dt>\n<dd>%s</dd>\n", TermAnchor(t.Term), esc(t.Term), esc(t.Definition))
+		}
+	}
+	w.WriteString("</dl>\n")
+}
+
+// index renders the entries of the index under a heading per initial letter,
+// linking each to the sections that mention it.
+func (r *htmlRenderer) index(w *bytes.Buffer, entries []IndexEntry) {
+	group := ""
+	for _, entry := range entries {
+		if g := indexGroup(entry.Term); g != group {
+			if group != "" {
+				w.WriteString("</ul>\n")
+			}
+			group = g
+			fmt.Fprintf(w, "<h2>%s</h2>\n<ul class=\"index\">\n", esc(group))
+		}
+		fmt.Fprintf(w, "<li>%s", esc(entry.Term))
+		for _, ref := range entry.Refs {
+			fmt.Fprintf(w, ", <a href=\"%s#%s\">%s</a>", fmt.Sprintf(r.page, ref.Chapter), ref.Anchor, esc(ref.Label))
+		}
+		w.WriteString("</li>\n")
+	}
+	w.WriteString("</ul>\n")
+}
+
+func (r *htmlRenderer) blocks(w *bytes.Buffer, nodes []*markdown.Node, tight bool) {
+	for _, n := range nodes {
+		r.block(w, n, tight)
+	}
+}
+
+func (r *htmlRenderer) block(w *bytes.Buffer, n *markdown.Node, tight bool) {
+	switch n.Kind {
+	case markdown.Heading:
+		level := headingLevel(n.Level, r.offset)
+		fmt.Fprintf(w, "<h%d>", level)
+		r.plainInlines(w, n.Children)
+		fmt.Fprintf(w, "</h%d>\n", level)
+	case markdown.Paragraph:
+		if tight {
+			r.inlines(w, n.Children)
+			w.WriteString("\n")
+			return
+		}
+		w.WriteString("<p>")
+		r.inlines(w, n.Children)
+		w.WriteString("</p>\n")
+	case markdown.CodeBlock:
+		lang := n.Lang()
+		if lang != "" {
+			fmt.Fprintf(w, "<pre><code class=\"language-%s\">", esc(lang))
+		} else {
+			w.WriteString("<pre><code>")
+		}
+		code, ok := "", false
+		if r.highlight {
+			code, ok = highlight(n.Literal, lang)
+		}
+		if !ok {
+			code = esc(n.Literal)
+		}
+		w.WriteString(code)
+		w.WriteString("</code></pre>\n")
+	case markdown.BlockQuote:
+		w.WriteString("<blockquote>\n")
+		r.blocks(w, n.Children, false)
+		w.WriteString("</blockquote>\n")
+	case markdown.List:
+		tag := "ul"
+		if n.Ordered {
+			tag = "ol"
+		}
+		if n.Ordered && n.Start != 1 {
+			fmt.Fprintf(w, "<ol start=\"%d\">\n", n.Start)
+		} else {
+			fmt.Fprintf(w, "<%s>\n", tag)
+		}
+		for _, item := range n.Children {
+			w.WriteString("<li>")
+			r.blocks(w, item.Children, n.Tight)
+			w.WriteString("</li>\n")
+		}
+		fmt.Fprintf(w, "</%s>\n", tag)
+	case markdown.Table:
+		w.WriteString("<table>\n")
+		for i, row := range n.Children {
+			if i == 0 && row.Header {
+				w.WriteString("<thead>\n")
+			} else if i == 1 || (i == 0 && !row.Header) {
+				w.WriteString("<tbody>\n")
+			}
+			w.WriteString("<tr>")
+			cell := "td"
+			if row.Header {
+				cell = "th"
+			}
+			for _, c := range row.Children {
+				if style := alignStyle(c.Align); style != "" {
+					fmt.Fprintf(w, "<%s style=\"text-align: %s\">", cell, style)
+				} else {
+					fmt.Fprintf(w, "<%s>", cell)
+				}
+				r.inlines(w, c.Children)
+				fmt.Fprintf(w, "</%s>", cell)
+			}
+			w.WriteString("</tr>\n")
+			if i == 0 && row.Header {
+				w.WriteString("</thead>\n")
+			}
+		}
+		if len(n.Children) > 1 || (len(n.Children) == 1 && !n.Children[0].Header) {
+			w.WriteString("</tbody>\n")
+		}
+		w.WriteString("</table>\n")
+	case markdown.ThematicBreak:
+		w.WriteString("<hr/>\n")
+	case markdown.HTMLBlock:
+		if r.escapeRaw {
+			fmt.Fprintf(w, "<pre>%s</pre>\n", esc(n.Literal))
+		} else {
+			w.WriteString(n.Literal)
+		}
+	case markdown.FootnoteDef:
+		// Rendered with the footnotes of the chapter.
+	}
+}
+
+// plainInlines renders inline nodes without linking glossary terms.
+func (r *htmlRenderer) plainInlines(w *bytes.Buffer, nodes []*markdown.Node) {
+	plain := r.plain
+	r.plain = true
+	r.inlines(w, nodes)
+	r.plain = plain
+}
+
+// text writes text, linking the first use in the book of each glossary term
+// to its entry.
+func (r *htmlRenderer) text(w *bytes.Buffer, s string) {
+	for !r.plain {
+		at, term := -1, ""
+		for _, t := range r.terms {
+			if r.linked[strings.ToLower(t.Term)] {
+				continue
+			}
+			if i := glossary.Find(s, t.Term); i >= 0 && (at < 0 || i < at || i == at && len(t.Term) > len(term)) {
+				at, term = i, t.Term
+			}
+		}
+		if at < 0 {
+			break
+		}
+		r.linked[strings.ToLower(term)] = true
+		fmt.Fprintf(w, "%s<a class=\"term\" href=\"%s#%s\">%s</a>", esc(s[:at]), r.glossaryPage, TermAnchor(term), esc(s[at:at+len(term)]))
+		s = s[at+len(term):]
+	}
+	w.WriteString(esc(s))
+}
+
+func (r *htmlRenderer) inlines(w *bytes.Buffer, nodes []*markdown.Node) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case markdown.Text:
+			r.text(w, n.Literal)
+		case markdown.SoftBreak:
+			w.WriteString("\n")
+		case markdown.HardBreak:
+			w.WriteString("<br/>\n")
+		case markdown.Emphasis:
+			w.WriteString("<em>")
+			r.inlines(w, n.Children)
+			w.WriteString("</em>")
+		case markdown.Strong:
+			w.WriteString("<strong>")
+			r.inlines(w, n.Children)
+			w.WriteString("</strong>")
+		case markdown.Strikethrough:
+			w.WriteString("<del>")
+			r.inlines(w, n.Children)
+			w.WriteString("</del>")
+		case markdown.Code:
+			fmt.Fprintf(w, "<code>%s</code>", esc(n.Literal))
+		case markdown.Link:
+			fmt.Fprintf(w, "<a href=\"%s\"", esc(n.Dest))
+			if n.Title != "" {
+				fmt.Fprintf(w, " title=\"%s\"", esc(n.Title))
+			}
+			w.WriteString(">")
+			r.plainInlines(w, n.Children)
+			w.WriteString("</a>")
+		case markdown.Image:
+			fmt.Fprintf(w, "<img src=\"%s\" alt=\"%s\"", esc(n.Dest), esc(n.PlainText()))
+			if n.Title != "" {
+				fmt.Fprintf(w, " title=\"%s\"", esc(n.Title))
+			}
+			w.WriteString("/>")
+		case markdown.InlineHTML:
+			if r.escapeRaw {
+				w.WriteString(esc(n.Literal))
+			} else {
+				w.WriteString(n.Literal)
+			}
+		case markdown.FootnoteRef:
+			r.footnoteRef(w, n.Label)
+		}
+	}
+}
+
+func (r *htmlRenderer) footnoteRef(w *bytes.Buffer, label string) {
+	def, ok := r.defs[label]
+	if !ok {
+		w.WriteString(esc("[^" + label + "]"))
+		return
+	}
+	id := r.prefix + "-" + label
+	number := 0
+	for i := range r.footnotes {
+		if r.footnotes[i].id == id {
+			number = i + 1
+			break
+		}
+	}
+	if number == 0 {
+		r.footnotes = append(r.footnotes, footnote{id: id, def: def})
+		number = len(r.footnotes)
+	}
+	note := &r.footnotes[number-1]
+	note.refs++
+	refID := "fnref-" + id
+	if note.refs > 1 {
+		refID += "-" + strconv.Itoa(note.refs)
+	}
+	noteref := ""
+	if r.xhtml {
+		noteref = " epub:type=\"noteref\""
+	}
+	fmt.Fprintf(w, "<sup><a href=\"#fn-%s\" id=\"%s\"%s>%d</a></sup>", id, refID, noteref, number)
+}
+
+func (r *htmlRenderer) writeFootnotes(w *bytes.Buffer) {
+	if len(r.footnotes) == 0 {
+		return
+	}
+	if r.xhtml {
+		w.WriteString("<section class=\"footnotes\" epub:type=\"footnotes\">\n<hr/>\n<ol>\n")
+	} else {
+		w.WriteString("<section class=\"footnotes\">\n<hr/>\n<ol>\n")
+	}
+	for _, note := range r.footnotes {
+		if r.xhtml {
+			fmt.Fprintf(w, "<li id=\"fn-%s\" epub:type=\"footnote\">", note.id)
+		} else {
+			fmt.Fprintf(w, "<li id=\"fn-%s\">", note.id)
+		}
+		back := fmt.Sprintf(" <a href=\"#fnref-%s\" class=\"backlink\">&#8617;</a>", note.id)
+		children := note.def.Children
+		if len(children) == 1 && children[0].Kind == markdown.Paragraph {
+			w.WriteString("<p>")
+			r.inlines(w, children[0].Children)
+			w.WriteString(back + "</p>")
+		} else {
+			r.blocks(w, children, false)
+			w.WriteString("<p>" + back + "</p>")
+		}
+		w.WriteString("</li>\n")
+	}
+	w.WriteString("</ol>\n</section>\n")
+}
+
+func alignStyle(a markdown.Align) string {
+	switch a {
+	case markdown.AlignLeft:
+		return "left"
+	case markdown.AlignCenter:
+		return "center"
+	case markdown.AlignRight:
+		return "right"
+	}
+	return ""
+}
+
+// wellFormed reports whether a fragment of XHTML parses as XML.
+func wellFormed(fragment []byte) bool {
+	d := xml.NewDecoder(io.MultiReader(strings.NewReader("<div xmlns:epub=\"x\">"), bytes.NewReader(fragment), strings.NewReader("</div>")))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
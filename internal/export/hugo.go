@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	Register("hugo", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		files, err := Hugo(b)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{Files: files}, nil
+	}))
+}
+
+type hugoFrontMatter struct {
+	Title       string `yaml:"title"`
+	Date        string `yaml:"date,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Weight      int    `yaml:"weight,omitempty"`
+	Draft       bool   `yaml:"draft,omitempty"`
+}
+
+// Hugo writes the book as a Hugo content tree to be placed under a site's
+// content directory: an _index.md for the book and for every chapter, and a
+// page per section. Weights follow the outline order, sections without a
+// draft are marked as Hugo drafts, and images sit in images/ at the top of the
+// tree. Front and back matter are pages at the top of the tree, weighted
+// before and after the chapters. It returns the files of the tree by path.
+func Hugo(b *Book) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, data := range b.Assets {
+		files[name] = data
+	}
+
+	var err error
+	files["_index.md"], err = hugoPage(hugoFrontMatter{
+		Title: b.Title,
+		Date:  b.Date.Format("2006-01-02"),
+		Draft: !b.Drafted(),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, ch := range b.Chapters {
+		if ch.Matter != "" {
+			files[ch.Anchor()+".md"], err = hugoPage(hugoFrontMatter{
+				Title:  ch.Title,
+				Weight: i + 1,
+			}, []byte(matterMarkdown(ch, 1, 1)+"\n"))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		files[ch.Anchor()+"/_index.md"], err = hugoPage(hugoFrontMatter{
+			Title:       ch.Heading(),
+			Description: ch.Description,
+			Weight:      i + 1,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, sec := range ch.Sections {
+			body := []byte("*This section has not been drafted yet.*\n")
+			if sec.Content != "" {
+				// Section pages are published one level further down than
+				// their files, at chN/sectionM/.
+				body = []byte(sec.Markdown(1, 2) + "\n")
+			}
+			files[ch.Anchor()+"/"+sec.ID+".md"], err = hugoPage(hugoFrontMatter{
+				Title:  sec.Title,
+				Weight: i + 1,
+				Draft:  sec.Content == "",
+			}, body)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+func hugoPage(matter hugoFrontMatter, body []byte) ([]byte, error) {
+	data, err := yaml.Marshal(matter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write front matter: %w", err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(data)
+	buf.WriteString("---\n")
+	if body != nil {
+		buf.WriteString("\n")
+		buf.Write(body)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,37 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHugo(t *testing.T) {
+	book := &Book{
+		Title: "Learning Go",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{
+			{ID: "ch1", Number: 1, Title: "Basics", Sections: []Section{NewSection("section1", "Variables", "Declare them.")}},
+			{ID: "ch2", Number: 2, Title: "Types", Description: "Structs and more.", Sections: []Section{
+				NewSection("section1", "Structs", "# Fields\n\nNamed."),
+				{ID: "section2", Title: "Interfaces"},
+			}},
+		},
+	}
+
+	files, err := Hugo(book)
+	if err != nil {
+		t.Fatalf("Hugo returned error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"_index.md":       "---\ntitle: Learning Go\ndate: \"2024-05-01\"\ndraft: true\n---\n",
+		"ch2/_index.md":   "---\ntitle: 'Chapter 2: Types'\ndescription: Structs and more.\nweight: 2\n---\n",
+		"ch2/section1.md": "---\ntitle: Structs\nweight: 1\n---\n\n## Fields\n\nNamed.\n",
+		"ch2/section2.md": "---\ntitle: Interfaces\nweight: 2\ndraft: true\n---\n",
+	} {
+		if !strings.Contains(string(files[name]), want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", name, want, files[name])
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"go-book-ai/internal/glossary"
+	"sort"
+	"strings"
+)
+
+// IndexID is the ID of the index chapter of a book. It is not "index", which
+// would name its page like the title page of a site.
+const IndexID = "book-index"
+
+// IndexEntry is a term of the back-of-book index with the places that
+// mention it.
+type IndexEntry struct {
+	Term string
+	Refs []IndexRef
+}
+
+// IndexRef points at a section, or at front or back matter, that mentions a
+// term.
+type IndexRef struct {
+	// Chapter is the anchor of the chapter, which names its page in exports
+	// of a page per chapter, and Anchor the anchor of the section.
+	Chapter string
+	Anchor  string
+	// Label names the place in the index, like "3.2" for the second section
+	// of chapter 3.
+	Label string
+}
+
+// Labels lists the places of an entry, like "1.2, 3.1".
+func (e IndexEntry) Labels() string {
+	labels := make([]string, len(e.Refs))
+	for i, ref := range e.Refs {
+		labels[i] = ref.Label
+	}
+	return strings.Join(labels, ", ")
+}
+
+// AddIndex adds a back-of-book index of terms to the back matter of the
+// book, pointing each term at the sections that mention it. Terms no section
+// mentions are left out, and a book without any gets no index.
+func (b *Book) AddIndex(terms []string) {
+	type place struct {
+		ref  IndexRef
+		text string
+	}
+	var places []place
+	for _, ch := range b.Chapters {
+		if ch.Body.Doc != nil {
+			places = append(places, place{IndexRef{ch.Anchor(), ch.Anchor(), ch.Title}, ch.Body.Doc.PlainText()})
+		}
+		for i, sec := range ch.Sections {
+			if sec.Doc != nil {
+				places = append(places, place{IndexRef{ch.Anchor(), ch.SectionAnchor(sec), fmt.Sprintf("%d.%d", ch.Number, i+1)}, sec.Doc.PlainText()})
+			}
+		}
+	}
+
+	var entries []IndexEntry
+	for _, term := range terms {
+		entry := IndexEntry{Term: term}
+		for _, p := range places {
+			if glossary.Find(p.text, term) >= 0 {
+				entry.Refs = append(entry.Refs, p.ref)
+			}
+		}
+		if len(entry.Refs) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Term) < strings.ToLower(entries[j].Term)
+	})
+	b.addBackMatter(Chapter{ID: IndexID, Title: "Index", Matter: IndexID, Back: true, Index: entries})
+}
+
+// indexGroup returns the heading an index entry is listed under: its initial
+// letter, or "Symbols".
+func indexGroup(term string) string {
+	for _, r := range term {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return strings.ToUpper(string(r))
+		}
+		return "Symbols"
+	}
+	return "Symbols"
+}
+
+// indexAnchors returns the index terms of the book by the anchor of the
+// sections that mention them.
+func (b *Book) indexAnchors() map[string][]string {
+	anchors := map[string][]string{}
+	for _, ch := range b.Chapters {
+		for _, entry := range ch.Index {
+			for _, ref := range entry.Refs {
+				anchors[ref.Anchor] = append(anchors[ref.Anchor], entry.Term)
+			}
+		}
+	}
+	return anchors
+}
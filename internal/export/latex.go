@@ -0,0 +1,423 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go-book-ai/internal/markdown"
+	"os"
+	"strings"
+	"text/template"
+)
+
+//go:embed themes/book.tex
+var latexTemplate string
+
+const latexPreamble = `\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage{lmodern}
+\usepackage{textcomp}
+\usepackage{graphicx}
+\usepackage{longtable}
+\usepackage{enumitem}
+\usepackage{booktabs}
+\usepackage[normalem]{ulem}
+\usepackage{xcolor}
+\DeclareUnicodeCharacter{2190}{\ensuremath{\leftarrow}}
+\DeclareUnicodeCharacter{2192}{\ensuremath{\rightarrow}}
+\DeclareUnicodeCharacter{21D2}{\ensuremath{\Rightarrow}}
+\DeclareUnicodeCharacter{2264}{\ensuremath{\le}}
+\DeclareUnicodeCharacter{2265}{\ensuremath{\ge}}
+\DeclareUnicodeCharacter{2260}{\ensuremath{\ne}}
+`
+
+const listingsPreamble = `\usepackage{listings}
+\lstset{basicstyle=\ttfamily\small, breaklines=true, columns=fullflexible,
+  frame=single, keywordstyle=\bfseries, commentstyle=\itshape\color{gray},
+  showstringspaces=false, upquote=true,
+  literate={→}{{$\rightarrow$}}1 {←}{{$\leftarrow$}}1 {⇒}{{$\Rightarrow$}}1
+    {≤}{{$\le$}}1 {≥}{{$\ge$}}1 {≠}{{$\ne$}}1 {×}{{$\times$}}1
+    {—}{{\textemdash}}1 {–}{{\textendash}}1 {…}{{\ldots}}1 {•}{{\textbullet}}1
+    {“}{{\textquotedblleft}}1 {”}{{\textquotedblright}}1
+    {‘}{{\textquoteleft}}1 {’}{{\textquoteright}}1 {°}{{\textdegree}}1
+    {é}{{\'e}}1 {è}{{\` + "`" + `e}}1 {à}{{\` + "`" + `a}}1 {ç}{{\c{c}}}1 {ñ}{{\~n}}1
+    {ä}{{\"a}}1 {ö}{{\"o}}1 {ü}{{\"u}}1 {ß}{{\ss}}1}
+\lstdefinelanguage{Go}{sensitive=true,
+  morekeywords={break,case,chan,const,continue,default,defer,else,fallthrough,
+    for,func,go,goto,if,import,interface,map,package,range,return,select,struct,
+    switch,type,var,nil,true,false},
+  morecomment=[l]{//}, morecomment=[s]{/*}{*/},
+  morestring=[b]", morestring=[b]', morestring=[b]` + "`" + `}
+\lstdefinelanguage{JavaScript}{sensitive=true,
+  morekeywords={async,await,break,case,catch,class,const,continue,default,
+    delete,do,else,export,extends,false,finally,for,function,if,import,in,
+    instanceof,let,new,null,return,switch,this,throw,true,try,typeof,var,while},
+  morecomment=[l]{//}, morecomment=[s]{/*}{*/},
+  morestring=[b]", morestring=[b]', morestring=[b]` + "`" + `}
+`
+
+const mintedPreamble = `\usepackage{minted}
+\setminted{fontsize=\small, breaklines=true, frame=single}
+`
+
+// listingsLanguages maps info string languages to the names listings knows.
+var listingsLanguages = map[string]string{
+	"go": "Go", "golang": "Go", "python": "Python", "py": "Python", "java": "Java",
+	"c": "C", "h": "C", "cpp": "C++", "c++": "C++", "sh": "bash", "bash": "bash",
+	"shell": "bash", "sql": "SQL", "javascript": "JavaScript", "js": "JavaScript",
+	"ts": "JavaScript", "typescript": "JavaScript", "json": "JavaScript", "ruby": "Ruby",
+	"rb": "Ruby", "html": "HTML", "xml": "XML", "php": "PHP", "perl": "Perl",
+}
+
+func init() {
+	Register("latex", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		files, err := LaTeX(b, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{Files: files}, nil
+	}))
+}
+
+// latexData fills in the main document template.
+type latexData struct {
+	Title    string
+	Date     string
+	Draft    bool
+	Preamble string
+	Chapters []string
+	// Front and Back are the files of front and back matter.
+	Front []string
+	Back  []string
+	// Index prints the index at the end of the back matter.
+	Index bool
+}
+
+// LaTeX writes the book as a LaTeX project: a main document from a template,
+// one file per chapter with the outline mapped to \chapter, \section and
+// \subsection, and a Makefile that builds the PDF with latexmk. Front and back
+// matter are unnumbered chapters in \frontmatter and \backmatter, and index
+// terms are marked with \index in the sections that mention them for
+// makeindex. It returns the files of the project by path.
+func LaTeX(b *Book, opts Options) (map[string][]byte, error) {
+	text := latexTemplate
+	if opts.Template != "" {
+		data, err := os.ReadFile(opts.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LaTeX template: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("main.tex").Delims("<<", ">>").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LaTeX template: %w", err)
+	}
+
+	preamble := latexPreamble
+	if opts.Minted {
+		preamble += mintedPreamble
+	} else {
+		preamble += listingsPreamble
+	}
+	indexAnchors := b.indexAnchors()
+	if len(indexAnchors) > 0 {
+		preamble += "\\usepackage{makeidx}\n\\makeindex\n"
+	}
+	// hyperref goes last, after the packages it patches.
+	preamble += "\\usepackage[hidelinks]{hyperref}\n"
+
+	files := map[string][]byte{}
+	for name, data := range b.Assets {
+		files[name] = data
+	}
+	data := latexData{
+		Title:    latexEscape(b.Title),
+		Date:     b.Date.Format("January 2, 2006"),
+		Draft:    !b.Drafted(),
+		Preamble: strings.TrimSpace(preamble),
+		Index:    len(indexAnchors) > 0,
+	}
+
+	r := &latexRenderer{minted: opts.Minted}
+	for _, ch := range b.Chapters {
+		if ch.Matter == IndexID {
+			continue
+		}
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "\\chapter{%s}\\label{%s}\n", latexEscape(ch.Name()), ch.Anchor())
+		writeIndexTerms(&buf, indexAnchors[ch.Anchor()])
+		if ch.Matter != "" {
+			name := "matter/" + ch.Anchor()
+			if ch.Body.Doc != nil {
+				buf.WriteString("\n")
+				r.offset, r.defs = 0, ch.Body.Notes
+				r.blocks(&buf, ch.Body.Doc.Children, false)
+			}
+			if len(ch.Terms) > 0 {
+				buf.WriteString("\n\\begin{description}\n")
+				for _, t := range ch.Terms {
+					fmt.Fprintf(&buf, "\\item[%s] %s\n", latexEscape(t.Term), latexEscape(t.Definition))
+				}
+				buf.WriteString("\\end{description}\n")
+			}
+			files[name+".tex"] = buf.Bytes()
+			if ch.Back {
+				data.Back = append(data.Back, name)
+			} else {
+				data.Front = append(data.Front, name)
+			}
+			continue
+		}
+		r.offset = 1
+		for _, sec := range ch.Sections {
+			fmt.Fprintf(&buf, "\n\\section{%s}\\label{%s}\n", latexEscape(sec.Title), ch.SectionAnchor(sec))
+			writeIndexTerms(&buf, indexAnchors[ch.SectionAnchor(sec)])
+			buf.WriteString("\n")
+			if sec.Content == "" {
+				buf.WriteString("\\emph{This section has not been drafted yet.}\n")
+				continue
+			}
+			r.defs = sec.Notes
+			r.blocks(&buf, sec.Doc.Children, false)
+		}
+		name := "chapters/" + ch.Anchor()
+		files[name+".tex"] = buf.Bytes()
+		data.Chapters = append(data.Chapters, name)
+	}
+
+	var main bytes.Buffer
+	err = tmpl.Execute(&main, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render LaTeX template: %w", err)
+	}
+	files["main.tex"] = main.Bytes()
+
+	flags := "-pdf"
+	if opts.Minted {
+		flags += " -shell-escape"
+	}
+	files["Makefile"] = []byte(fmt.Sprintf("main.pdf: main.tex $(wildcard chapters/*.tex matter/*.tex)\n\tlatexmk %s main.tex\n\nclean:\n\tlatexmk -C main.tex\n", flags))
+	return files, nil
+}
+
+// writeIndexTerms marks the index terms a section mentions.
+func writeIndexTerms(w *bytes.Buffer, terms []string) {
+	for _, term := range terms {
+		fmt.Fprintf(w, "\\index{%s}\n", indexEscape(term))
+	}
+}
+
+// makeindex reads !, @ and | in an entry as commands unless quoted with ".
+var indexReplacer = strings.NewReplacer(`"`, `""`, `!`, `"!`, `@`, `"@`, `|`, `"|`)
+
+// indexEscape escapes a term for \index.
+func indexEscape(s string) string {
+	return indexReplacer.Replace(latexEscape(s))
+}
+
+// latexRenderer renders parsed drafts as LaTeX.
+type latexRenderer struct {
+	minted bool
+	// offset is the level of the heading a draft is rendered under.
+	offset int
+	defs   map[string]*markdown.Node
+	// inNote stops footnotes that refer to themselves from recursing.
+	inNote bool
+}
+
+func (r *latexRenderer) blocks(w *bytes.Buffer, nodes []*markdown.Node, tight bool) {
+	for _, n := range nodes {
+		r.block(w, n, tight)
+	}
+}
+
+func (r *latexRenderer) block(w *bytes.Buffer, n *markdown.Node, tight bool) {
+	switch n.Kind {
+	case markdown.Heading:
+		commands := []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+		level := n.Level - 1 + r.offset
+		if level >= len(commands) {
+			level = len(commands) - 1
+		}
+		fmt.Fprintf(w, "\\%s{", commands[level])
+		r.inlines(w, n.Children)
+		w.WriteString("}\n\n")
+	case markdown.Paragraph:
+		r.inlines(w, n.Children)
+		if tight {
+			w.WriteString("\n")
+		} else {
+			w.WriteString("\n\n")
+		}
+	case markdown.CodeBlock:
+		lang := strings.ToLower(n.Lang())
+		if r.minted {
+			if lang == "" {
+				lang = "text"
+			}
+			fmt.Fprintf(w, "\\begin{minted}{%s}\n%s\\end{minted}\n\n", lang, n.Literal)
+			return
+		}
+		if name, ok := listingsLanguages[lang]; ok {
+			fmt.Fprintf(w, "\\begin{lstlisting}[language=%s]\n%s\\end{lstlisting}\n\n", name, n.Literal)
+		} else {
+			fmt.Fprintf(w, "\\begin{lstlisting}\n%s\\end{lstlisting}\n\n", n.Literal)
+		}
+	case markdown.BlockQuote:
+		w.WriteString("\\begin{quote}\n")
+		r.blocks(w, n.Children, false)
+		w.WriteString("\\end{quote}\n\n")
+	case markdown.List:
+		env := "itemize"
+		if n.Ordered {
+			env = "enumerate"
+		}
+		if n.Ordered && n.Start > 1 {
+			fmt.Fprintf(w, "\\begin{%s}[start=%d]\n", env, n.Start)
+		} else {
+			fmt.Fprintf(w, "\\begin{%s}\n", env)
+		}
+		for _, item := range n.Children {
+			w.WriteString("\\item ")
+			r.blocks(w, item.Children, n.Tight)
+		}
+		fmt.Fprintf(w, "\\end{%s}\n\n", env)
+	case markdown.Table:
+		if len(n.Children) == 0 {
+			return
+		}
+		spec := ""
+		for _, cell := range n.Children[0].Children {
+			switch cell.Align {
+			case markdown.AlignCenter:
+				spec += "c"
+			case markdown.AlignRight:
+				spec += "r"
+			default:
+				spec += "l"
+			}
+		}
+		fmt.Fprintf(w, "\\begin{longtable}{%s}\n\\toprule\n", spec)
+		for i, row := range n.Children {
+			for j, cell := range row.Children {
+				if j > 0 {
+					w.WriteString(" & ")
+				}
+				if row.Header {
+					w.WriteString("\\textbf{")
+					r.inlines(w, cell.Children)
+					w.WriteString("}")
+				} else {
+					r.inlines(w, cell.Children)
+				}
+			}
+			w.WriteString(" \\\\\n")
+			if i == 0 && row.Header {
+				w.WriteString("\\midrule\n\\endhead\n")
+			}
+		}
+		w.WriteString("\\bottomrule\n\\end{longtable}\n\n")
+	case markdown.ThematicBreak:
+		w.WriteString("\\begin{center}\\rule{0.5\\linewidth}{0.4pt}\\end{center}\n\n")
+	case markdown.HTMLBlock, markdown.FootnoteDef:
+		// Raw HTML has no LaTeX equivalent; footnotes are set where they
+		// are referenced.
+	}
+}
+
+func (r *latexRenderer) inlines(w *bytes.Buffer, nodes []*markdown.Node) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case markdown.Text:
+			w.WriteString(latexEscape(n.Literal))
+		case markdown.SoftBreak:
+			w.WriteString("\n")
+		case markdown.HardBreak:
+			w.WriteString("\\\\\n")
+		case markdown.Emphasis:
+			w.WriteString("\\emph{")
+			r.inlines(w, n.Children)
+			w.WriteString("}")
+		case markdown.Strong:
+			w.WriteString("\\textbf{")
+			r.inlines(w, n.Children)
+			w.WriteString("}")
+		case markdown.Strikethrough:
+			w.WriteString("\\sout{")
+			r.inlines(w, n.Children)
+			w.WriteString("}")
+		case markdown.Code:
+			fmt.Fprintf(w, "\\texttt{%s}", latexEscape(n.Literal))
+		case markdown.Link:
+			if strings.HasPrefix(n.Dest, "#") {
+				r.inlines(w, n.Children)
+				continue
+			}
+			if n.PlainText() == n.Dest {
+				fmt.Fprintf(w, "\\url{%s}", latexURL(n.Dest))
+				continue
+			}
+			fmt.Fprintf(w, "\\href{%s}{", latexURL(n.Dest))
+			r.inlines(w, n.Children)
+			w.WriteString("}")
+		case markdown.Image:
+			if strings.Contains(n.Dest, "://") {
+				fmt.Fprintf(w, "\\href{%s}{%s}", latexURL(n.Dest), latexEscape(n.PlainText()))
+				continue
+			}
+			if strings.HasSuffix(strings.ToLower(n.Dest), ".svg") {
+				// graphicx cannot include SVG.
+				fmt.Fprintf(w, "\\emph{%s}", latexEscape(n.PlainText()))
+				continue
+			}
+			fmt.Fprintf(w, "\\includegraphics[width=\\linewidth]{%s}", n.Dest)
+		case markdown.FootnoteRef:
+			def, ok := r.defs[n.Label]
+			if !ok || r.inNote {
+				w.WriteString(latexEscape("[^" + n.Label + "]"))
+				continue
+			}
+			r.inNote = true
+			w.WriteString("\\footnote{")
+			for i, child := range def.Children {
+				if i > 0 {
+					w.WriteString("\\par ")
+				}
+				if child.Kind == markdown.Paragraph {
+					r.inlines(w, child.Children)
+				} else {
+					w.WriteString(latexEscape(child.PlainText()))
+				}
+			}
+			w.WriteString("}")
+			r.inNote = false
+		}
+	}
+}
+
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	`<`, `\textless{}`,
+	`>`, `\textgreater{}`,
+)
+
+// latexEscape escapes the characters LaTeX gives a special meaning.
+func latexEscape(s string) string {
+	return latexReplacer.Replace(s)
+}
+
+var urlReplacer = strings.NewReplacer(`\`, `/`, `#`, `\#`, `%`, `\%`, `{`, `%7B`, `}`, `%7D`)
+
+// latexURL escapes a URL for \url and \href.
+func latexURL(s string) string {
+	return urlReplacer.Replace(s)
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLaTeX(t *testing.T) {
+	draft := "Costs 5$ & 10% of_all.[^n]\n\n## Steps\n\n3. *go* **now**\n4. [docs](https://go.dev/#top)\n\n" +
+		"| Key | Value |\n|---|--:|\n| a | `x_y` |\n\n```go\nfunc main() {}\n```\n\n[^n]: A {note}.\n"
+	book := &Book{
+		Title: "C# & Go",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{{ID: "ch1", Number: 1, Title: "Chapter 1: Basics", Sections: []Section{
+			NewSection("section1", "Prices", draft),
+		}}},
+	}
+
+	files, err := LaTeX(book, Options{})
+	if err != nil {
+		t.Fatalf("LaTeX returned error: %v", err)
+	}
+	main := string(files["main.tex"])
+	for _, want := range []string{`\title{C\# \& Go}`, `\include{chapters/ch1}`, `\usepackage{listings}`, "{→}{{$\\rightarrow$}}1", "{è}{{\\`e}}1"} {
+		if !strings.Contains(main, want) {
+			t.Errorf("Expected main.tex to contain %q, got:\n%s", want, main)
+		}
+	}
+
+	chapter := string(files["chapters/ch1.tex"])
+	for _, want := range []string{
+		`\chapter{Basics}\label{ch1}`,
+		`\section{Prices}\label{ch1-section1}`,
+		`Costs 5\$ \& 10\% of\_all.\footnote{A \{note\}.}`,
+		`\subsection{Steps}`,
+		"\\begin{enumerate}[start=3]\n\\item \\emph{go} \\textbf{now}\n\\item \\href{https://go.dev/\\#top}{docs}\n",
+		"\\begin{longtable}{lr}\n\\toprule\n\\textbf{Key} & \\textbf{Value} \\\\\n\\midrule\n\\endhead\na & \\texttt{x\\_y} \\\\\n",
+		"\\begin{lstlisting}[language=Go]\nfunc main() {}\n\\end{lstlisting}",
+	} {
+		if !strings.Contains(chapter, want) {
+			t.Errorf("Expected chapter to contain %q, got:\n%s", want, chapter)
+		}
+	}
+
+	files, err = LaTeX(book, Options{Minted: true})
+	if err != nil {
+		t.Fatalf("LaTeX returned error: %v", err)
+	}
+	if !strings.Contains(string(files["chapters/ch1.tex"]), `\begin{minted}{go}`) || !strings.Contains(string(files["Makefile"]), "-shell-escape") {
+		t.Errorf("Expected minted code blocks built with -shell-escape")
+	}
+}
+
+func TestLaTeXIndex(t *testing.T) {
+	book := &Book{
+		Title: "Learning Go",
+		Chapters: []Chapter{
+			{ID: "ch1", Number: 1, Title: "Basics", Sections: []Section{
+				NewSection("section1", "Goroutines", "Start a goroutine with Go@Scale.\n"),
+				NewSection("section2", "Channels", "Channels connect goroutines.\n"),
+				NewSection("section3", "Mutexes", "A goroutine may hold a lock.\n"),
+			}},
+		},
+	}
+	book.AddIndex([]string{"goroutine", "Go@Scale", "unused"})
+
+	files, err := LaTeX(book, Options{})
+	if err != nil {
+		t.Fatalf("LaTeX returned error: %v", err)
+	}
+	main := string(files["main.tex"])
+	if !strings.Contains(main, "\\makeindex") || !strings.Contains(main, "\\printindex") {
+		t.Errorf("Expected the index in the main document, got:\n%s", main)
+	}
+	chapter := string(files["chapters/ch1.tex"])
+	if !strings.Contains(chapter, "\\label{ch1-section1}\n\\index{Go\"@Scale}\n\\index{goroutine}\n") || strings.Count(chapter, "\\index{goroutine}") != 2 {
+		t.Errorf("Expected index entries in the sections mentioning them, got:\n%s", chapter)
+	}
+
+	page, err := HTMLSite(book, Options{})
+	if err != nil {
+		t.Fatalf("HTMLSite returned error: %v", err)
+	}
+	if index := string(page["book-index.html"]); !strings.Contains(index, `<li>goroutine, <a href="ch1.html#ch1-section1">1.1</a>, <a href="ch1.html#ch1-section3">1.3</a></li>`) || strings.Contains(index, "unused") {
+		t.Errorf("Expected linked index entries, got:\n%s", index)
+	}
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"fmt"
+	"go-book-ai/internal/markdown"
+	"go-book-ai/internal/state"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Load builds the document model of a book from its state, reading the draft
+// of each section from the directory dir returns for it, and front and back
+// matter from the file matter returns, when it has been written. Images a
+// draft refers to by a relative path are read from beside the draft and
+// become assets of the book.
+func Load(st *state.State, dir func(ch *state.ChapterState, sec *state.SectionState) string, matter func(m *state.MatterState) string) (*Book, error) {
+	book := &Book{Title: st.Title, Date: time.Now(), Assets: map[string][]byte{}}
+
+	var back []Chapter
+	for i := range st.Matter {
+		m := &st.Matter[i]
+		path := matter(m)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", m.Title, err)
+		}
+		chapter := Chapter{ID: m.ID, Title: m.Title, Matter: m.ID, Back: m.Back()}
+		chapter.Body = NewSection(m.ID, m.Title, string(content))
+		err = book.collectImages(&chapter.Body, filepath.Dir(path), m.ID)
+		if err != nil {
+			return nil, err
+		}
+		if chapter.Back {
+			back = append(back, chapter)
+		} else {
+			book.Chapters = append(book.Chapters, chapter)
+		}
+	}
+
+	for i := range st.Chapters {
+		ch := &st.Chapters[i]
+		chapter := Chapter{
+			ID:          ch.ID,
+			Number:      i + 1,
+			Title:       ch.Title,
+			Description: ch.Description,
+		}
+		for j := range ch.Sections {
+			sec := &ch.Sections[j]
+			sectionDir := dir(ch, sec)
+			content, err := os.ReadFile(filepath.Join(sectionDir, "draft.md"))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read draft of %s/%s: %w", ch.ID, sec.ID, err)
+			}
+			section := NewSection(sec.ID, sec.Title, string(content))
+			err = book.collectImages(&section, sectionDir, chapter.SectionAnchor(section))
+			if err != nil {
+				return nil, err
+			}
+			chapter.Sections = append(chapter.Sections, section)
+		}
+		book.Chapters = append(book.Chapters, chapter)
+	}
+	book.Chapters = append(book.Chapters, back...)
+	return book, nil
+}
+
+// collectImages reads the local images a section refers to into the assets
+// of the book, and points the draft at their place in an export.
+func (b *Book) collectImages(sec *Section, dir, prefix string) error {
+	if sec.Doc == nil {
+		return nil
+	}
+	var err error
+	markdown.Walk(sec.Doc, func(n *markdown.Node) bool {
+		if err != nil || n.Kind != markdown.Image || !localPath(n.Dest) {
+			return true
+		}
+		if _, ok := imageTypes[strings.ToLower(path.Ext(n.Dest))]; !ok {
+			return true
+		}
+		name := "images/" + prefix + "-" + path.Base(n.Dest)
+		if _, ok := b.Assets[name]; !ok {
+			data, readErr := os.ReadFile(filepath.Join(dir, filepath.FromSlash(n.Dest)))
+			if os.IsNotExist(readErr) {
+				return true
+			}
+			if readErr != nil {
+				err = fmt.Errorf("failed to read image %s: %w", n.Dest, readErr)
+				return false
+			}
+			b.Assets[name] = data
+			sec.Images = append(sec.Images, name)
+		}
+		sec.Content = strings.ReplaceAll(sec.Content, "]("+n.Dest, "]("+name)
+		n.Dest = name
+		return true
+	})
+	return err
+}
+
+// localPath reports whether a link destination is a path relative to the
+// draft rather than a URL, an absolute path or an anchor.
+func localPath(dest string) bool {
+	return dest != "" && !strings.Contains(dest, ":") && !strings.HasPrefix(dest, "/") && !strings.HasPrefix(dest, "#")
+}
+
+// readCover returns the cover image of an export, if any, with the extension
+// its file name should keep.
+func readCover(opts Options) (string, []byte, error) {
+	if opts.Cover == "" {
+		return "", nil, nil
+	}
+	ext := strings.ToLower(filepath.Ext(opts.Cover))
+	if _, ok := imageTypes[ext]; !ok {
+		return "", nil, fmt.Errorf("unsupported cover image %s, expected JPEG, PNG, GIF or SVG", opts.Cover)
+	}
+	data, err := os.ReadFile(opts.Cover)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read cover image: %w", err)
+	}
+	return ext, data, nil
+}
+
+// readStylesheet returns the stylesheet of an export, or theme when none is
+// given.
+func readStylesheet(opts Options, theme string) (string, error) {
+	if opts.Stylesheet == "" {
+		return theme, nil
+	}
+	data, err := os.ReadFile(opts.Stylesheet)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stylesheet: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bytes"
+	"go-book-ai/internal/state"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	sectionDir := filepath.Join(dir, "ch1", "section1")
+	err := os.MkdirAll(sectionDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var img bytes.Buffer
+	err = png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(sectionDir, "diagram.png"), img.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft := "## Variables\n\nSee ![The diagram](diagram.png) and ![Remote](https://example.com/x.png).[^1]\n\n### Zero Values\n\n[^1]: A note.\n"
+	err = os.WriteFile(filepath.Join(sectionDir, "draft.md"), []byte(draft), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := &state.State{Title: "Learning Go", Chapters: []state.ChapterState{{
+		ID:       "ch1",
+		Title:    "Basics",
+		Sections: []state.SectionState{{ID: "section1", Title: "Variables"}, {ID: "section2", Title: "Constants"}},
+	}}}
+	book, err := Load(st, func(ch *state.ChapterState, sec *state.SectionState) string {
+		return filepath.Join(dir, ch.ID, sec.ID)
+	}, func(m *state.MatterState) string {
+		return filepath.Join(dir, "matter", m.ID+".md")
+	})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	sec := book.Chapters[0].Sections[0]
+	name := "images/ch1-section1-diagram.png"
+	if !bytes.Equal(book.Assets[name], img.Bytes()) || !reflect.DeepEqual(sec.Images, []string{name}) {
+		t.Fatalf("Expected the image as an asset, got %v", sec.Images)
+	}
+	if !strings.HasPrefix(sec.Content, "See ![The diagram](images/ch1-section1-diagram.png) and ![Remote](https://example.com/x.png)") {
+		t.Errorf("Expected the draft to point at the asset, got:\n%s", sec.Content)
+	}
+	if !strings.Contains(sec.Markdown(1, 1), "](../images/ch1-section1-diagram.png) and") || !strings.Contains(sec.Markdown(1, 1), "\n## Zero Values\n") {
+		t.Errorf("Expected shifted headings and relative asset links, got:\n%s", sec.Markdown(1, 1))
+	}
+	if sec.Notes["1"] == nil {
+		t.Errorf("Expected the footnote definition to be collected")
+	}
+	if book.Chapters[0].Sections[1].Doc != nil {
+		t.Errorf("Expected no document for an undrafted section")
+	}
+
+	out, err := exporters["docx"].Export(book, Options{})
+	if err != nil {
+		t.Fatalf("Exporting DOCX returned error: %v", err)
+	}
+	parts := readDocx(t, out.File)
+	if _, ok := parts["word/media/ch1-section1-diagram.png"]; !ok || !strings.Contains(parts["word/document.xml"], `<wp:extent cx="38100" cy="19050"/>`) {
+		t.Errorf("Expected the image to be embedded in the DOCX")
+	}
+
+	if formats := Formats(); !reflect.DeepEqual(formats, []string{"docx", "epub", "html", "hugo", "latex", "md", "mdbook"}) {
+		t.Errorf("Unexpected formats %v", formats)
+	}
+}
+
+func TestLoadMatter(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "matter"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, text := range map[string]string{"preface": "Why this book.\n", "conclusion": "# Next Steps\n\nKeep going.\n"} {
+		err = os.WriteFile(filepath.Join(dir, "matter", id+".md"), []byte(text), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := &state.State{
+		Title:    "Learning Go",
+		Chapters: []state.ChapterState{{ID: "ch1", Title: "Basics"}},
+		Matter:   state.DefaultMatter(),
+	}
+	book, err := Load(st, func(ch *state.ChapterState, sec *state.SectionState) string {
+		return filepath.Join(dir, ch.ID, sec.ID)
+	}, func(m *state.MatterState) string {
+		return filepath.Join(dir, "matter", m.ID+".md")
+	})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	var order []string
+	for _, ch := range book.Chapters {
+		order = append(order, ch.Heading())
+	}
+	if !reflect.DeepEqual(order, []string{"Preface", "Chapter 1: Basics", "Conclusion"}) {
+		t.Fatalf("Expected matter that has been written around the chapters, got %v", order)
+	}
+
+	out, err := exporters["latex"].Export(book, Options{})
+	if err != nil {
+		t.Fatalf("Exporting LaTeX returned error: %v", err)
+	}
+	main := string(out.Files["main.tex"])
+	if !strings.Contains(main, "\\tableofcontents\n\\include{matter/preface}\n") || !strings.Contains(main, "\\backmatter\n\\include{matter/conclusion}\n") {
+		t.Errorf("Expected matter in the front and back matter, got:\n%s", main)
+	}
+	if !strings.Contains(string(out.Files["matter/conclusion.tex"]), "\\section{Next Steps}") {
+		t.Errorf("Expected matter headings as sections, got:\n%s", out.Files["matter/conclusion.tex"])
+	}
+
+	out, err = exporters["mdbook"].Export(book, Options{})
+	if err != nil {
+		t.Fatalf("Exporting mdBook returned error: %v", err)
+	}
+	if summary := string(out.Files["src/SUMMARY.md"]); !strings.Contains(summary, "[Preface](preface.md)\n\n- [Chapter 1: Basics](ch1/README.md)\n\n[Conclusion](conclusion.md)\n") {
+		t.Errorf("Expected prefix and suffix chapters, got:\n%s", summary)
+	}
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	Register("md", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		data, err := Markdown(b)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{File: data, Files: b.Assets}, nil
+	}))
+}
+
+type frontMatter struct {
+	Title    string `yaml:"title"`
+	Date     string `yaml:"date"`
+	Chapters int    `yaml:"chapters"`
+	Sections int    `yaml:"sections"`
+	Draft    bool   `yaml:"draft,omitempty"`
+}
+
+// Markdown writes the book as a single Markdown manuscript: YAML front matter
+// for the title page, a linked table of contents, and every chapter and
+// section in outline order with chapters at level one, sections at level two
+// and the headings of each draft nested below its section. Front and back
+// matter sit at level one before and after the chapters.
+func Markdown(b *Book) ([]byte, error) {
+	var buf bytes.Buffer
+
+	matter := frontMatter{
+		Title: b.Title,
+		Date:  b.Date.Format("2006-01-02"),
+		Draft: !b.Drafted(),
+	}
+	for _, ch := range b.Chapters {
+		if ch.Matter == "" {
+			matter.Chapters++
+		}
+		matter.Sections += len(ch.Sections)
+	}
+	data, err := yaml.Marshal(matter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write front matter: %w", err)
+	}
+	buf.WriteString("---\n")
+	buf.Write(data)
+	buf.WriteString("---\n\n")
+
+	buf.WriteString("**Contents**\n\n")
+	for _, ch := range b.Chapters {
+		fmt.Fprintf(&buf, "- [%s](#%s)\n", ch.Heading(), ch.Anchor())
+		for _, sec := range ch.Sections {
+			fmt.Fprintf(&buf, "  - [%s](#%s)\n", sec.Title, ch.SectionAnchor(sec))
+		}
+	}
+
+	for _, ch := range b.Chapters {
+		fmt.Fprintf(&buf, "\n<a id=\"%s\"></a>\n\n# %s\n", ch.Anchor(), ch.Heading())
+		if ch.Matter != "" {
+			buf.WriteString("\n" + matterMarkdown(ch, 1, 0) + "\n")
+		}
+		for _, sec := range ch.Sections {
+			fmt.Fprintf(&buf, "\n<a id=\"%s\"></a>\n\n## %s\n\n", ch.SectionAnchor(sec), sec.Title)
+			if sec.Content == "" {
+				buf.WriteString("*This section has not been drafted yet.*\n")
+				continue
+			}
+			buf.WriteString(sec.Markdown(2, 0))
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
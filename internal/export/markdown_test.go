@@ -0,0 +1,54 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdown(t *testing.T) {
+	draft := "# Variables\n\nDeclare them.\n\n## Zero Values\n\nEvery type has one.\n\n```sh\n# not a heading\n```\n"
+	book := &Book{
+		Title: "Learning Go",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{{
+			ID:     "ch1",
+			Number: 1,
+			Title:  "Chapter 1: Basics",
+			Sections: []Section{
+				NewSection("section1", "Variables", draft),
+				{ID: "section2", Title: "Constants"},
+			},
+		}},
+	}
+
+	data, err := Markdown(book)
+	if err != nil {
+		t.Fatalf("Markdown returned error: %v", err)
+	}
+	text := string(data)
+
+	for _, want := range []string{
+		"---\ntitle: Learning Go\ndate: \"2024-05-01\"\n",
+		"draft: true\n",
+		"- [Chapter 1: Basics](#ch1)\n  - [Variables](#ch1-section1)\n",
+		"<a id=\"ch1-section1\"></a>\n\n## Variables\n\nDeclare them.\n\n### Zero Values\n",
+		"# not a heading\n",
+		"*This section has not been drafted yet.*",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected export to contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Count(text, "## Variables") != 1 {
+		t.Errorf("Expected the section heading once, got:\n%s", text)
+	}
+}
+
+func TestNormalizeSection(t *testing.T) {
+	draft := "Variables\n=========\n\nIntro.\n\nZero Values\n-----------\n\nExample:\n\n    # comment\n    x := 0\n\n#### Details\n"
+	want := "Intro.\n\n# Zero Values\n\nExample:\n\n    # comment\n    x := 0\n\n### Details"
+	if got := NormalizeSection("Variables", draft); got != want {
+		t.Errorf("NormalizeSection returned:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	Register("mdbook", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		files, err := MdBook(b, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{Files: files}, nil
+	}))
+}
+
+// MdBook writes the book as an mdBook project: book.toml, src/SUMMARY.md and
+// a title page, and for every chapter a README.md with its description
+// followed by one page per section. Front and back matter become prefix and
+// suffix chapters. It returns the files of the project by path.
+func MdBook(b *Book, opts Options) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, data := range b.Assets {
+		files["src/"+name] = data
+	}
+
+	var toml bytes.Buffer
+	fmt.Fprintf(&toml, "[book]\ntitle = %s\nlanguage = \"en\"\nsrc = \"src\"\n\n[output.html]\n", tomlString(b.Title))
+	if opts.Stylesheet != "" {
+		css, err := readStylesheet(opts, "")
+		if err != nil {
+			return nil, err
+		}
+		files["theme/custom.css"] = []byte(css)
+		toml.WriteString("additional-css = [\"theme/custom.css\"]\n")
+	}
+	files["book.toml"] = toml.Bytes()
+
+	var title bytes.Buffer
+	fmt.Fprintf(&title, "# %s\n\n", b.Title)
+	ext, cover, err := readCover(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cover != nil {
+		files["src/cover"+ext] = cover
+		fmt.Fprintf(&title, "![Cover](cover%s)\n\n", ext)
+	}
+	if !b.Drafted() {
+		title.WriteString("*Draft*\n\n")
+	}
+	fmt.Fprintf(&title, "%s\n", b.Date.Format("January 2, 2006"))
+	files["src/README.md"] = title.Bytes()
+
+	var summary bytes.Buffer
+	fmt.Fprintf(&summary, "# Summary\n\n[%s](README.md)\n\n", b.Title)
+	for i, ch := range b.Chapters {
+		if ch.Matter != "" {
+			// Prefix and suffix chapters are set apart from the numbered
+			// ones by a blank line.
+			if ch.Back && (i == 0 || !b.Chapters[i-1].Back) {
+				summary.WriteString("\n")
+			}
+			fmt.Fprintf(&summary, "[%s](%s.md)\n", ch.Title, ch.Anchor())
+			if !ch.Back && (i+1 == len(b.Chapters) || b.Chapters[i+1].Matter == "") {
+				summary.WriteString("\n")
+			}
+			files["src/"+ch.Anchor()+".md"] = []byte("# " + ch.Title + "\n\n" + matterMarkdown(ch, 1, 0) + "\n")
+			continue
+		}
+		fmt.Fprintf(&summary, "- [%s](%s/README.md)\n", ch.Heading(), ch.Anchor())
+
+		var intro bytes.Buffer
+		fmt.Fprintf(&intro, "# %s\n\n", ch.Heading())
+		if ch.Description != "" {
+			fmt.Fprintf(&intro, "%s\n\n", ch.Description)
+		}
+		for _, sec := range ch.Sections {
+			fmt.Fprintf(&summary, "  - [%s](%s/%s.md)\n", sec.Title, ch.Anchor(), sec.ID)
+			fmt.Fprintf(&intro, "- [%s](%s.md)\n", sec.Title, sec.ID)
+			files["src/"+ch.Anchor()+"/"+sec.ID+".md"] = sectionPage(sec)
+		}
+		files["src/"+ch.Anchor()+"/README.md"] = intro.Bytes()
+	}
+	files["src/SUMMARY.md"] = summary.Bytes()
+	return files, nil
+}
+
+// sectionPage writes a section as a Markdown page of its own, with the
+// headings of its draft below the section title.
+func sectionPage(sec Section) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", sec.Title)
+	if sec.Content == "" {
+		buf.WriteString("*This section has not been drafted yet.*\n")
+	} else {
+		buf.WriteString(sec.Markdown(1, 1))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+func tomlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(s) + `"`
+}
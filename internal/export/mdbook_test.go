@@ -0,0 +1,42 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMdBook(t *testing.T) {
+	book := &Book{
+		Title: `The "Go" Book`,
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{{
+			ID:          "ch1",
+			Number:      1,
+			Title:       "Basics",
+			Description: "Where to start.",
+			Sections: []Section{
+				NewSection("section1", "Variables", "Declare them.\n\n# Zero Values\n\nEvery type has one."),
+				{ID: "section2", Title: "Constants"},
+			},
+		}},
+	}
+
+	files, err := MdBook(book, Options{})
+	if err != nil {
+		t.Fatalf("MdBook returned error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"book.toml":           "title = \"The \\\"Go\\\" Book\"\n",
+		"src/SUMMARY.md":      "[The \"Go\" Book](README.md)\n\n- [Chapter 1: Basics](ch1/README.md)\n  - [Variables](ch1/section1.md)\n  - [Constants](ch1/section2.md)\n",
+		"src/README.md":       "*Draft*",
+		"src/ch1/README.md":   "# Chapter 1: Basics\n\nWhere to start.\n\n- [Variables](section1.md)\n",
+		"src/ch1/section1.md": "# Variables\n\nDeclare them.\n\n## Zero Values\n",
+		"src/ch1/section2.md": "*This section has not been drafted yet.*",
+	} {
+		if !strings.Contains(string(files[name]), want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", name, want, files[name])
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var (
+	//go:embed themes/site.css
+	siteTheme string
+	//go:embed themes/search.js
+	siteSearch string
+)
+
+func init() {
+	Register("html", ExporterFunc(func(b *Book, opts Options) (*Output, error) {
+		files, err := HTMLSite(b, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{Files: files}, nil
+	}))
+}
+
+// searchEntry is a section in the search index of an HTML site.
+type searchEntry struct {
+	Title   string `json:"title"`
+	Chapter string `json:"chapter"`
+	URL     string `json:"url"`
+	Text    string `json:"text"`
+}
+
+// HTMLSite writes the book as a static website: an index page with the table
+// of contents, one page per chapter with a sidebar and previous and next
+// links, highlighted code, and a search index for client-side search. It
+// returns the files of the site by path.
+func HTMLSite(b *Book, opts Options) (map[string][]byte, error) {
+	files := map[string][]byte{
+		"assets/search.js": []byte(siteSearch),
+	}
+	for name, data := range b.Assets {
+		files[name] = data
+	}
+	css, err := readStylesheet(opts, siteTheme)
+	if err != nil {
+		return nil, err
+	}
+	files["assets/site.css"] = []byte(css)
+
+	cover := ""
+	ext, data, err := readCover(opts)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		cover = "assets/cover" + ext
+		files[cover] = data
+	}
+
+	var index bytes.Buffer
+	sitePageStart(&index, b, b.Title, -1)
+	fmt.Fprintf(&index, "<h1>%s</h1>\n", esc(b.Title))
+	if cover != "" {
+		fmt.Fprintf(&index, "<p><img src=\"%s\" alt=\"%s\"/></p>\n", cover, esc(b.Title))
+	}
+	if !b.Drafted() {
+		index.WriteString("<p><em>Draft</em></p>\n")
+	}
+	fmt.Fprintf(&index, "<p>%s</p>\n<h2>Contents</h2>\n", b.Date.Format("January 2, 2006"))
+	siteTOC(&index, b, -1)
+	sitePageEnd(&index, b, -1)
+	files["index.html"] = index.Bytes()
+
+	var entries []searchEntry
+	r := &htmlRenderer{highlight: true, page: "%s.html"}
+	r.linkTerms(b)
+	for i, ch := range b.Chapters {
+		var page bytes.Buffer
+		sitePageStart(&page, b, ch.Heading()+" - "+b.Title, i)
+		r.chapter(&page, ch)
+		sitePageEnd(&page, b, i)
+		files[ch.Anchor()+".html"] = page.Bytes()
+
+		if ch.Matter != "" && ch.Body.Doc != nil {
+			entries = append(entries, searchEntry{
+				Title:   ch.Title,
+				Chapter: ch.Title,
+				URL:     ch.Anchor() + ".html",
+				Text:    strings.Join(strings.Fields(ch.Body.Doc.PlainText()), " "),
+			})
+		}
+		for _, sec := range ch.Sections {
+			entry := searchEntry{
+				Title:   sec.Title,
+				Chapter: ch.Heading(),
+				URL:     ch.Anchor() + ".html#" + ch.SectionAnchor(sec),
+			}
+			if sec.Doc != nil {
+				entry.Text = strings.Join(strings.Fields(sec.Doc.PlainText()), " ")
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	search, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write search index: %w", err)
+	}
+	files["search.json"] = search
+	return files, nil
+}
+
+// sitePageStart writes the head of a page and the sidebar, marking the
+// chapter at position current, or none when it is negative.
+func sitePageStart(w *bytes.Buffer, b *Book, title string, current int) {
+	w.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\"/>\n")
+	w.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"/>\n")
+	fmt.Fprintf(w, "<title>%s</title>\n<link rel=\"stylesheet\" href=\"assets/site.css\"/>\n</head>\n<body>\n", esc(title))
+	fmt.Fprintf(w, "<aside class=\"sidebar\">\n<a class=\"book-title\" href=\"index.html\">%s</a>\n", esc(b.Title))
+	w.WriteString("<input type=\"search\" id=\"search\" placeholder=\"Search\" data-index=\"search.json\"/>\n<ol id=\"search-results\"></ol>\n<nav>\n")
+	siteTOC(w, b, current)
+	w.WriteString("</nav>\n</aside>\n<main>\n")
+}
+
+func sitePageEnd(w *bytes.Buffer, b *Book, current int) {
+	w.WriteString("<nav class=\"pager\">\n")
+	if current >= 0 {
+		prev, label := "index.html", b.Title
+		if current > 0 {
+			prev, label = b.Chapters[current-1].Anchor()+".html", b.Chapters[current-1].Heading()
+		}
+		fmt.Fprintf(w, "<a class=\"prev\" href=\"%s\">&larr; %s</a>\n", prev, esc(label))
+	} else {
+		w.WriteString("<span></span>\n")
+	}
+	if next := current + 1; next < len(b.Chapters) {
+		fmt.Fprintf(w, "<a class=\"next\" href=\"%s.html\">%s &rarr;</a>\n", b.Chapters[next].Anchor(), esc(b.Chapters[next].Heading()))
+	}
+	w.WriteString("</nav>\n</main>\n<script src=\"assets/search.js\"></script>\n</body>\n</html>\n")
+}
+
+// siteTOC writes the outline as nested lists of links, expanding the sections
+// of the chapter at position current, or of every chapter when it is
+// negative.
+func siteTOC(w *bytes.Buffer, b *Book, current int) {
+	w.WriteString("<ol>\n")
+	for i, ch := range b.Chapters {
+		if i == current {
+			w.WriteString("<li class=\"current\">")
+		} else {
+			w.WriteString("<li>")
+		}
+		fmt.Fprintf(w, "<a href=\"%s.html\">%s</a>", ch.Anchor(), esc(ch.Heading()))
+		if (current < 0 || i == current) && len(ch.Sections) > 0 {
+			w.WriteString("\n<ol>\n")
+			for _, sec := range ch.Sections {
+				fmt.Fprintf(w, "<li><a href=\"%s.html#%s\">%s</a></li>\n", ch.Anchor(), ch.SectionAnchor(sec), esc(sec.Title))
+			}
+			w.WriteString("</ol>\n")
+		}
+		w.WriteString("</li>\n")
+	}
+	w.WriteString("</ol>\n")
+}
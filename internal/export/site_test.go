@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/json"
+	"go-book-ai/internal/glossary"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLSite(t *testing.T) {
+	book := &Book{
+		Title: "Learning Go",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{
+			{ID: "ch1", Number: 1, Title: "Basics", Sections: []Section{
+				NewSection("section1", "Variables", "Declare variables.\n\n```go\n// Zero value.\nvar n int = 42\ns := \"<hi>\"\n```\n"),
+			}},
+			{ID: "ch2", Number: 2, Title: "Types", Sections: []Section{{ID: "section1", Title: "Structs"}}},
+		},
+	}
+
+	files, err := HTMLSite(book, Options{})
+	if err != nil {
+		t.Fatalf("HTMLSite returned error: %v", err)
+	}
+	for _, name := range []string{"index.html", "ch1.html", "ch2.html", "search.json", "assets/site.css", "assets/search.js"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the site", name)
+		}
+	}
+
+	page := string(files["ch1.html"])
+	for _, want := range []string{
+		`<li class="current"><a href="ch1.html">Chapter 1: Basics</a>`,
+		`<a href="ch1.html#ch1-section1">Variables</a>`,
+		`<span class="tok-com">// Zero value.</span>`,
+		`<span class="tok-kw">var</span> n int = <span class="tok-num">42</span>`,
+		`<span class="tok-str">&#34;&lt;hi&gt;&#34;</span>`,
+		`<a class="prev" href="index.html">`,
+		`<a class="next" href="ch2.html">Chapter 2: Types &rarr;</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected chapter page to contain %q, got:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(files["ch2.html"]), `class="next"`) {
+		t.Errorf("Expected no next link on the last chapter")
+	}
+
+	var entries []searchEntry
+	err = json.Unmarshal(files["search.json"], &entries)
+	if err != nil {
+		t.Fatalf("Failed to read search index: %v", err)
+	}
+	if len(entries) != 2 || entries[0].URL != "ch1.html#ch1-section1" || !strings.HasPrefix(entries[0].Text, "Declare variables.") {
+		t.Errorf("Unexpected search index %+v", entries)
+	}
+}
+
+func TestHTMLSiteGlossary(t *testing.T) {
+	book := &Book{
+		Title: "Learning Go",
+		Chapters: []Chapter{
+			{ID: "ch1", Number: 1, Title: "Basics", Sections: []Section{
+				NewSection("section1", "Goroutines", "## Goroutine Basics\n\nA **goroutine** is cheap. Start a goroutine per [goroutine task](x.html).\n"),
+				NewSection("section2", "More", "Every goroutine has a stack.\n"),
+			}},
+		},
+	}
+	book.AddGlossary([]glossary.Term{{Term: "goroutine", Definition: "A function running concurrently."}})
+
+	files, err := HTMLSite(book, Options{})
+	if err != nil {
+		t.Fatalf("HTMLSite returned error: %v", err)
+	}
+	page := string(files["ch1.html"])
+	if strings.Count(page, `class="term"`) != 1 || !strings.Contains(page, `<strong><a class="term" href="glossary.html#term-goroutine">goroutine</a></strong>`) {
+		t.Errorf("Expected only the first use to link to the glossary, got:\n%s", page)
+	}
+	if !strings.Contains(string(files["glossary.html"]), `<dt id="term-goroutine"><dfn>goroutine</dfn></dt>`) {
+		t.Errorf("Expected the glossary page, got:\n%s", files["glossary.html"])
+	}
+}
@@ -0,0 +1,70 @@
+// Client-side search over search.json, the text of every section.
+(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("search-results");
+  if (!input || !results) {
+    return;
+  }
+
+  var index = null;
+  function load() {
+    if (index !== null) {
+      return Promise.resolve(index);
+    }
+    return fetch(input.dataset.index)
+      .then(function (response) { return response.json(); })
+      .then(function (data) { index = data; return index; });
+  }
+
+  function snippet(text, term) {
+    var at = text.toLowerCase().indexOf(term);
+    if (at < 0) {
+      return text.slice(0, 120);
+    }
+    var start = Math.max(0, at - 50);
+    return (start > 0 ? "..." : "") + text.slice(start, at + 70) + "...";
+  }
+
+  function search() {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.innerHTML = "";
+    if (terms.length === 0) {
+      return;
+    }
+    load().then(function (entries) {
+      var matches = entries.map(function (entry) {
+        var title = entry.title.toLowerCase();
+        var text = entry.text.toLowerCase();
+        var score = 0;
+        for (var i = 0; i < terms.length; i++) {
+          if (title.indexOf(terms[i]) >= 0) {
+            score += 10;
+          } else if (text.indexOf(terms[i]) >= 0) {
+            score += 1;
+          } else {
+            return null;
+          }
+        }
+        return { entry: entry, score: score };
+      }).filter(Boolean);
+      matches.sort(function (a, b) { return b.score - a.score; });
+
+      matches.slice(0, 20).forEach(function (match) {
+        var li = document.createElement("li");
+        var a = document.createElement("a");
+        a.href = match.entry.url;
+        a.textContent = match.entry.title;
+        var small = document.createElement("small");
+        small.textContent = match.entry.chapter + ": " + snippet(match.entry.text, terms[0]);
+        li.appendChild(a);
+        li.appendChild(small);
+        results.appendChild(li);
+      });
+      if (matches.length === 0) {
+        results.innerHTML = "<li>No results</li>";
+      }
+    });
+  }
+
+  input.addEventListener("input", search);
+})();
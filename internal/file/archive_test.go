@@ -0,0 +1,58 @@
+package file
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"go-book-ai/internal/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestArchiveDir(t *testing.T) {
+	dir := t.TempDir()
+	book := filepath.Join(dir, "my-book")
+	os.MkdirAll(filepath.Join(book, "ch1", "section1"), os.ModePerm)
+	os.WriteFile(filepath.Join(book, "state.yaml"), []byte("title: My Book\n"), 0644)
+	os.WriteFile(filepath.Join(book, "ch1", "section1", "draft.md"), []byte("# Draft\n"), 0644)
+	os.WriteFile(filepath.Join(book, LockFileName), []byte("1\n"), 0644)
+
+	fm := NewFileManager(logger.NewSimpleLogger())
+	dest := filepath.Join(dir, "archive", "my-book.tar.gz")
+	err := fm.ArchiveDir(book, dest)
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+
+	f, err := os.Open(dest)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+
+	want := "my-book/ my-book/ch1/ my-book/ch1/section1/ my-book/ch1/section1/draft.md my-book/state.yaml"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("Expected entries %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,172 @@
+package file
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the advisory lock file taken in a book directory while a
+// bookcli process is changing the book.
+const LockFileName = ".lock"
+
+// lockToken identifies the locks taken by this process. Process IDs are
+// reused, so a lock is only this process's own if it carries this token.
+var lockToken = newLockToken()
+
+// held records the lock files this process holds and how many nested
+// LockBook calls hold each of them.
+var (
+	heldMu sync.Mutex
+	held   = map[string]int{}
+)
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// LockBook takes the advisory lock for a book directory and returns a function
+// that releases it. If another live process holds the lock, a descriptive
+// error is returned. Locks left behind by processes that are no longer running
+// are taken over. Taking a lock this process already holds succeeds, so
+// nested operations can lock freely; the lock is released when the outermost
+// holder releases it.
+func (fm *FileManager) LockBook(bookPath string) (func(), error) {
+	lockPath := filepath.Join(bookPath, LockFileName)
+	if abs, err := filepath.Abs(lockPath); err == nil {
+		lockPath = abs
+	}
+
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	if held[lockPath] > 0 {
+		held[lockPath]++
+		return fm.releaser(bookPath, lockPath), nil
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			hostname, _ := os.Hostname()
+			fmt.Fprintf(f, "%d\n%s\n%s\n%s\n", os.Getpid(), hostname, time.Now().Format(time.RFC3339), lockToken)
+			f.Close()
+			held[lockPath] = 1
+			fm.Logger.Debug(fmt.Sprintf("Locked %s", bookPath))
+			return fm.releaser(bookPath, lockPath), nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		data, err := os.ReadFile(lockPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		pid, hostname, started, token := parseLock(data)
+		localHost, _ := os.Hostname()
+		// A lock with this process's ID that this process does not hold was
+		// left by an earlier process whose ID has been reused.
+		reused := pid == os.Getpid() && token != lockToken
+		if pid > 0 && hostname == localHost && (reused || !processAlive(pid)) {
+			if takeOver(lockPath, data) {
+				fm.Logger.Info(fmt.Sprintf("Removed stale lock left by process %d", pid))
+			}
+			continue
+		}
+		return nil, fmt.Errorf("book %s is locked by process %d on %s since %s; if no other bookcli is running on this book, remove %s", bookPath, pid, hostname, started, lockPath)
+	}
+	return nil, fmt.Errorf("failed to lock %s", bookPath)
+}
+
+// releaser returns the function that releases one hold on a lock. The last
+// release removes the lock file, unless it no longer carries this process's
+// token because another process has taken it over.
+func (fm *FileManager) releaser(bookPath, lockPath string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			heldMu.Lock()
+			defer heldMu.Unlock()
+			held[lockPath]--
+			if held[lockPath] > 0 {
+				return
+			}
+			delete(held, lockPath)
+			data, err := os.ReadFile(lockPath)
+			if err != nil {
+				return
+			}
+			if _, _, _, token := parseLock(data); token == lockToken {
+				os.Remove(lockPath)
+				fm.Logger.Debug(fmt.Sprintf("Unlocked %s", bookPath))
+			}
+		})
+	}
+}
+
+// parseLock returns the process ID, host, start time and token recorded in a
+// lock file, or a zero process ID if they cannot be read.
+func parseLock(data []byte) (int, string, string, string) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	pid, _ := strconv.Atoi(lines[0])
+	hostname, started, token := "", "", ""
+	if len(lines) > 1 {
+		hostname = lines[1]
+	}
+	if len(lines) > 2 {
+		started = lines[2]
+	}
+	if len(lines) > 3 {
+		token = lines[3]
+	}
+	return pid, hostname, started, token
+}
+
+// takeOver removes a stale lock file that held stale, and reports whether it
+// did. The lock is first renamed to a name of its own, which only one of
+// several processes taking over the same lock can do. A process that finds
+// it renamed a fresh lock, taken by another process after the stale one was
+// removed, puts that lock back.
+func takeOver(lockPath string, stale []byte) bool {
+	claim, err := os.CreateTemp(filepath.Dir(lockPath), LockFileName+".stale-*")
+	if err != nil {
+		return false
+	}
+	claim.Close()
+	defer os.Remove(claim.Name())
+
+	err = os.Rename(lockPath, claim.Name())
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(claim.Name())
+	if err == nil && bytes.Equal(data, stale) {
+		return true
+	}
+	os.Link(claim.Name(), lockPath)
+	return false
+}
+
+// processAlive reports whether a process with the given ID is running. When
+// that cannot be determined the process is assumed to be alive.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
@@ -0,0 +1,140 @@
+package file
+
+import (
+	"fmt"
+	"go-book-ai/internal/logger"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLock(t *testing.T, dir string, pid int) {
+	hostname, _ := os.Hostname()
+	err := os.WriteFile(filepath.Join(dir, LockFileName), []byte(fmt.Sprintf("%d\n%s\n2024-01-01T00:00:00Z\n", pid, hostname)), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write lock file: %v", err)
+	}
+}
+
+func TestLockBook(t *testing.T) {
+	dir := t.TempDir()
+	fm := NewFileManager(logger.NewSimpleLogger())
+
+	unlock, err := fm.LockBook(dir)
+	if err != nil {
+		t.Fatalf("Expected to take the lock, got %v", err)
+	}
+
+	nested, err := fm.LockBook(dir)
+	if err != nil {
+		t.Fatalf("Expected the lock to be re-entrant, got %v", err)
+	}
+	nested()
+	if _, err := os.Stat(filepath.Join(dir, LockFileName)); err != nil {
+		t.Errorf("Expected a nested release to keep the lock")
+	}
+
+	unlock()
+	if _, err := os.Stat(filepath.Join(dir, LockFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed")
+	}
+}
+
+func TestLockBookHeldByOtherProcess(t *testing.T) {
+	dir := t.TempDir()
+	fm := NewFileManager(logger.NewSimpleLogger())
+	writeLock(t, dir, os.Getppid())
+
+	_, err := fm.LockBook(dir)
+	if err == nil || !strings.Contains(err.Error(), "is locked by process") {
+		t.Errorf("Expected a lock error, got %v", err)
+	}
+}
+
+func TestLockBookStale(t *testing.T) {
+	dir := t.TempDir()
+	fm := NewFileManager(logger.NewSimpleLogger())
+
+	finished := exec.Command(os.Args[0], "-test.run=^$")
+	if err := finished.Run(); err != nil {
+		t.Fatalf("Failed to run helper process: %v", err)
+	}
+	writeLock(t, dir, finished.Process.Pid)
+
+	unlock, err := fm.LockBook(dir)
+	if err != nil {
+		t.Fatalf("Expected a stale lock to be taken over, got %v", err)
+	}
+	unlock()
+}
+
+func TestLockBookReusedPID(t *testing.T) {
+	dir := t.TempDir()
+	fm := NewFileManager(logger.NewSimpleLogger())
+	// A lock left by an earlier process that had this process's ID.
+	writeLock(t, dir, os.Getpid())
+
+	unlock, err := fm.LockBook(dir)
+	if err != nil {
+		t.Fatalf("Expected a lock with a reused process ID to be taken over, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, token := parseLock(data); token != lockToken {
+		t.Errorf("Expected the lock to carry this process's token, got %q", data)
+	}
+	unlock()
+	if _, err := os.Stat(filepath.Join(dir, LockFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed")
+	}
+}
+
+func TestTakeOverTwoTakers(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, LockFileName)
+	stale := []byte("1\nhost\n2024-01-01T00:00:00Z\n")
+	fresh := []byte("2\nhost\n2024-01-02T00:00:00Z\n")
+	if err := os.WriteFile(lockPath, stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both takers read the stale lock. The first takes it over and locks the
+	// book; the second must then leave that lock alone.
+	if !takeOver(lockPath, stale) {
+		t.Fatalf("Expected the first taker to remove the stale lock")
+	}
+	if err := os.WriteFile(lockPath, fresh, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if takeOver(lockPath, stale) {
+		t.Errorf("Expected the second taker not to remove the fresh lock")
+	}
+	if data, err := os.ReadFile(lockPath); err != nil || string(data) != string(fresh) {
+		t.Errorf("Expected the fresh lock to be kept, got %q, %v", data, err)
+	}
+
+	// Takers racing for the same stale lock: exactly one wins.
+	if err := os.WriteFile(lockPath, stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := make(chan bool, 8)
+	for i := 0; i < cap(results); i++ {
+		go func() { results <- takeOver(lockPath, stale) }()
+	}
+	won := 0
+	for i := 0; i < cap(results); i++ {
+		if <-results {
+			won++
+		}
+	}
+	if won != 1 {
+		t.Errorf("Expected exactly one taker to win, got %d", won)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected no files left behind, got %v", entries)
+	}
+}
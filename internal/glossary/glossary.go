@@ -0,0 +1,233 @@
+// Package glossary finds the technical terms a book introduces and keeps
+// their definitions in glossary.yaml, which authors can edit.
+package glossary
+
+import (
+	"fmt"
+	"go-book-ai/internal/markdown"
+	"go-book-ai/internal/utils"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the name of the glossary file inside a book directory.
+const FileName = "glossary.yaml"
+
+// MaxCandidates caps the candidate terms sent to the model in one call.
+const MaxCandidates = 80
+
+// Term is a glossary entry.
+type Term struct {
+	Term       string `yaml:"term"`
+	Definition string `yaml:"definition"`
+}
+
+// Glossary holds the terms of a book in alphabetical order.
+type Glossary struct {
+	Terms []Term `yaml:"terms"`
+	// Ignore lists candidate terms left out of the glossary, so they are
+	// not proposed again.
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+// Load reads a glossary file. A missing file is an empty glossary.
+func Load(path string) (*Glossary, error) {
+	var g Glossary
+	err := utils.LoadYAML(path, &g)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load glossary %s: %w", path, err)
+	}
+	return &g, nil
+}
+
+// Save writes the glossary file.
+func (g *Glossary) Save(path string) error {
+	err := utils.SaveYAML(path, g)
+	if err != nil {
+		return fmt.Errorf("failed to save glossary: %w", err)
+	}
+	return nil
+}
+
+// Has reports whether the glossary has a term, ignoring case.
+func (g *Glossary) Has(term string) bool {
+	for _, t := range g.Terms {
+		if strings.EqualFold(t.Term, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// Listed reports whether a list of terms holds term, ignoring case.
+func Listed(list []string, term string) bool {
+	for _, t := range list {
+		if strings.EqualFold(t, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds the terms that are not in the glossary yet, keeping it sorted.
+// It returns how many were added.
+func (g *Glossary) Add(terms ...Term) int {
+	added := 0
+	for _, t := range terms {
+		t.Term = strings.TrimSpace(t.Term)
+		t.Definition = strings.TrimSpace(t.Definition)
+		if t.Term == "" || t.Definition == "" || g.Has(t.Term) {
+			continue
+		}
+		g.Terms = append(g.Terms, t)
+		added++
+	}
+	sort.SliceStable(g.Terms, func(i, j int) bool {
+		return strings.ToLower(g.Terms[i].Term) < strings.ToLower(g.Terms[j].Term)
+	})
+	return added
+}
+
+// Candidate is a phrase the drafts set in bold or italics, which is how
+// technical writing marks a term where it is introduced.
+type Candidate struct {
+	Term string
+	// Context is the paragraph that first emphasizes the term.
+	Context string
+	// Sections counts the drafts that mention the term.
+	Sections int
+}
+
+// Candidates returns the terms the drafts introduce that are not in the
+// glossary yet, the most widely used first, at most MaxCandidates of them.
+// Drafts are given in reading order.
+func (g *Glossary) Candidates(drafts []*markdown.Node) []Candidate {
+	var candidates []Candidate
+	seen := map[string]bool{}
+	texts := make([]string, len(drafts))
+	for i, doc := range drafts {
+		if doc == nil {
+			continue
+		}
+		texts[i] = doc.PlainText()
+		markdown.Walk(doc, func(n *markdown.Node) bool {
+			switch n.Kind {
+			case markdown.Heading, markdown.CodeBlock:
+				return false
+			case markdown.Paragraph:
+				paragraph := n
+				markdown.Walk(n, func(n *markdown.Node) bool {
+					if n.Kind == markdown.Link {
+						return false
+					}
+					if n.Kind != markdown.Strong && n.Kind != markdown.Emphasis {
+						return true
+					}
+					term := strings.TrimSpace(n.PlainText())
+					key := strings.ToLower(term)
+					if !TermLike(term) || seen[key] || g.Has(term) || Listed(g.Ignore, term) {
+						return false
+					}
+					seen[key] = true
+					candidates = append(candidates, Candidate{Term: term, Context: context(paragraph)})
+					return false
+				})
+				return false
+			}
+			return true
+		})
+	}
+
+	for i := range candidates {
+		for _, text := range texts {
+			if Find(text, candidates[i].Term) >= 0 {
+				candidates[i].Sections++
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Sections > candidates[j].Sections
+	})
+	if len(candidates) > MaxCandidates {
+		candidates = candidates[:MaxCandidates]
+	}
+	return candidates
+}
+
+// TermLike reports whether emphasized text looks like a term rather than a
+// stressed word or a sentence: one to four words, starting with a letter and
+// not ending a sentence.
+func TermLike(text string) bool {
+	words := strings.Fields(text)
+	if len(words) == 0 || len(words) > 4 || len(text) < 3 || len(text) > 40 {
+		return false
+	}
+	if !unicode.IsLetter([]rune(text)[0]) {
+		return false
+	}
+	return !strings.ContainsAny(text[len(text)-1:], ".:;!?,")
+}
+
+// context returns the text of a paragraph, cut to about 300 bytes at a space,
+// or at a rune boundary when the start of the text has no space.
+func context(paragraph *markdown.Node) string {
+	text := strings.Join(strings.Fields(paragraph.PlainText()), " ")
+	if len(text) <= 300 {
+		return text
+	}
+	cut := strings.LastIndex(text[:300], " ")
+	if cut < 0 {
+		cut = 300
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return text[:cut] + "..."
+}
+
+// Find returns the byte offset of the first whole-word use of term in text,
+// ignoring case, or -1.
+func Find(text, term string) int {
+	if term == "" {
+		return -1
+	}
+	for start := 0; start+len(term) <= len(text); start++ {
+		end := start + len(term)
+		if !strings.EqualFold(text[start:end], term) {
+			continue
+		}
+		if (start == 0 || !wordByte(text[start-1])) && (end == len(text) || !wordByte(text[end])) {
+			return start
+		}
+	}
+	return -1
+}
+
+func wordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// ParseDefinitions reads the terms the model defined, a YAML list of term and
+// definition pairs, optionally in a code fence.
+func ParseDefinitions(response string) ([]Term, error) {
+	var terms []Term
+	err := yaml.Unmarshal([]byte(StripFence(response)), &terms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse glossary definitions: %w", err)
+	}
+	return terms, nil
+}
+
+// StripFence returns a model reply without the code fence around it, if any.
+func StripFence(response string) string {
+	text := strings.TrimSpace(response)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text[strings.Index(text, "\n")+1:], "\n")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	return text
+}
@@ -0,0 +1,85 @@
+package glossary
+
+import (
+	"go-book-ai/internal/markdown"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCandidates(t *testing.T) {
+	g := &Glossary{
+		Terms:  []Term{{Term: "Channel", Definition: "A typed conduit."}},
+		Ignore: []string{"really"},
+	}
+	drafts := []*markdown.Node{
+		markdown.Parse("# A **Heading Term**\n\nA **goroutine** is cheap. It is *really* cheap, unlike a **channel**.\n\n**Note: this is a whole sentence.**\n"),
+		markdown.Parse("Start a goroutine per request with a *worker pool*.\n"),
+	}
+
+	var terms []string
+	for _, c := range g.Candidates(drafts) {
+		terms = append(terms, c.Term)
+	}
+	if !reflect.DeepEqual(terms, []string{"goroutine", "worker pool"}) {
+		t.Fatalf("Unexpected candidates %v", terms)
+	}
+	if c := g.Candidates(drafts)[0]; c.Sections != 2 || c.Context != "A goroutine is cheap. It is really cheap, unlike a channel." {
+		t.Errorf("Unexpected candidate %+v", c)
+	}
+}
+
+func TestCandidateContextWithoutSpaces(t *testing.T) {
+	long := strings.Repeat("用", 150)
+	doc := markdown.Parse(long + "的**协程**很便宜。\n")
+	candidates := (&Glossary{}).Candidates([]*markdown.Node{doc})
+	if len(candidates) != 1 {
+		t.Fatalf("Expected one candidate, got %+v", candidates)
+	}
+	context := candidates[0].Context
+	if !utf8.ValidString(context) || !strings.HasSuffix(context, "...") || len(context) > 303 {
+		t.Errorf("Unexpected context %q", context)
+	}
+}
+
+func TestFind(t *testing.T) {
+	for _, tc := range []struct {
+		text, term string
+		want       int
+	}{
+		{"Goroutines and a goroutine.", "goroutine", 17},
+		{"A Goroutine.", "goroutine", 2},
+		{"No match in subgoroutine.", "goroutine", -1},
+	} {
+		if got := Find(tc.text, tc.term); got != tc.want {
+			t.Errorf("Find(%q, %q) = %d, want %d", tc.text, tc.term, got, tc.want)
+		}
+	}
+}
+
+func TestSaveAndAdd(t *testing.T) {
+	terms, err := ParseDefinitions("```yaml\n- term: slice\n  definition: A view of an array.\n- term: Channel\n  definition: Duplicate.\n- term: array\n  definition: A fixed-size sequence.\n```\n")
+	if err != nil {
+		t.Fatalf("ParseDefinitions returned error: %v", err)
+	}
+	g := &Glossary{Terms: []Term{{Term: "Channel", Definition: "A typed conduit."}}}
+	if added := g.Add(terms...); added != 2 {
+		t.Errorf("Expected 2 terms added, got %d", added)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	err = g.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	want := []Term{{"array", "A fixed-size sequence."}, {"Channel", "A typed conduit."}, {"slice", "A view of an array."}}
+	if !reflect.DeepEqual(loaded.Terms, want) {
+		t.Errorf("Unexpected glossary %v", loaded.Terms)
+	}
+}
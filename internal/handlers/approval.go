@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/outline"
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// writeOutlineForApproval writes the book outline to OUTLINE.yaml so it can be
+// edited by hand, and marks the state as waiting for approval.
+func (h *BookCommandHandler) writeOutlineForApproval(bookPath string, bookState *state.State) error {
+	err := outlineFromState(bookState).Save(bookPath)
+	if err != nil {
+		return h.handleError("failed to write outline for approval", err)
+	}
+	bookState.PendingApproval = true
+	h.Logger.Info(fmt.Sprintf("Book outline written to %s for review", filepath.Join(bookPath, "OUTLINE.yaml")))
+	return nil
+}
+
+// writeChapterOutlineForApproval writes a chapter outline to the chapter's
+// OUTLINE.yaml and marks the chapter as waiting for approval.
+func (h *BookCommandHandler) writeChapterOutlineForApproval(chapterPath string, chapterState *state.ChapterState) error {
+	err := os.MkdirAll(chapterPath, os.ModePerm)
+	if err != nil {
+		return h.handleError("failed to create chapter directory", err)
+	}
+	err = chapterOutlineFromState(chapterState).Save(chapterPath)
+	if err != nil {
+		return h.handleError("failed to write chapter outline for approval", err)
+	}
+	chapterState.PendingApproval = true
+	h.Logger.Info(fmt.Sprintf("Chapter outline written to %s for review", filepath.Join(chapterPath, "OUTLINE.yaml")))
+	return nil
+}
+
+// ApproveBook re-reads any outlines waiting for approval, including edits made
+// by hand, into the book state and then resumes processing the book.
+func (h *BookCommandHandler) ApproveBook(topic string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !bookState.AwaitingApproval() {
+		h.Logger.Info("Nothing is waiting for approval.")
+		return h.ProcessBook(topic)
+	}
+
+	err = h.approveOutlines(bookPath, bookState)
+	if err != nil {
+		return err
+	}
+
+	err = h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		return fmt.Errorf("failed to save state after approval: %w", err)
+	}
+
+	return h.ProcessBook(topic)
+}
+
+// approveOutlines applies the outlines waiting for approval to the state,
+// recording those changed by hand as edited.
+func (h *BookCommandHandler) approveOutlines(bookPath string, bookState *state.State) error {
+	if bookState.PendingApproval {
+		bookOutline, err := outline.LoadOutline(bookPath)
+		if err != nil {
+			return h.handleError("failed to read approved outline", err)
+		}
+		written := outlineFromState(bookState)
+		applyOutline(bookState, bookOutline)
+		bookState.AssignIDs()
+		if !sameOutline(written, outlineFromState(bookState)) {
+			bookState.OutlineProvenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+		}
+		bookState.PendingApproval = false
+		h.Logger.Info(fmt.Sprintf("Book outline approved with %d chapters", len(bookState.Chapters)))
+	} else {
+		for i := range bookState.Chapters {
+			if !bookState.Chapters[i].PendingApproval {
+				continue
+			}
+			chapterOutline, err := outline.LoadChapterOutline(chapterPath(bookPath, &bookState.Chapters[i]))
+			if err != nil {
+				return h.handleError("failed to read approved chapter outline", err)
+			}
+			written := chapterOutlineFromState(&bookState.Chapters[i])
+			applyChapterOutline(&bookState.Chapters[i], chapterOutline)
+			bookState.Chapters[i].OutlineInputs = h.chapterOutlineInputs(&bookState.Chapters[i])
+			bookState.AssignIDs()
+			if !sameOutline(written, chapterOutlineFromState(&bookState.Chapters[i])) {
+				bookState.Chapters[i].OutlineProvenance = bookState.Chapters[i].OutlineProvenance.Edited(time.Now().UTC())
+			}
+			bookState.Chapters[i].PendingApproval = false
+			h.Logger.Info(fmt.Sprintf("Chapter outline approved: %s", bookState.Chapters[i].Title))
+		}
+		if !bookState.AwaitingApproval() && bookState.ChaptersOutlined() {
+			bookState.RequireChapterApproval = false
+		}
+	}
+	return nil
+}
+
+// sameOutline reports whether two outlines would be written identically, so
+// an approval only counts as an edit when the file was changed.
+func sameOutline(a, b interface{}) bool {
+	x, errX := yaml.Marshal(a)
+	y, errY := yaml.Marshal(b)
+	return errX == nil && errY == nil && string(x) == string(y)
+}
+
+// outlineFromState builds an editable book outline from the state.
+func outlineFromState(bookState *state.State) *outline.Outline {
+	o := outline.NewOutline(bookState.Title)
+	for i := range bookState.Chapters {
+		chapterOutline := chapterOutlineFromState(&bookState.Chapters[i])
+		o.Chapters = append(o.Chapters, outline.Chapter{
+			ID:          bookState.Chapters[i].ID,
+			Title:       chapterOutline.Title,
+			Description: bookState.Chapters[i].Description,
+			Sections:    chapterOutline.Sections,
+		})
+	}
+	return o
+}
+
+// chapterOutlineFromState builds an editable chapter outline from the state.
+func chapterOutlineFromState(chapterState *state.ChapterState) *outline.ChapterOutline {
+	c := outline.NewChapterOutline(chapterState.Title)
+	for _, section := range chapterState.Sections {
+		c.Sections = append(c.Sections, sectionOutline(section))
+	}
+	return c
+}
+
+// sectionOutline converts a section state into the outline type the writing
+// agent expects.
+func sectionOutline(section state.SectionState) outline.Section {
+	subsections := make([]outline.Subsection, len(section.Subsections))
+	for k, subsection := range section.Subsections {
+		subsections[k] = outline.Subsection{Title: subsection.Title, Description: subsection.Description}
+	}
+	return outline.Section{
+		ID:          section.ID,
+		Title:       section.Title,
+		Description: section.Description,
+		Subsections: subsections,
+	}
+}
+
+// applyOutline replaces the chapters in the state with the given outline.
+// Chapter outlines still have to be generated afterwards.
+func applyOutline(bookState *state.State, o *outline.Outline) {
+	if o.Title != "" {
+		bookState.Title = o.Title
+	}
+	bookState.OutlineGenerated = true
+	bookState.Chapters = make([]state.ChapterState, len(o.Chapters))
+	for i, chapter := range o.Chapters {
+		bookState.Chapters[i].ID = chapter.ID
+		bookState.Chapters[i].Description = chapter.Description
+		applyChapterOutline(&bookState.Chapters[i], &outline.ChapterOutline{Title: chapter.Title, Sections: chapter.Sections})
+		bookState.Chapters[i].OutlineGenerated = false
+	}
+}
+
+// applyChapterOutline replaces the title and sections of a chapter with the
+// given chapter outline.
+func applyChapterOutline(chapterState *state.ChapterState, c *outline.ChapterOutline) {
+	if c.Title != "" {
+		chapterState.Title = c.Title
+	}
+	chapterState.OutlineGenerated = true
+	chapterState.DraftGenerated = false
+	chapterState.Sections = make([]state.SectionState, len(c.Sections))
+	for j, section := range c.Sections {
+		subsections := make([]state.SubsectionState, len(section.Subsections))
+		for k, subsection := range section.Subsections {
+			subsections[k] = state.SubsectionState{Title: subsection.Title, Description: subsection.Description}
+		}
+		chapterState.Sections[j] = state.SectionState{
+			ID:          section.ID,
+			Title:       section.Title,
+			Description: section.Description,
+			Subsections: subsections,
+		}
+	}
+}
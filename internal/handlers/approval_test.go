@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"go-book-ai/internal/outline"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestApprovalGatesProcessing(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	h := newTestHandler(t, func(prompt string) (string, error) {
+		mu.Lock()
+		prompts = append(prompts, prompt)
+		mu.Unlock()
+		switch {
+		case prompt == "outline: go":
+			return "title: Go\nchapters:\n- title: Basics\n- title: Tooling\n", nil
+		case strings.HasPrefix(prompt, "chapter: "):
+			return "sections:\n- title: Overview of " + strings.TrimPrefix(prompt, "chapter: ") + "\n", nil
+		}
+		return "", nil
+	})
+	h.RequireOutlineApproval = true
+	h.RequireChapterApproval = true
+	asked := func(prefix string) int {
+		n := 0
+		for _, prompt := range prompts {
+			if strings.HasPrefix(prompt, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	err := h.ProcessBook("go")
+	if err != nil {
+		t.Fatalf("ProcessBook returned error: %v", err)
+	}
+	bookPath := h.bookDir("go")
+	bookState, err := h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bookState.PendingApproval || asked("chapter: ") != 0 {
+		t.Fatalf("Expected processing to stop at the book outline, got pending %v after %q", bookState.PendingApproval, prompts)
+	}
+
+	// Later runs keep the gates without being asked again.
+	h.RequireOutlineApproval = false
+	h.RequireChapterApproval = false
+	err = h.ProcessBook("go")
+	if err != nil {
+		t.Fatalf("ProcessBook returned error: %v", err)
+	}
+	if asked("chapter: ") != 0 {
+		t.Fatalf("Expected chapter outlines to wait for approval, got %q", prompts)
+	}
+
+	bookOutline, err := outline.LoadOutline(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookOutline.Chapters = bookOutline.Chapters[:1]
+	bookOutline.Chapters[0].Title = "Go Basics"
+	err = bookOutline.Save(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = h.ApproveBook("go")
+	if err != nil {
+		t.Fatalf("ApproveBook returned error: %v", err)
+	}
+	bookState, err = h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookState.Chapters) != 1 || asked("chapter: ") != 1 || asked("chapter: Go Basics") != 1 {
+		t.Fatalf("Expected one chapter outline for the edited chapter, got %q", prompts)
+	}
+	chapter := &bookState.Chapters[0]
+	if !chapter.PendingApproval || asked("section: ") != 0 {
+		t.Fatalf("Expected processing to stop at the chapter outline, got pending %v after %q", chapter.PendingApproval, prompts)
+	}
+
+	chapterOutline, err := outline.LoadChapterOutline(chapterPath(bookPath, chapter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapterOutline.Sections = append(chapterOutline.Sections, outline.Section{Title: "Modules"})
+	err = chapterOutline.Save(chapterPath(bookPath, chapter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = h.ApproveBook("go")
+	if err != nil {
+		t.Fatalf("ApproveBook returned error: %v", err)
+	}
+	bookState, err = h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapter = &bookState.Chapters[0]
+	if chapter.PendingApproval || !chapter.DraftGenerated || len(chapter.Sections) != 2 {
+		t.Fatalf("Expected the approved chapter to be drafted, got %+v", chapter)
+	}
+	if asked("section: Overview of Go Basics") != 1 || asked("section: Modules") != 1 {
+		t.Errorf("Expected a draft of each approved section, got %q", prompts)
+	}
+	if chapter.Sections[0].ID != "section1" || chapter.Sections[1].ID != "section2" {
+		t.Errorf("Expected the added section to get the next ID, got %+v", chapter.Sections)
+	}
+	if bookState.RequireChapterApproval {
+		t.Errorf("Expected the chapter approval gate to be lifted once every chapter is approved")
+	}
+}
@@ -3,18 +3,24 @@ package handlers
 import (
 	"fmt"
 	"go-book-ai/internal/agents"
+	"go-book-ai/internal/config"
 	"go-book-ai/internal/errors"
 	"go-book-ai/internal/file"
 	"go-book-ai/internal/logger"
-	"go-book-ai/internal/outline"
 	"go-book-ai/internal/state"
+	"go-book-ai/internal/store"
+	"go-book-ai/internal/transcript"
 	"go-book-ai/internal/utils"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"gopkg.in/yaml.v2"
 )
 
+// DefaultWorkspace is the workspace used when none is configured.
+const DefaultWorkspace = "books"
+
 // BookCommandHandler handles book-related commands.
 type BookCommandHandler struct {
 	WritingAgent   agents.WritingAgent
@@ -22,16 +28,39 @@ type BookCommandHandler struct {
 	FileManager    *file.FileManager
 	ErrorHandler   *errors.ErrorHandler
 	Logger         logger.Logger
+	StateStore     store.StateStore
+	// RunID identifies this run in provenance records and the transcript.
+	RunID string
+	// Workspace is the directory holding one directory per book.
+	Workspace string
+	// Config is the effective configuration of the open book. When nil the
+	// built-in defaults and the writing agent's model are used.
+	Config *config.Config
+	// ConfigLoader, when set, is used to apply each book's configuration,
+	// including its book.yaml, when the book is opened.
+	ConfigLoader *config.Loader
+
+	// RequireOutlineApproval pauses processing after the book outline is
+	// generated until it has been reviewed and approved.
+	RequireOutlineApproval bool
+	// RequireChapterApproval pauses processing after the chapter outlines
+	// are generated until they have been reviewed and approved. The choice
+	// is kept in the book state until then.
+	RequireChapterApproval bool
 }
 
 // NewBookCommandHandler returns a new BookCommandHandler.
 func NewBookCommandHandler(writingAgent agents.WritingAgent, reviewingAgent agents.ReviewingAgent, fm *file.FileManager, eh *errors.ErrorHandler, lg logger.Logger) *BookCommandHandler {
-	return &BookCommandHandler{WritingAgent: writingAgent, ReviewingAgent: reviewingAgent, FileManager: fm, ErrorHandler: eh, Logger: lg}
+	stateStore, err := store.NewAutoStore(store.BackendYAML)
+	if err != nil {
+		// The default backend is a constant, so this is a programming error.
+		panic(err)
+	}
+	return &BookCommandHandler{WritingAgent: writingAgent, ReviewingAgent: reviewingAgent, FileManager: fm, ErrorHandler: eh, Logger: lg, StateStore: stateStore, RunID: newRunID(), Workspace: DefaultWorkspace}
 }
 
 func (h *BookCommandHandler) ProcessBook(topic string) error {
-	folderName := utils.CleanName(topic)
-	bookPath := filepath.Join("books", folderName)
+	bookPath := h.bookDir(topic)
 	h.Logger.Info(fmt.Sprintf("Processing book folder: %s", bookPath))
 
 	// Ensure the book directory exists
@@ -40,24 +69,48 @@ func (h *BookCommandHandler) ProcessBook(topic string) error {
 		return fmt.Errorf("failed to create book directory: %v", err)
 	}
 
-	stateFilePath := filepath.Join(bookPath, "state.yaml")
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = h.configure(bookPath)
+	if err != nil {
+		return err
+	}
 
 	// Load state
-	bookState, err := h.FileManager.LoadState(stateFilePath)
+	bookState, err := h.StateStore.Open(bookPath)
 	if err != nil {
 		return fmt.Errorf("failed to load state: %v", err)
 	}
 
 	h.Logger.Debug(fmt.Sprintf("Loaded state: %+v", bookState))
 
+	// The chapter approval gate holds until the chapters are approved, even
+	// when a later run does not ask for it.
+	if h.RequireChapterApproval && !bookState.ChaptersOutlined() {
+		bookState.RequireChapterApproval = true
+	}
+
 	// Check if the outline has already been generated
+	if !bookState.OutlineGenerated && !h.settings().StageEnabled(config.StageBookOutline) {
+		return fmt.Errorf("the book outline stage is disabled for this book; import an outline with: bookcli new %q --from-outline <file>", topic)
+	}
 	if !bookState.OutlineGenerated {
 		err := h.generateBookOutline(topic, bookPath, bookState)
 		if err != nil {
 			return err
 		}
 		h.Logger.Debug(fmt.Sprintf("State after generating book outline: %+v", bookState))
-		err = h.FileManager.SaveState(stateFilePath, bookState)
+		if h.RequireOutlineApproval {
+			err = h.writeOutlineForApproval(bookPath, bookState)
+			if err != nil {
+				return err
+			}
+		}
+		err = h.StateStore.Save(bookPath, bookState)
 		if err != nil {
 			return fmt.Errorf("failed to save state after outline generation: %w", err)
 		}
@@ -65,68 +118,75 @@ func (h *BookCommandHandler) ProcessBook(topic string) error {
 		h.Logger.Info("Outline already generated, skipping outline generation.")
 	}
 
+	if bookState.PendingApproval {
+		h.Logger.Info(fmt.Sprintf("Book outline is waiting for approval. Edit OUTLINE.yaml and run: bookcli approve %q", topic))
+		return nil
+	}
+
 	err = h.generateChapterOutlines(bookPath, bookState)
 	if err != nil {
 		return err
 	}
 	h.Logger.Debug(fmt.Sprintf("State after generating chapter outlines: %+v", bookState))
-	err = h.FileManager.SaveState(stateFilePath, bookState)
+	err = h.StateStore.Save(bookPath, bookState)
 	if err != nil {
 		return fmt.Errorf("failed to save state after chapter outlines generation: %w", err)
 	}
 
+	if bookState.AwaitingApproval() {
+		h.Logger.Info(fmt.Sprintf("Chapter outlines are waiting for approval. Edit the chapter OUTLINE.yaml files and run: bookcli approve %q", topic))
+		return nil
+	}
+
 	err = h.generateDrafts(bookPath, bookState)
 	if err != nil {
 		return err
 	}
 	h.Logger.Debug(fmt.Sprintf("State after generating drafts: %+v", bookState))
-	err = h.FileManager.SaveState(stateFilePath, bookState)
+	err = h.StateStore.Save(bookPath, bookState)
 	if err != nil {
 		return fmt.Errorf("failed to save state after drafts generation: %w", err)
 	}
 
-	h.Logger.Info(fmt.Sprintf("Book processing completed for topic: %s", topic))
-	return nil
-}
-
-func (h *BookCommandHandler) generateBookOutline(topic, bookPath string, bookState *state.State) error {
-	err := os.MkdirAll(bookPath, os.ModePerm)
+	err = h.generateMatter(bookPath, bookState)
 	if err != nil {
-		return h.handleError("failed to create book directory", err)
+		return err
 	}
 
-	h.Logger.Info("Generating book outline...")
-
-	prompt, err := h.WritingAgent.GenerateOutline(topic)
+	err = h.generateGlossary(bookPath, bookState)
 	if err != nil {
-		return h.handleError("failed to generate outline prompt", err)
+		return err
 	}
 
-	outlineContent, err := h.WritingAgent.SendMessage(prompt)
+	err = h.generateIndex(bookPath, bookState)
 	if err != nil {
-		if !h.ErrorHandler.HandleError(h.handleError("failed to generate book outline", err)) {
-			return fmt.Errorf("retry attempts exhausted")
-		}
+		return err
 	}
 
-	h.Logger.Debug(fmt.Sprintf("Generated outline content:\n%s", outlineContent))
+	h.Logger.Info(fmt.Sprintf("Book processing completed for topic: %s", topic))
+	return nil
+}
 
-	var outline struct {
-		Title    string               `yaml:"title"`
-		Chapters []state.ChapterState `yaml:"chapters"`
+func (h *BookCommandHandler) generateBookOutline(topic, bookPath string, bookState *state.State) error {
+	err := os.MkdirAll(bookPath, os.ModePerm)
+	if err != nil {
+		return h.handleError("failed to create book directory", err)
 	}
 
-	err = yaml.Unmarshal([]byte(outlineContent), &outline)
+	h.Logger.Info("Generating book outline...")
+
+	bookOutline, outlineContent, provenance, err := h.requestBookOutline(topic, bookPath)
 	if err != nil {
-		return h.handleError("failed to parse generated outline", err)
+		return err
 	}
 
-	bookState.OutlineGenerated = true
-	bookState.Chapters = outline.Chapters
+	applyOutline(bookState, bookOutline)
+	bookState.AssignIDs()
+	bookState.OutlineProvenance = provenance
 
-	for i := range bookState.Chapters {
-		bookState.Chapters[i].OutlineGenerated = false
-		bookState.Chapters[i].DraftGenerated = false
+	chapters := h.settings().Chapters
+	if n := len(bookState.Chapters); (chapters.Min > 0 && n < chapters.Min) || (chapters.Max > 0 && n > chapters.Max) {
+		h.Logger.Info(fmt.Sprintf("The generated outline has %d chapters, outside the configured range %s. Edit it with the outline commands or regenerate it.", n, chapters))
 	}
 
 	bookState.MessageHistory = append(bookState.MessageHistory, state.Message{Role: "assistant", Content: outlineContent})
@@ -136,6 +196,11 @@ func (h *BookCommandHandler) generateBookOutline(topic, bookPath string, bookSta
 }
 
 func (h *BookCommandHandler) generateChapterOutlines(bookPath string, bookState *state.State) error {
+	if !h.settings().StageEnabled(config.StageChapterOutline) {
+		h.Logger.Info("Chapter outline stage is disabled, skipping chapter outlines.")
+		return nil
+	}
+
 	for i, chapterState := range bookState.Chapters {
 		if chapterState.OutlineGenerated && chapterState.DraftGenerated {
 			continue
@@ -148,12 +213,12 @@ func (h *BookCommandHandler) generateChapterOutlines(bookPath string, bookState
 
 		h.Logger.Info(fmt.Sprintf("Generating outline for chapter: %s", chapterState.Title))
 
-		prompt, err := h.WritingAgent.GenerateChapterOutline(chapterState.Title)
+		prompt, err := h.chapterOutlinePrompt(chapterState.Title, chapterState.Description)
 		if err != nil {
 			return h.handleError("failed to generate chapter outline prompt", err)
 		}
 
-		chapterOutlineContent, err := h.WritingAgent.SendMessage(prompt)
+		chapterOutlineContent, provenance, err := h.send(bookPath, transcript.StageChapterOutline, chapterState.ID, prompt)
 		if err != nil {
 			if !h.ErrorHandler.HandleError(h.handleError("failed to generate chapter outline", err)) {
 				return fmt.Errorf("retry attempts exhausted")
@@ -171,10 +236,21 @@ func (h *BookCommandHandler) generateChapterOutlines(bookPath string, bookState
 
 		bookState.Chapters[i].OutlineGenerated = true
 		bookState.Chapters[i].Sections = chapterOutline.Sections
+		bookState.Chapters[i].OutlineInputs = h.chapterOutlineInputs(&bookState.Chapters[i])
+		provenance.PromptVersion = bookState.Chapters[i].OutlineInputs.Prompt
+		bookState.Chapters[i].OutlineProvenance = provenance
 
 		for j := range bookState.Chapters[i].Sections {
 			bookState.Chapters[i].Sections[j].DraftGenerated = false
 		}
+		bookState.AssignIDs()
+
+		if bookState.RequireChapterApproval {
+			err = h.writeChapterOutlineForApproval(chapterPath(bookPath, &bookState.Chapters[i]), &bookState.Chapters[i])
+			if err != nil {
+				return err
+			}
+		}
 
 		bookState.MessageHistory = append(bookState.MessageHistory, state.Message{Role: "assistant", Content: chapterOutlineContent})
 	}
@@ -184,57 +260,74 @@ func (h *BookCommandHandler) generateChapterOutlines(bookPath string, bookState
 }
 
 func (h *BookCommandHandler) generateDrafts(bookPath string, bookState *state.State) error {
-	for i, chapterState := range bookState.Chapters {
+	if !h.settings().StageEnabled(config.StageDraft) {
+		h.Logger.Info("Draft stage is disabled, skipping drafts.")
+		return nil
+	}
+
+	type job struct{ chapter, section int }
+	var jobs []job
+	for i := range bookState.Chapters {
+		chapterState := &bookState.Chapters[i]
 		if chapterState.OutlineGenerated && !chapterState.DraftGenerated {
-			chapterPath := filepath.Join(bookPath, fmt.Sprintf("ch%d", i+1))
 			for j, section := range chapterState.Sections {
 				if !section.DraftGenerated {
-					h.Logger.Info(fmt.Sprintf("Generating draft for section: %s", section.Title))
-					subsections := make([]outline.Subsection, len(section.Subsections))
-					for k, subsection := range section.Subsections {
-						subsections[k] = outline.Subsection{Title: subsection.Title}
-					}
-					sectionContent := outline.Section{
-						Title:       section.Title,
-						Subsections: subsections,
-					}
-
-					prompt, err := h.WritingAgent.GenerateSectionContent(sectionContent)
-					if err != nil {
-						return h.handleError("failed to generate section content prompt", err)
-					}
-
-					content, err := h.WritingAgent.SendMessage(prompt)
-					if err != nil {
-						if !h.ErrorHandler.HandleError(h.handleError("failed to generate section content", err)) {
-							return fmt.Errorf("retry attempts exhausted")
-						}
-					}
-
-					sectionPath := filepath.Join(chapterPath, fmt.Sprintf("section%d", j+1))
-					err = os.MkdirAll(sectionPath, os.ModePerm)
-					if err != nil {
-						return h.handleError("failed to create section directory", err)
-					}
+					jobs = append(jobs, job{i, j})
+				}
+			}
+		}
+	}
 
-					err = h.FileManager.SaveSectionContent(content, filepath.Join(sectionPath, "draft.md"))
-					if err != nil {
-						return h.handleError("failed to save section content", err)
-					}
+	workers := h.settings().Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
 
-					chapterState.Sections[j].DraftGenerated = true
-					err = h.FileManager.SaveState(filepath.Join(bookPath, "state.yaml"), bookState)
-					if err != nil {
-						return h.handleError("failed to save state", err)
+	// Drafts are generated by a pool of workers. The state is only touched
+	// with mu held; after the first error no new drafts are started.
+	var mu sync.Mutex
+	var firstErr error
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next := range queue {
+				err := h.generateDraft(bookPath, bookState, &mu, next.chapter, next.section)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
 					}
-
-					// Add reference to saved content in the history
-					bookState.MessageHistory = append(bookState.MessageHistory, state.Message{Role: "assistant", Content: fmt.Sprintf("Content saved to %s", filepath.Join(sectionPath, "draft.md"))})
+					mu.Unlock()
 				}
 			}
+		}()
+	}
+	for _, next := range jobs {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		queue <- next
+	}
+	close(queue)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
 
+	for i := range bookState.Chapters {
+		chapterState := &bookState.Chapters[i]
+		if chapterState.OutlineGenerated && !chapterState.DraftGenerated {
 			chapterState.DraftGenerated = true
-			err := h.FileManager.SaveState(filepath.Join(bookPath, "state.yaml"), bookState)
+			err := h.StateStore.Save(bookPath, bookState)
 			if err != nil {
 				return h.handleError("failed to save state", err)
 			}
@@ -245,6 +338,164 @@ func (h *BookCommandHandler) generateDrafts(bookPath string, bookState *state.St
 	return nil
 }
 
+// generateDraft generates and saves the draft of one section. mu guards the
+// book state, which other drafts update concurrently.
+func (h *BookCommandHandler) generateDraft(bookPath string, bookState *state.State, mu *sync.Mutex, i, j int) error {
+	mu.Lock()
+	chapterState := &bookState.Chapters[i]
+	section := chapterState.Sections[j]
+	itemID := chapterState.ID + "/" + section.ID
+	sectionPath := sectionPath(bookPath, chapterState, &section)
+	mu.Unlock()
+
+	h.Logger.Info(fmt.Sprintf("Generating draft for section: %s", section.Title))
+	prompt, err := h.draftPrompt(section)
+	if err != nil {
+		return h.handleError("failed to generate section content prompt", err)
+	}
+
+	content, provenance, err := h.send(bookPath, transcript.StageDraft, itemID, prompt)
+	if err != nil {
+		if !h.ErrorHandler.HandleError(h.handleError("failed to generate section content", err)) {
+			return fmt.Errorf("retry attempts exhausted")
+		}
+	}
+
+	err = os.MkdirAll(sectionPath, os.ModePerm)
+	if err != nil {
+		return h.handleError("failed to create section directory", err)
+	}
+
+	err = h.FileManager.SaveSectionContent(content, filepath.Join(sectionPath, "draft.md"))
+	if err != nil {
+		return h.handleError("failed to save section content", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	chapterState.Sections[j].DraftGenerated = true
+	chapterState.Sections[j].DraftInputs = h.draftInputs(chapterState, &chapterState.Sections[j])
+	provenance.PromptVersion = chapterState.Sections[j].DraftInputs.Prompt
+	provenance.ContentHash = state.Hash(content)
+	chapterState.Sections[j].DraftProvenance = provenance
+	err = h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		return h.handleError("failed to save state", err)
+	}
+
+	// Add reference to saved content in the history
+	bookState.MessageHistory = append(bookState.MessageHistory, state.Message{Role: "assistant", Content: fmt.Sprintf("Content saved to %s", filepath.Join(sectionPath, "draft.md"))})
+	return nil
+}
+
+// configure applies the configuration of a book, including its book.yaml, to
+// the handler.
+func (h *BookCommandHandler) configure(bookPath string) error {
+	if h.ConfigLoader == nil {
+		return nil
+	}
+	cfg, err := h.ConfigLoader.Load(bookPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	h.Config = cfg
+	h.ErrorHandler.RetryLimit = cfg.Retries
+	return nil
+}
+
+// workspaceDir returns the directory holding the books.
+func (h *BookCommandHandler) workspaceDir() string {
+	if h.Workspace == "" {
+		return DefaultWorkspace
+	}
+	return h.Workspace
+}
+
+// bookDir returns the directory of the book with the given topic.
+func (h *BookCommandHandler) bookDir(topic string) string {
+	return filepath.Join(h.workspaceDir(), utils.CleanName(topic))
+}
+
+// loadBook loads the state of an existing book, returning the book directory
+// along with it.
+func (h *BookCommandHandler) loadBook(topic string) (string, *state.State, error) {
+	bookPath := h.bookDir(topic)
+	if !h.StateStore.Exists(bookPath) {
+		return "", nil, fmt.Errorf("no book found at %s", bookPath)
+	}
+	err := h.configure(bookPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	bookState, err := h.StateStore.Load(bookPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load state: %v", err)
+	}
+	return bookPath, bookState, nil
+}
+
+// openBook locks an existing book for changes and loads its state. The
+// returned function releases the lock.
+func (h *BookCommandHandler) openBook(topic string) (string, *state.State, func(), error) {
+	bookPath := h.bookDir(topic)
+	if !h.StateStore.Exists(bookPath) {
+		return "", nil, nil, fmt.Errorf("no book found at %s", bookPath)
+	}
+
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	err = h.configure(bookPath)
+	if err != nil {
+		unlock()
+		return "", nil, nil, err
+	}
+
+	bookState, err := h.StateStore.Open(bookPath)
+	if err != nil {
+		unlock()
+		return "", nil, nil, fmt.Errorf("failed to load state: %v", err)
+	}
+	return bookPath, bookState, unlock, nil
+}
+
+// MigrateStateBackend moves the state of a book to another storage backend.
+func (h *BookCommandHandler) MigrateStateBackend(topic, backend string) error {
+	autoStore, ok := h.StateStore.(*store.AutoStore)
+	if !ok {
+		return fmt.Errorf("the configured state store cannot switch backends")
+	}
+	if _, err := store.NewStore(backend); err != nil {
+		return err
+	}
+
+	bookPath, _, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	from := autoStore.Backend(bookPath)
+	err = autoStore.Convert(bookPath, backend)
+	if err != nil {
+		return h.handleError("failed to migrate state", err)
+	}
+	h.Logger.Info(fmt.Sprintf("Migrated state of %s from %s to %s", bookPath, from, backend))
+	return nil
+}
+
+// chapterPath returns the directory holding a chapter's outline and sections.
+func chapterPath(bookPath string, chapter *state.ChapterState) string {
+	return filepath.Join(bookPath, chapter.ID)
+}
+
+// sectionPath returns the directory holding a section's drafts.
+func sectionPath(bookPath string, chapter *state.ChapterState, section *state.SectionState) string {
+	return filepath.Join(bookPath, chapter.ID, section.ID)
+}
+
 func (h *BookCommandHandler) handleError(message string, err error) error {
 	h.ErrorHandler.LogError(fmt.Errorf("%s: %w", message, err))
 	return fmt.Errorf("%s: %w", message, err)
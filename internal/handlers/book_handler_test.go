@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/bookindex"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/errors"
+	"go-book-ai/internal/file"
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/logger"
+	"go-book-ai/internal/models"
+	"go-book-ai/internal/outline"
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAgent is a WritingAgent whose prompts name what they ask for, like
+// "section: Why Go", and whose replies come from respond.
+type fakeAgent struct {
+	respond func(prompt string) (string, error)
+}
+
+func (a *fakeAgent) GenerateOutline(topic string) (string, error) {
+	return "outline: " + topic, nil
+}
+
+func (a *fakeAgent) GenerateChapterOutline(chapterTitle string) (string, error) {
+	return "chapter: " + chapterTitle, nil
+}
+
+func (a *fakeAgent) GenerateSectionContent(section outline.Section) (string, error) {
+	return "section: " + section.Title, nil
+}
+
+func (a *fakeAgent) GenerateMatter(kind, title, bookTitle string, chapters []string) (string, error) {
+	return "matter: " + kind, nil
+}
+
+func (a *fakeAgent) GenerateGlossary(bookTitle string, candidates []glossary.Candidate) (string, error) {
+	var terms []string
+	for _, c := range candidates {
+		terms = append(terms, c.Term)
+	}
+	return "glossary: " + strings.Join(terms, ", "), nil
+}
+
+func (a *fakeAgent) GenerateIndex(bookTitle string, candidates []bookindex.Candidate) (string, error) {
+	var terms []string
+	for _, c := range candidates {
+		terms = append(terms, c.Term)
+	}
+	return "index: " + strings.Join(terms, ", "), nil
+}
+
+func (a *fakeAgent) SendMessage(prompt string) (string, error) {
+	return a.respond(prompt)
+}
+
+func (a *fakeAgent) ModelName() string {
+	return "fake"
+}
+
+func (a *fakeAgent) Exchange(prompt string, opts models.Options) (string, *models.CallInfo, error) {
+	response, err := a.respond(prompt)
+	return response, &models.CallInfo{Provider: "fake"}, err
+}
+
+// newTestHandler returns a handler with a workspace in a temporary directory
+// that sends its prompts to respond.
+func newTestHandler(t *testing.T, respond func(prompt string) (string, error)) *BookCommandHandler {
+	t.Helper()
+	lg := logger.NewSimpleLogger()
+	h := NewBookCommandHandler(&fakeAgent{respond: respond}, nil, file.NewFileManager(lg), errors.NewErrorHandler(0), lg)
+	h.Workspace = t.TempDir()
+	return h
+}
+
+// saveTestBook saves the state of a book and the given drafts, keyed by
+// "chapterID/sectionID", and returns the book directory.
+func saveTestBook(t *testing.T, h *BookCommandHandler, topic string, bookState *state.State, drafts map[string]string) string {
+	t.Helper()
+	bookPath := h.bookDir(topic)
+	err := os.MkdirAll(bookPath, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+	for id, content := range drafts {
+		dir := filepath.Join(bookPath, filepath.FromSlash(id))
+		err = os.MkdirAll(dir, os.ModePerm)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, "draft.md"), []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bookPath
+}
+
+// twoSectionBook returns the state of a book with one outlined chapter of two
+// drafted sections.
+func twoSectionBook() *state.State {
+	bookState := state.NewState()
+	bookState.Title = "Go"
+	bookState.OutlineGenerated = true
+	bookState.Chapters = []state.ChapterState{
+		{ID: "ch1", Title: "Chapter 1: Basics", OutlineGenerated: true, DraftGenerated: true, Sections: []state.SectionState{
+			{ID: "section1", Title: "Goroutines", DraftGenerated: true},
+			{ID: "section2", Title: "Channels", DraftGenerated: true},
+		}},
+	}
+	return bookState
+}
+
+// undraftedBook returns the state of an outlined book with chapters of
+// sections that have no drafts yet.
+func undraftedBook(chapters, sections int) *state.State {
+	bookState := state.NewState()
+	bookState.OutlineGenerated = true
+	for i := 0; i < chapters; i++ {
+		chapter := state.ChapterState{Title: fmt.Sprintf("Chapter %d", i+1), OutlineGenerated: true}
+		for j := 0; j < sections; j++ {
+			chapter.Sections = append(chapter.Sections, state.SectionState{Title: fmt.Sprintf("Section %d.%d", i+1, j+1)})
+		}
+		bookState.Chapters = append(bookState.Chapters, chapter)
+	}
+	bookState.AssignIDs()
+	return bookState
+}
+
+func TestGenerateDraftsConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	h := newTestHandler(t, func(prompt string) (string, error) {
+		mu.Lock()
+		calls[prompt]++
+		mu.Unlock()
+		return "Text of " + prompt, nil
+	})
+	h.Config = config.Defaults()
+	h.Config.Concurrency = 4
+	bookState := undraftedBook(3, 4)
+	bookPath := saveTestBook(t, h, "go", bookState, nil)
+
+	err := h.generateDrafts(bookPath, bookState)
+	if err != nil {
+		t.Fatalf("generateDrafts returned error: %v", err)
+	}
+	for i := range bookState.Chapters {
+		chapter := &bookState.Chapters[i]
+		if !chapter.DraftGenerated {
+			t.Errorf("Expected chapter %s to be drafted", chapter.ID)
+		}
+		for j := range chapter.Sections {
+			section := &chapter.Sections[j]
+			prompt := "section: " + section.Title
+			if calls[prompt] != 1 {
+				t.Errorf("Expected one call for %s, got %d", section.Title, calls[prompt])
+			}
+			content, err := os.ReadFile(filepath.Join(sectionPath(bookPath, chapter, section), "draft.md"))
+			if err != nil || string(content) != "Text of "+prompt || !section.DraftGenerated || section.DraftProvenance == nil {
+				t.Errorf("Expected %s to be saved once with provenance, got %q, %v", section.Title, content, err)
+			}
+		}
+	}
+	saved, err := h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Chapters[2].Sections[3].DraftGenerated {
+		t.Errorf("Expected the saved state to record every draft")
+	}
+}
+
+func TestGenerateDraftsStopsAfterError(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	h := newTestHandler(t, func(prompt string) (string, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if prompt == "section: Section 1.1" {
+			return "", fmt.Errorf("model unavailable")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return "Text", nil
+	})
+	h.Config = config.Defaults()
+	h.Config.Concurrency = 2
+	bookState := undraftedBook(2, 6)
+	bookPath := saveTestBook(t, h, "go", bookState, nil)
+
+	err := h.generateDrafts(bookPath, bookState)
+	if err == nil {
+		t.Fatalf("Expected the failed draft to fail the stage")
+	}
+	if calls >= 12 {
+		t.Errorf("Expected no new drafts to start after the error, got %d calls", calls)
+	}
+	if bookState.Chapters[0].Sections[0].DraftGenerated || bookState.Chapters[0].DraftGenerated {
+		t.Errorf("Expected the failed section and its chapter to stay undrafted")
+	}
+}
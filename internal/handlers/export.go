@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"go-book-ai/internal/bookindex"
+	"go-book-ai/internal/export"
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// exportDirName is the directory inside a book that exports are written to
+// unless an output path is given.
+const exportDirName = "export"
+
+// coverNames are the cover images picked up from the book directory when no
+// cover is given.
+var coverNames = []string{"cover.jpg", "cover.jpeg", "cover.png"}
+
+// Export writes the book in the given format to output, or to the export
+// directory of the book when output is empty, and returns the path written.
+// Formats made of several files, like html, latex, mdbook, hugo and docx
+// split by chapter, are written to a directory. Files whose content has not
+// changed since the last export, apart from the export date, are left
+// untouched, and files the last export wrote that are no longer produced are
+// removed.
+func (h *BookCommandHandler) Export(topic, format, output string, opts export.Options) (string, error) {
+	exporter, ok := export.Lookup(format)
+	if !ok {
+		return "", fmt.Errorf("unknown export format %q, expected one of %v", format, export.Formats())
+	}
+
+	bookPath, bookState, err := h.loadBook(topic)
+	if err != nil {
+		return "", err
+	}
+
+	book, err := export.Load(bookState, func(ch *state.ChapterState, sec *state.SectionState) string {
+		return sectionPath(bookPath, ch, sec)
+	}, func(m *state.MatterState) string {
+		return matterPath(bookPath, m)
+	})
+	if err != nil {
+		return "", err
+	}
+	if book.Title == "" {
+		book.Title = filepath.Base(bookPath)
+	}
+	terms, err := glossary.Load(glossaryPath(bookPath))
+	if err != nil {
+		return "", err
+	}
+	book.AddGlossary(terms.Terms)
+	index, err := bookindex.Load(indexPath(bookPath))
+	if err != nil {
+		return "", err
+	}
+	book.AddIndex(index.Terms)
+
+	if opts.Cover == "" {
+		for _, name := range coverNames {
+			if _, err := os.Stat(filepath.Join(bookPath, name)); err == nil {
+				opts.Cover = filepath.Join(bookPath, name)
+				break
+			}
+		}
+	}
+
+	out, err := exporter.Export(book, opts)
+	if err != nil {
+		return "", err
+	}
+	// The book rendered without a date tells which files changed apart from
+	// the export date.
+	date := book.Date
+	book.Date = time.Time{}
+	undatedOut, err := exporter.Export(book, opts)
+	book.Date = date
+	if err != nil {
+		return "", err
+	}
+
+	// Single files go to output, with the files they refer to beside them;
+	// other formats go to output as a directory.
+	files := map[string][]byte{}
+	for name, data := range out.Files {
+		files[name] = data
+	}
+	undated := map[string][]byte{}
+	for name, data := range undatedOut.Files {
+		undated[name] = data
+	}
+	dir := output
+	if out.File != nil {
+		if output == "" {
+			output = filepath.Join(bookPath, exportDirName, filepath.Base(bookPath)+"."+format)
+		}
+		dir = filepath.Dir(output)
+		files[filepath.Base(output)] = out.File
+		undated[filepath.Base(output)] = undatedOut.File
+	} else if output == "" {
+		output = filepath.Join(bookPath, exportDirName, format)
+		dir = output
+	}
+	manifest := manifestName
+	if out.File != nil {
+		manifest = "." + filepath.Base(output) + manifestName
+	}
+	written, err := writeExportFiles(dir, manifest, files, undated)
+	if err != nil {
+		return "", err
+	}
+	h.Logger.Info(fmt.Sprintf("Exported %s to %s (%d of %d files changed)", bookState.Title, output, written, len(files)))
+	return output, nil
+}
+
+// manifestName is the name of the file, inside the directory of an export,
+// listing the files the export wrote. Single-file exports prefix it with the
+// name of the file.
+const manifestName = ".manifest"
+
+// exportManifest records what an export wrote.
+type exportManifest struct {
+	Files  []string              `yaml:"files"`
+	Hashes map[string]exportHash `yaml:"hashes,omitempty"`
+}
+
+// exportHash holds the hashes of a file an export wrote: of the content
+// written, and of the content rendered with the export date left out.
+type exportHash struct {
+	Content string `yaml:"content"`
+	Undated string `yaml:"undated"`
+}
+
+// writeExportFiles writes files by their slash-separated path inside dir and
+// returns the number of files written. undated holds the same files rendered
+// without the export date. Files that already have the same content, or that
+// are as the last export wrote them and differ from it only in the date, are
+// skipped. The files are recorded in the manifest, and files the previous
+// manifest lists that are no longer produced are removed unless another
+// export in dir lists them.
+func writeExportFiles(dir, manifest string, files, undated map[string][]byte) (int, error) {
+	manifestPath := filepath.Join(dir, manifest)
+	var previous exportManifest
+	err := utils.LoadYAML(manifestPath, &previous)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read export manifest: %w", err)
+	}
+
+	current := exportManifest{Hashes: map[string]exportHash{}}
+	written := 0
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		hash := exportHash{Content: state.Hash(string(data)), Undated: state.Hash(string(undated[name]))}
+		if existing, err := os.ReadFile(path); err == nil {
+			if bytes.Equal(existing, data) {
+				current.Hashes[name] = hash
+				continue
+			}
+			last, ok := previous.Hashes[name]
+			if ok && last.Undated == hash.Undated && last.Content == state.Hash(string(existing)) {
+				current.Hashes[name] = last
+				continue
+			}
+		}
+		current.Hashes[name] = hash
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return written, fmt.Errorf("failed to create export directory: %w", err)
+		}
+		err = os.WriteFile(path, data, 0644)
+		if err != nil {
+			return written, fmt.Errorf("failed to write export: %w", err)
+		}
+		written++
+	}
+
+	kept, err := otherExportFiles(dir, manifest)
+	if err != nil {
+		return written, err
+	}
+	for _, name := range previous.Files {
+		if _, ok := files[name]; ok || kept[name] {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return written, fmt.Errorf("failed to remove stale export file: %w", err)
+		}
+		// Remove the directories the file leaves empty.
+		for parent := filepath.Dir(path); parent != filepath.Clean(dir); parent = filepath.Dir(parent) {
+			if os.Remove(parent) != nil {
+				break
+			}
+		}
+	}
+
+	for name := range files {
+		current.Files = append(current.Files, name)
+	}
+	sort.Strings(current.Files)
+	err = utils.SaveYAML(manifestPath, current)
+	if err != nil {
+		return written, fmt.Errorf("failed to write export manifest: %w", err)
+	}
+	return written, nil
+}
+
+// otherExportFiles returns the files that the manifests in dir other than
+// manifest list.
+func otherExportFiles(dir, manifest string) (map[string]bool, error) {
+	kept := map[string]bool{}
+	others, err := filepath.Glob(filepath.Join(dir, ".*"+manifestName))
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if filepath.Base(other) == manifest {
+			continue
+		}
+		var m exportManifest
+		err := utils.LoadYAML(other, &m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export manifest: %w", err)
+		}
+		for _, name := range m.Files {
+			kept[name] = true
+		}
+	}
+	return kept, nil
+}
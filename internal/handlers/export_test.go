@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rendered returns files as an export on date renders them, with the
+// date in place of every {date}, and as rendered without a date.
+func rendered(date string, files map[string]string) (map[string][]byte, map[string][]byte) {
+	dated, undated := map[string][]byte{}, map[string][]byte{}
+	for name, content := range files {
+		dated[name] = []byte(strings.ReplaceAll(content, "{date}", date))
+		undated[name] = []byte(strings.ReplaceAll(content, "{date}", "0001-01-01"))
+	}
+	return dated, undated
+}
+
+func TestWriteExportFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	files, undated := rendered("2024-05-06", map[string]string{
+		"_index.md":          "date: {date}\n",
+		"ch1/section1.md":    "One",
+		"ch2/section1.md":    "Two",
+		"images/diagram.png": "png",
+		"news.md":            "Released on 2024-05-06.\n",
+		"edited.md":          "date: {date}\n",
+	})
+	written, err := writeExportFiles(dir, manifestName, files, undated)
+	if err != nil || written != 6 {
+		t.Fatalf("Expected 6 files written, got %d, %v", written, err)
+	}
+	// A single-file export in the same directory shares the image.
+	files, undated = rendered("2024-05-06", map[string]string{
+		"book.md":            "Book",
+		"images/diagram.png": "png",
+	})
+	_, err = writeExportFiles(dir, ".book.md"+manifestName, files, undated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "edited.md"), []byte("Edited by hand\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next day: the news names that day, which is a change even though
+	// it is also the export date, and the hand-edited file is written again.
+	files, undated = rendered("2024-05-07", map[string]string{
+		"_index.md":       "date: {date}\n",
+		"ch1/section1.md": "One, revised",
+		"news.md":         "Released on 2024-05-07.\n",
+		"edited.md":       "date: {date}\n",
+	})
+	written, err = writeExportFiles(dir, manifestName, files, undated)
+	if err != nil || written != 3 {
+		t.Fatalf("Expected the revised section, news and edited file written, got %d, %v", written, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "_index.md")); string(data) != "date: 2024-05-06\n" {
+		t.Errorf("Expected a change of date alone not to rewrite the file, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "news.md")); string(data) != "Released on 2024-05-07.\n" {
+		t.Errorf("Expected dates in the content to count as changes, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "edited.md")); string(data) != "date: 2024-05-07\n" {
+		t.Errorf("Expected the hand-edited file to be rewritten, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ch2")); !os.IsNotExist(err) {
+		t.Errorf("Expected the removed chapter and its directory to be deleted, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "images", "diagram.png")); err != nil {
+		t.Errorf("Expected the image another export uses to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/markdown"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"path/filepath"
+)
+
+// glossaryPath returns the glossary file of a book.
+func glossaryPath(bookPath string) string {
+	return filepath.Join(bookPath, glossary.FileName)
+}
+
+// Glossary proposes the terms the drafts of a book introduce to the model and
+// adds their definitions to glossary.yaml.
+func (h *BookCommandHandler) Glossary(topic string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return h.generateGlossary(bookPath, bookState)
+}
+
+// generateGlossary adds definitions of the terms the drafts introduce to the
+// glossary of the book. Terms already in the glossary or its ignore list are
+// not proposed again, so edits to glossary.yaml are kept, and candidates the
+// model leaves out of a reply that defines any term are added to the ignore
+// list. The provenance of the call that added definitions is kept in the
+// state.
+func (h *BookCommandHandler) generateGlossary(bookPath string, bookState *state.State) error {
+	if !h.settings().StageEnabled(config.StageGlossary) {
+		h.Logger.Info("Glossary stage is disabled, skipping glossary.")
+		return nil
+	}
+
+	sections, err := h.parsedDrafts(bookPath, bookState)
+	if err != nil {
+		return err
+	}
+	var drafts []*markdown.Node
+	for _, section := range sections {
+		drafts = append(drafts, section.Doc)
+	}
+	if len(drafts) == 0 {
+		h.Logger.Info("No drafts yet, skipping glossary.")
+		return nil
+	}
+
+	path := glossaryPath(bookPath)
+	g, err := glossary.Load(path)
+	if err != nil {
+		return h.handleError("failed to load glossary", err)
+	}
+	candidates := g.Candidates(drafts)
+	if len(candidates) == 0 {
+		h.Logger.Info("No new glossary terms found.")
+		return nil
+	}
+
+	h.Logger.Info(fmt.Sprintf("Defining %d candidate glossary terms", len(candidates)))
+	prompt, err := h.glossaryPrompt(bookState.Title, candidates)
+	if err != nil {
+		return h.handleError("failed to generate glossary prompt", err)
+	}
+	content, provenance, err := h.send(bookPath, transcript.StageGlossary, "glossary", prompt)
+	if err != nil {
+		if !h.ErrorHandler.HandleError(h.handleError("failed to generate glossary", err)) {
+			return fmt.Errorf("retry attempts exhausted")
+		}
+	}
+	terms, err := glossary.ParseDefinitions(content)
+	if err != nil {
+		return h.handleError("failed to generate glossary", err)
+	}
+
+	added := g.Add(terms...)
+	// An empty reply is more likely cut off than a rejection of every
+	// candidate, so candidates are only ignored when some term was defined.
+	if len(terms) > 0 {
+		for _, candidate := range candidates {
+			if !g.Has(candidate.Term) {
+				g.Ignore = append(g.Ignore, candidate.Term)
+			}
+		}
+	}
+	err = g.Save(path)
+	if err != nil {
+		return h.handleError("failed to save glossary", err)
+	}
+	if added > 0 {
+		template, _ := h.glossaryPrompt(templatePlaceholder, nil)
+		provenance.PromptVersion = state.Hash(template)
+		bookState.GlossaryProvenance = provenance
+		err = h.StateStore.Save(bookPath, bookState)
+		if err != nil {
+			return h.handleError("failed to save state", err)
+		}
+	}
+	h.Logger.Info(fmt.Sprintf("Added %d terms to %s", added, path))
+	return nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"go-book-ai/internal/glossary"
+	"reflect"
+	"testing"
+)
+
+func TestGlossaryIgnoresOnlyAfterAnswer(t *testing.T) {
+	reply := ""
+	h := newTestHandler(t, func(prompt string) (string, error) { return reply, nil })
+	bookPath := saveTestBook(t, h, "go", twoSectionBook(), map[string]string{
+		"ch1/section1": "A **goroutine** is cheap.\n",
+		"ch1/section2": "A **channel** connects a *worker pool*.\n",
+	})
+
+	// A reply that defines nothing is taken as cut off, not as a rejection.
+	err := h.Glossary("go")
+	if err != nil {
+		t.Fatalf("Glossary returned error: %v", err)
+	}
+	g, err := glossary.Load(glossaryPath(bookPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Terms) != 0 || len(g.Ignore) != 0 {
+		t.Fatalf("Expected an empty reply to change nothing, got %+v", g)
+	}
+
+	reply = "- term: goroutine\n  definition: A lightweight thread.\n- term: channel\n  definition: A typed conduit.\n"
+	err = h.Glossary("go")
+	if err != nil {
+		t.Fatalf("Glossary returned error: %v", err)
+	}
+	g, err = glossary.Load(glossaryPath(bookPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Terms) != 2 || !reflect.DeepEqual(g.Ignore, []string{"worker pool"}) {
+		t.Errorf("Expected two terms and the left-out candidate ignored, got %+v", g)
+	}
+
+	report, err := h.Provenance("go", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := report.Children[len(report.Children)-1]
+	if last.Item != "glossary" || last.Provenance == nil || last.Provenance.Provider != "fake" || last.Provenance.PromptVersion == "" {
+		t.Errorf("Expected the glossary to record how it was generated, got %+v", last)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/outline"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/utils"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ImportManuscript splits an existing Markdown manuscript into the book
+// layout, one draft per section, and reconstructs the outline and state from
+// its headings. Sections that only have a heading are left pending so they can
+// be drafted later. It returns the topic the book was stored under.
+func (h *BookCommandHandler) ImportManuscript(path, topic string) (string, error) {
+	manuscript, err := outline.ReadManuscript(path)
+	if err != nil {
+		return "", h.handleError("failed to import manuscript", err)
+	}
+
+	if topic == "" {
+		topic = manuscript.Title
+	}
+	if topic == "" {
+		topic = filepath.Base(path)
+		topic = topic[:len(topic)-len(filepath.Ext(topic))]
+	}
+
+	folderName := utils.CleanName(topic)
+	bookPath := h.bookDir(folderName)
+	if h.StateStore.Exists(bookPath) {
+		return "", fmt.Errorf("book %s already exists", bookPath)
+	}
+
+	err = os.MkdirAll(bookPath, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("failed to create book directory: %v", err)
+	}
+
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	bookState := state.NewState()
+	bookState.Title = manuscript.Title
+	if bookState.Title == "" {
+		bookState.Title = topic
+	}
+	bookState.OutlineGenerated = true
+	bookState.OutlineProvenance = h.importedProvenance("")
+
+	drafted := 0
+	for _, chapter := range manuscript.Chapters {
+		chapterState := state.ChapterState{
+			ID:               bookState.NewChapterID(),
+			Title:            chapter.Title,
+			OutlineGenerated: len(chapter.Sections) > 0,
+			DraftGenerated:   len(chapter.Sections) > 0,
+		}
+		if chapterState.OutlineGenerated {
+			chapterState.OutlineProvenance = h.importedProvenance("")
+		}
+		for _, section := range chapter.Sections {
+			sectionState := state.SectionState{ID: chapterState.NewSectionID(), Title: section.Title}
+			for _, subsection := range section.Subsections {
+				sectionState.Subsections = append(sectionState.Subsections, state.SubsectionState{Title: subsection})
+			}
+
+			if section.Content == "" {
+				chapterState.DraftGenerated = false
+			} else {
+				sectionPath := sectionPath(bookPath, &chapterState, &sectionState)
+				err = os.MkdirAll(sectionPath, os.ModePerm)
+				if err != nil {
+					return "", h.handleError("failed to create section directory", err)
+				}
+				err = h.FileManager.SaveSectionContent(section.Content, filepath.Join(sectionPath, "draft.md"))
+				if err != nil {
+					return "", h.handleError("failed to save section content", err)
+				}
+				sectionState.DraftGenerated = true
+				sectionState.DraftProvenance = h.importedProvenance(section.Content)
+				drafted++
+			}
+
+			chapterState.Sections = append(chapterState.Sections, sectionState)
+		}
+
+		bookState.Chapters = append(bookState.Chapters, chapterState)
+	}
+
+	err = h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		return "", fmt.Errorf("failed to save state after import: %w", err)
+	}
+
+	h.Logger.Info(fmt.Sprintf("Imported %d chapters with %d drafted sections into %s", len(bookState.Chapters), drafted, bookPath))
+	return folderName, nil
+}
+
+// importedProvenance returns the provenance of content brought in from
+// outside rather than generated. content is hashed when it is written to a
+// file.
+func (h *BookCommandHandler) importedProvenance(content string) *state.Provenance {
+	now := time.Now().UTC()
+	provenance := &state.Provenance{Source: state.SourceImported, RunID: h.RunID, StartedAt: now, FinishedAt: now}
+	if content != "" {
+		provenance.ContentHash = state.Hash(content)
+	}
+	return provenance
+}
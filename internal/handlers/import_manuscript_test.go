@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportManuscript(t *testing.T) {
+	h := newTestHandler(t, nil)
+	path := filepath.Join(t.TempDir(), "go.md")
+	err := os.WriteFile(path, []byte("# Learning Go\n\n## Basics\n\n### Types\n\nInts and strings.\n\n```sh\n~~~\n# not a chapter\n```\n\n### Control Flow\n\n## Tooling\n\nModules and the go command.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topic, err := h.ImportManuscript(path, "")
+	if err != nil {
+		t.Fatalf("ImportManuscript returned error: %v", err)
+	}
+	bookPath := h.bookDir(topic)
+	bookState, err := h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bookState.Title != "Learning Go" || len(bookState.Chapters) != 2 {
+		t.Fatalf("Expected two chapters of Learning Go, got %q with %d chapters", bookState.Title, len(bookState.Chapters))
+	}
+	basics, tooling := &bookState.Chapters[0], &bookState.Chapters[1]
+	if basics.ID != "ch1" || tooling.ID != "ch2" || len(basics.Sections) != 2 || basics.Sections[0].ID != "section1" || basics.Sections[1].ID != "section2" {
+		t.Fatalf("Expected IDs from the state allocators, got %+v", bookState.Chapters)
+	}
+	if basics.DraftGenerated || !basics.Sections[0].DraftGenerated || basics.Sections[1].DraftGenerated {
+		t.Errorf("Expected only the section with text to be drafted, got %+v", basics.Sections)
+	}
+	if p := basics.Sections[0].DraftProvenance; p == nil || p.Source != state.SourceImported || p.ContentHash == "" {
+		t.Errorf("Expected imported provenance with a content hash, got %+v", p)
+	}
+
+	draft, err := os.ReadFile(filepath.Join(sectionPath(bookPath, basics, &basics.Sections[0]), "draft.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(draft), "# Types\n") || !strings.Contains(string(draft), "# not a chapter") {
+		t.Errorf("Expected the section draft with its code block, got %q", draft)
+	}
+	if _, err := os.Stat(sectionPath(bookPath, basics, &basics.Sections[1])); !os.IsNotExist(err) {
+		t.Errorf("Expected no directory for the pending section")
+	}
+	if len(tooling.Sections) != 1 || tooling.Sections[0].Title != "Introduction" || !tooling.DraftGenerated {
+		t.Errorf("Expected the chapter text as a drafted introduction, got %+v", tooling)
+	}
+}
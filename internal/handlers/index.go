@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/bookindex"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/markdown"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"os"
+	"path/filepath"
+)
+
+// indexPath returns the index file of a book.
+func indexPath(bookPath string) string {
+	return filepath.Join(bookPath, bookindex.FileName)
+}
+
+// parsedDrafts returns the parsed drafts of the book in reading order.
+// Sections without a draft are left out.
+func (h *BookCommandHandler) parsedDrafts(bookPath string, bookState *state.State) ([]bookindex.Draft, error) {
+	var drafts []bookindex.Draft
+	for i := range bookState.Chapters {
+		ch := &bookState.Chapters[i]
+		for j := range ch.Sections {
+			sec := &ch.Sections[j]
+			content, err := os.ReadFile(filepath.Join(sectionPath(bookPath, ch, sec), "draft.md"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, h.handleError("failed to read draft", err)
+			}
+			drafts = append(drafts, bookindex.Draft{Title: sec.Title, Doc: markdown.Parse(string(content))})
+		}
+	}
+	return drafts, nil
+}
+
+// Index proposes the key terms and proper nouns of the drafts of a book to
+// the model and adds the ones it keeps to index.yaml.
+func (h *BookCommandHandler) Index(topic string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return h.generateIndex(bookPath, bookState)
+}
+
+// generateIndex adds the terms of the drafts the model picks for the
+// back-of-book index to the index of the book. Terms already in the index or
+// its ignore list are not proposed again, so edits to index.yaml are kept,
+// and candidates the model leaves out of a reply that keeps any term are
+// added to the ignore list. The provenance of the call that added terms is
+// kept in the state.
+func (h *BookCommandHandler) generateIndex(bookPath string, bookState *state.State) error {
+	if !h.settings().StageEnabled(config.StageIndex) {
+		h.Logger.Info("Index stage is disabled, skipping index.")
+		return nil
+	}
+
+	drafts, err := h.parsedDrafts(bookPath, bookState)
+	if err != nil {
+		return err
+	}
+	if len(drafts) == 0 {
+		h.Logger.Info("No drafts yet, skipping index.")
+		return nil
+	}
+
+	path := indexPath(bookPath)
+	index, err := bookindex.Load(path)
+	if err != nil {
+		return h.handleError("failed to load index", err)
+	}
+	candidates := index.Candidates(drafts)
+	if len(candidates) == 0 {
+		h.Logger.Info("No new index terms found.")
+		return nil
+	}
+
+	h.Logger.Info(fmt.Sprintf("Choosing index terms from %d candidates", len(candidates)))
+	prompt, err := h.indexPrompt(bookState.Title, candidates)
+	if err != nil {
+		return h.handleError("failed to generate index prompt", err)
+	}
+	content, provenance, err := h.send(bookPath, transcript.StageIndex, "index", prompt)
+	if err != nil {
+		if !h.ErrorHandler.HandleError(h.handleError("failed to generate index", err)) {
+			return fmt.Errorf("retry attempts exhausted")
+		}
+	}
+	terms, err := bookindex.ParseTerms(content)
+	if err != nil {
+		return h.handleError("failed to generate index", err)
+	}
+
+	added := index.Add(terms...)
+	// An empty reply is more likely cut off than a rejection of every
+	// candidate, so candidates are only ignored when some term was kept.
+	if len(terms) > 0 {
+		for _, candidate := range candidates {
+			if !index.Has(candidate.Term) {
+				index.Ignore = append(index.Ignore, candidate.Term)
+			}
+		}
+	}
+	err = index.Save(path)
+	if err != nil {
+		return h.handleError("failed to save index", err)
+	}
+	if added > 0 {
+		template, _ := h.indexPrompt(templatePlaceholder, nil)
+		provenance.PromptVersion = state.Hash(template)
+		bookState.IndexProvenance = provenance
+		err = h.StateStore.Save(bookPath, bookState)
+		if err != nil {
+			return h.handleError("failed to save state", err)
+		}
+	}
+	h.Logger.Info(fmt.Sprintf("Added %d terms to %s", added, path))
+	return nil
+}
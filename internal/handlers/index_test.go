@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"go-book-ai/internal/bookindex"
+	"reflect"
+	"testing"
+)
+
+func TestIndexIgnoresOnlyAfterAnswer(t *testing.T) {
+	reply := "[]"
+	h := newTestHandler(t, func(prompt string) (string, error) { return reply, nil })
+	bookPath := saveTestBook(t, h, "go", twoSectionBook(), map[string]string{
+		"ch1/section1": "A **goroutine** is cheap.\n",
+		"ch1/section2": "Call `close` on a channel.\n",
+	})
+
+	// A reply that keeps nothing is taken as cut off, not as a rejection.
+	err := h.Index("go")
+	if err != nil {
+		t.Fatalf("Index returned error: %v", err)
+	}
+	index, err := bookindex.Load(indexPath(bookPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Terms) != 0 || len(index.Ignore) != 0 {
+		t.Fatalf("Expected an empty reply to change nothing, got %+v", index)
+	}
+
+	reply = "- goroutine\n"
+	err = h.Index("go")
+	if err != nil {
+		t.Fatalf("Index returned error: %v", err)
+	}
+	index, err = bookindex.Load(indexPath(bookPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index.Terms, []string{"goroutine"}) || !reflect.DeepEqual(index.Ignore, []string{"close"}) {
+		t.Errorf("Expected goroutine kept and close ignored, got %+v", index)
+	}
+
+	report, err := h.Provenance("go", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := report.Children[len(report.Children)-1]
+	if last.Item != "index" || last.Provenance == nil || last.Provenance.Provider != "fake" || last.Provenance.PromptVersion == "" {
+		t.Errorf("Expected the index to record how it was generated, got %+v", last)
+	}
+}
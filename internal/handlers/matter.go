@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"os"
+	"path/filepath"
+)
+
+// matterDirName is the directory inside a book holding its front and back
+// matter.
+const matterDirName = "matter"
+
+// matterTemplates are written for the items of front and back matter only the
+// author can write.
+var matterTemplates = map[string]string{
+	state.MatterAcknowledgements: `*Acknowledgements have not been written yet. Replace this text with thanks to the people
+who helped make this book: reviewers, editors, colleagues, friends and family.*
+`,
+	state.MatterAboutAuthor: `**[Author name]** is [a one-sentence description of the author's role and expertise].
+
+[A few sentences about the author's background and the experience that led to this book.]
+
+[Where readers can find the author online, such as a website or social media handles.]
+`,
+}
+
+// matterPath returns the file holding an item of front or back matter.
+func matterPath(bookPath string, item *state.MatterState) string {
+	return filepath.Join(bookPath, matterDirName, item.ID+".md")
+}
+
+// generateMatter writes the front and back matter of the book that is not
+// written yet: a preface, introduction, guide to reading the book and
+// conclusion from the model, and templates for the acknowledgements and the
+// about the author page.
+func (h *BookCommandHandler) generateMatter(bookPath string, bookState *state.State) error {
+	if !h.settings().StageEnabled(config.StageMatter) {
+		h.Logger.Info("Matter stage is disabled, skipping front and back matter.")
+		return nil
+	}
+	if bookState.Matter == nil {
+		bookState.Matter = state.DefaultMatter()
+	}
+
+	for i := range bookState.Matter {
+		item := &bookState.Matter[i]
+		if item.Generated {
+			continue
+		}
+		path := matterPath(bookPath, item)
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			return h.handleError("failed to create matter directory", err)
+		}
+
+		if text, ok := matterTemplates[item.ID]; ok {
+			h.Logger.Info(fmt.Sprintf("Writing template for: %s", item.Title))
+			err = h.FileManager.SaveSectionContent(text, path)
+			if err != nil {
+				return h.handleError("failed to save matter template", err)
+			}
+			item.Generated = true
+		} else {
+			h.Logger.Info(fmt.Sprintf("Generating: %s", item.Title))
+			prompt, err := h.matterPrompt(bookState, *item)
+			if err != nil {
+				return h.handleError("failed to generate matter prompt", err)
+			}
+			content, provenance, err := h.send(bookPath, transcript.StageMatter, item.ID, prompt)
+			if err != nil {
+				if !h.ErrorHandler.HandleError(h.handleError("failed to generate matter", err)) {
+					return fmt.Errorf("retry attempts exhausted")
+				}
+			}
+			err = h.FileManager.SaveSectionContent(content, path)
+			if err != nil {
+				return h.handleError("failed to save matter", err)
+			}
+			provenance.ContentHash = state.Hash(content)
+			item.Generated = true
+			item.Provenance = provenance
+		}
+
+		err = h.StateStore.Save(bookPath, bookState)
+		if err != nil {
+			return h.handleError("failed to save state", err)
+		}
+	}
+
+	h.Logger.Info(fmt.Sprintf("Front and back matter generated for book: %s", bookPath))
+	return nil
+}
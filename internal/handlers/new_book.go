@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/outline"
+	"os"
+)
+
+// NewBook starts a new book, refusing to touch a book that already exists.
+// When outlinePath is set, the outline is read from that file instead of being
+// generated, and processing proceeds straight to drafting.
+func (h *BookCommandHandler) NewBook(topic, outlinePath string) error {
+	bookPath := h.bookDir(topic)
+	if h.StateStore.Exists(bookPath) {
+		return fmt.Errorf("book %s already exists, use the book command to continue it", bookPath)
+	}
+
+	if outlinePath == "" {
+		return h.ProcessBook(topic)
+	}
+
+	bookOutline, err := outline.ReadOutlineFile(outlinePath)
+	if err != nil {
+		return h.handleError("failed to import outline", err)
+	}
+
+	err = os.MkdirAll(bookPath, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create book directory: %v", err)
+	}
+
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	bookState, err := h.StateStore.Open(bookPath)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %v", err)
+	}
+
+	applyOutline(bookState, bookOutline)
+	if bookState.Title == "" {
+		bookState.Title = topic
+	}
+
+	// Chapters that came with sections are already outlined. Chapters listed
+	// on their own still get a generated chapter outline.
+	for i, chapter := range bookOutline.Chapters {
+		bookState.Chapters[i].OutlineGenerated = len(chapter.Sections) > 0
+		if bookState.Chapters[i].OutlineGenerated {
+			bookState.Chapters[i].OutlineProvenance = h.importedProvenance("")
+		}
+	}
+	bookState.OutlineProvenance = h.importedProvenance("")
+	bookState.AssignIDs()
+
+	err = h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		return fmt.Errorf("failed to save state after outline import: %w", err)
+	}
+	h.Logger.Info(fmt.Sprintf("Imported outline with %d chapters from %s", len(bookState.Chapters), outlinePath))
+
+	return h.ProcessBook(topic)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutlineRef identifies a chapter, or a section when Section is not -1, by its
+// index in the book state.
+type OutlineRef struct {
+	Chapter int
+	Section int
+}
+
+// ResolveRef turns a user-supplied reference into indexes. References are
+// 1-based positions ("3" for a chapter, "3.2" for a section) or IDs ("ch3",
+// "ch3/section2").
+func ResolveRef(bookState *state.State, ref string) (OutlineRef, error) {
+	chapterPart, sectionPart := ref, ""
+	if i := strings.IndexAny(ref, "./"); i >= 0 {
+		chapterPart, sectionPart = ref[:i], ref[i+1:]
+	}
+
+	r := OutlineRef{Chapter: -1, Section: -1}
+	for i, chapter := range bookState.Chapters {
+		if chapter.ID == chapterPart {
+			r.Chapter = i
+		}
+	}
+	if n, err := strconv.Atoi(chapterPart); err == nil && r.Chapter == -1 {
+		if n < 1 || n > len(bookState.Chapters) {
+			return r, fmt.Errorf("chapter %d out of range, book has %d chapters", n, len(bookState.Chapters))
+		}
+		r.Chapter = n - 1
+	}
+	if r.Chapter == -1 {
+		return r, fmt.Errorf("no chapter matches %q", chapterPart)
+	}
+	if sectionPart == "" {
+		return r, nil
+	}
+
+	sections := bookState.Chapters[r.Chapter].Sections
+	for j, section := range sections {
+		if section.ID == sectionPart {
+			r.Section = j
+		}
+	}
+	if n, err := strconv.Atoi(sectionPart); err == nil && r.Section == -1 {
+		if n < 1 || n > len(sections) {
+			return r, fmt.Errorf("section %d out of range, chapter has %d sections", n, len(sections))
+		}
+		r.Section = n - 1
+	}
+	if r.Section == -1 {
+		return r, fmt.Errorf("no section matches %q", sectionPart)
+	}
+	return r, nil
+}
+
+// AddOutlineItem adds a chapter, or a section when chapterRef is set, at the
+// 1-based position (0 appends). New chapters get a generated chapter outline
+// and new sections a draft on the next run.
+func (h *BookCommandHandler) AddOutlineItem(topic, chapterRef, title string, position int) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if chapterRef == "" {
+		chapter := state.ChapterState{ID: bookState.NewChapterID(), Title: title}
+		bookState.Chapters = insertAt(bookState.Chapters, chapter, position)
+		bookState.OutlineProvenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+		h.Logger.Info(fmt.Sprintf("Added chapter %s: %s", chapter.ID, title))
+	} else {
+		ref, err := ResolveRef(bookState, chapterRef)
+		if err != nil {
+			return err
+		}
+		chapter := &bookState.Chapters[ref.Chapter]
+		section := state.SectionState{ID: chapter.NewSectionID(), Title: title}
+		chapter.Sections = insertAt(chapter.Sections, section, position)
+		chapter.OutlineGenerated = true
+		chapter.DraftGenerated = false
+		chapter.OutlineProvenance = chapter.OutlineProvenance.Edited(time.Now().UTC())
+		h.Logger.Info(fmt.Sprintf("Added section %s/%s: %s", chapter.ID, section.ID, title))
+	}
+
+	return h.saveEditedState(bookPath, bookState)
+}
+
+// RemoveOutlineItem removes a chapter or section from the outline. Its
+// directory is moved to the book's removed/ folder rather than deleted.
+func (h *BookCommandHandler) RemoveOutlineItem(topic, itemRef string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ref, err := ResolveRef(bookState, itemRef)
+	if err != nil {
+		return err
+	}
+
+	chapter := &bookState.Chapters[ref.Chapter]
+	var dir, title string
+	if ref.Section == -1 {
+		dir, title = chapterPath(bookPath, chapter), chapter.Title
+		bookState.Chapters = append(bookState.Chapters[:ref.Chapter], bookState.Chapters[ref.Chapter+1:]...)
+		bookState.OutlineProvenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+	} else {
+		section := &chapter.Sections[ref.Section]
+		dir, title = sectionPath(bookPath, chapter, section), section.Title
+		chapter.Sections = append(chapter.Sections[:ref.Section], chapter.Sections[ref.Section+1:]...)
+		chapter.OutlineProvenance = chapter.OutlineProvenance.Edited(time.Now().UTC())
+	}
+
+	removedPath := filepath.Join(bookPath, "removed", fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), strings.ReplaceAll(strings.TrimPrefix(dir, bookPath+string(filepath.Separator)), string(filepath.Separator), "-")))
+	undo, err := moveDir(dir, removedPath)
+	if err != nil {
+		return h.handleError("failed to move removed content", err)
+	}
+	err = h.saveEditedState(bookPath, bookState)
+	if err != nil {
+		undo()
+		return err
+	}
+
+	h.Logger.Info(fmt.Sprintf("Removed %q", title))
+	return nil
+}
+
+// MoveOutlineItem reorders a chapter to a new 1-based position, or moves a
+// section to a position in the same or another chapter. The destination is a
+// position for chapters and a chapter reference with optional position
+// ("2" or "2.1") for sections. Drafts follow their section.
+func (h *BookCommandHandler) MoveOutlineItem(topic, itemRef, destination string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ref, err := ResolveRef(bookState, itemRef)
+	if err != nil {
+		return err
+	}
+
+	if ref.Section == -1 {
+		position, err := strconv.Atoi(destination)
+		if err != nil || position < 1 || position > len(bookState.Chapters) {
+			return fmt.Errorf("invalid chapter position %q", destination)
+		}
+		chapter := bookState.Chapters[ref.Chapter]
+		bookState.Chapters = append(bookState.Chapters[:ref.Chapter], bookState.Chapters[ref.Chapter+1:]...)
+		bookState.Chapters = insertAt(bookState.Chapters, chapter, position)
+		bookState.OutlineProvenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+		h.Logger.Info(fmt.Sprintf("Moved chapter %q to position %d", chapter.Title, position))
+		return h.saveEditedState(bookPath, bookState)
+	}
+
+	chapterRef, position := destination, 0
+	if i := strings.Index(destination, "."); i >= 0 {
+		chapterRef = destination[:i]
+		position, err = strconv.Atoi(destination[i+1:])
+		if err != nil || position < 1 {
+			return fmt.Errorf("invalid section position %q", destination)
+		}
+	}
+	dest, err := ResolveRef(bookState, chapterRef)
+	if err != nil {
+		return err
+	}
+
+	from := &bookState.Chapters[ref.Chapter]
+	section := from.Sections[ref.Section]
+	from.Sections = append(from.Sections[:ref.Section], from.Sections[ref.Section+1:]...)
+
+	to := &bookState.Chapters[dest.Chapter]
+	undo := func() {}
+	if dest.Chapter != ref.Chapter {
+		oldPath := sectionPath(bookPath, from, &section)
+		section.ID = to.NewSectionID()
+		undo, err = moveDir(oldPath, sectionPath(bookPath, to, &section))
+		if err != nil {
+			return h.handleError("failed to move section content", err)
+		}
+		to.OutlineGenerated = true
+		to.DraftGenerated = to.DraftGenerated && section.DraftGenerated
+	}
+	to.Sections = insertAt(to.Sections, section, position)
+	from.OutlineProvenance = from.OutlineProvenance.Edited(time.Now().UTC())
+	to.OutlineProvenance = to.OutlineProvenance.Edited(time.Now().UTC())
+
+	err = h.saveEditedState(bookPath, bookState)
+	if err != nil {
+		undo()
+		return err
+	}
+	h.Logger.Info(fmt.Sprintf("Moved section %q to chapter %q", section.Title, to.Title))
+	return nil
+}
+
+// RenameOutlineItem changes the title of a chapter or section. Directories are
+// named by ID, so existing drafts stay where they are.
+func (h *BookCommandHandler) RenameOutlineItem(topic, itemRef, title string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ref, err := ResolveRef(bookState, itemRef)
+	if err != nil {
+		return err
+	}
+
+	chapter := &bookState.Chapters[ref.Chapter]
+	if ref.Section == -1 {
+		h.Logger.Info(fmt.Sprintf("Renamed chapter %q to %q", chapter.Title, title))
+		chapter.Title = title
+		bookState.OutlineProvenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+	} else {
+		h.Logger.Info(fmt.Sprintf("Renamed section %q to %q", chapter.Sections[ref.Section].Title, title))
+		chapter.Sections[ref.Section].Title = title
+		chapter.OutlineProvenance = chapter.OutlineProvenance.Edited(time.Now().UTC())
+	}
+
+	return h.saveEditedState(bookPath, bookState)
+}
+
+// OutlineTree loads a book and returns its state for display.
+func (h *BookCommandHandler) OutlineTree(topic string) (*state.State, error) {
+	_, bookState, err := h.loadBook(topic)
+	return bookState, err
+}
+
+// saveEditedState saves the state after an outline edit, keeping the on-disk
+// OUTLINE.yaml in step when the book has one.
+func (h *BookCommandHandler) saveEditedState(bookPath string, bookState *state.State) error {
+	if _, err := os.Stat(filepath.Join(bookPath, "OUTLINE.yaml")); err == nil {
+		err = outlineFromState(bookState).Save(bookPath)
+		if err != nil {
+			return h.handleError("failed to update outline file", err)
+		}
+	}
+	err := h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		return fmt.Errorf("failed to save state after outline edit: %w", err)
+	}
+	return nil
+}
+
+// moveDir renames src to dst if src exists and returns a function that moves
+// it back, so callers can undo the move when saving the state fails.
+func moveDir(src, dst string) (func(), error) {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return func() {}, nil
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return nil, fmt.Errorf("destination %s already exists", dst)
+	}
+	err := os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Rename(src, dst)
+	if err != nil {
+		return nil, err
+	}
+	return func() { os.Rename(dst, src) }, nil
+}
+
+// insertAt inserts item at the 1-based position, appending when position is 0
+// or past the end.
+func insertAt[T any](items []T, item T, position int) []T {
+	if position < 1 || position > len(items) {
+		return append(items, item)
+	}
+	items = append(items, item)
+	copy(items[position:], items[position-1:])
+	items[position-1] = item
+	return items
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sectionIDs returns the IDs of the sections of each chapter, keyed by
+// chapter ID.
+func sectionIDs(bookState *state.State) map[string][]string {
+	ids := map[string][]string{}
+	for _, chapter := range bookState.Chapters {
+		ids[chapter.ID] = []string{}
+		for _, section := range chapter.Sections {
+			ids[chapter.ID] = append(ids[chapter.ID], section.ID)
+		}
+	}
+	return ids
+}
+
+func TestOutlineEditsKeepIDs(t *testing.T) {
+	h := newTestHandler(t, nil)
+	bookState := twoSectionBook()
+	bookState.Chapters = append(bookState.Chapters, state.ChapterState{ID: "ch2", Title: "Chapter 2: Tooling", OutlineGenerated: true, Sections: []state.SectionState{
+		{ID: "section1", Title: "Modules"},
+	}})
+	bookPath := saveTestBook(t, h, "go", bookState, map[string]string{
+		"ch1/section1": "Goroutines are cheap.\n",
+		"ch1/section2": "Channels connect goroutines.\n",
+	})
+	load := func() *state.State {
+		t.Helper()
+		bookState, err := h.StateStore.Load(bookPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bookState
+	}
+	draft := func(id string) string {
+		content, _ := os.ReadFile(filepath.Join(bookPath, filepath.FromSlash(id), "draft.md"))
+		return string(content)
+	}
+
+	// A section added first gets a fresh ID; the others keep theirs.
+	err := h.AddOutlineItem("go", "ch1", "Memory Model", 1)
+	if err != nil {
+		t.Fatalf("AddOutlineItem returned error: %v", err)
+	}
+	ids := sectionIDs(load())
+	if got := ids["ch1"]; len(got) != 3 || got[0] != "section3" || got[1] != "section1" || got[2] != "section2" {
+		t.Fatalf("Expected the new section to get a fresh ID in front, got %v", got)
+	}
+
+	// Moving a section to another chapter gives it an ID free there and
+	// takes its draft along.
+	err = h.MoveOutlineItem("go", "ch1/section2", "ch2.1")
+	if err != nil {
+		t.Fatalf("MoveOutlineItem returned error: %v", err)
+	}
+	ids = sectionIDs(load())
+	if got := ids["ch1"]; len(got) != 2 || got[0] != "section3" || got[1] != "section1" {
+		t.Errorf("Expected the remaining sections to keep their IDs, got %v", got)
+	}
+	if got := ids["ch2"]; len(got) != 2 || got[0] != "section2" || got[1] != "section1" {
+		t.Fatalf("Expected the moved section first with a free ID, got %v", got)
+	}
+	if draft("ch2/section2") != "Channels connect goroutines.\n" || draft("ch1/section2") != "" {
+		t.Errorf("Expected the draft to move with its section")
+	}
+
+	// Reordering chapters keeps their IDs, so drafts stay where they are.
+	err = h.MoveOutlineItem("go", "ch2", "1")
+	if err != nil {
+		t.Fatalf("MoveOutlineItem returned error: %v", err)
+	}
+	moved := load()
+	if moved.Chapters[0].ID != "ch2" || moved.Chapters[1].ID != "ch1" {
+		t.Errorf("Expected the chapters to swap with their IDs, got %s and %s", moved.Chapters[0].ID, moved.Chapters[1].ID)
+	}
+
+	// Removing a section keeps the IDs of the rest and moves its draft aside.
+	err = h.RemoveOutlineItem("go", "2.2")
+	if err != nil {
+		t.Fatalf("RemoveOutlineItem returned error: %v", err)
+	}
+	ids = sectionIDs(load())
+	if got := ids["ch1"]; len(got) != 1 || got[0] != "section3" {
+		t.Errorf("Expected only section3 to remain in ch1, got %v", got)
+	}
+	if draft("ch1/section1") != "" {
+		t.Errorf("Expected the removed draft to be moved out of the chapter")
+	}
+	removed, err := filepath.Glob(filepath.Join(bookPath, "removed", "*-ch1-section1", "draft.md"))
+	if err != nil || len(removed) != 1 {
+		t.Errorf("Expected the removed draft to be kept under removed/, got %v", removed)
+	}
+
+	// A new chapter does not reuse a chapter ID.
+	err = h.AddOutlineItem("go", "", "Chapter 3: Testing", 0)
+	if err != nil {
+		t.Fatalf("AddOutlineItem returned error: %v", err)
+	}
+	added := load()
+	if last := added.Chapters[len(added.Chapters)-1]; last.ID != "ch3" || last.OutlineGenerated {
+		t.Errorf("Expected a pending chapter ch3, got %+v", last)
+	}
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/outline"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// matchThreshold is the title similarity above which a new outline item is
+// considered the same as an existing one.
+const matchThreshold = 0.6
+
+// OutlineChange describes what a merge does to one chapter or section.
+type OutlineChange struct {
+	Kind     string // "keep", "rename", "add" or "remove"
+	Chapter  string
+	Section  string
+	OldTitle string
+	ID       string
+	Drafted  bool
+}
+
+func (c OutlineChange) String() string {
+	item := fmt.Sprintf("chapter %q", c.Chapter)
+	if c.Section != "" {
+		item = fmt.Sprintf("section %q in chapter %q", c.Section, c.Chapter)
+	}
+	switch c.Kind {
+	case "add":
+		return fmt.Sprintf("+ %s (pending)", item)
+	case "remove":
+		if c.Drafted {
+			return fmt.Sprintf("- %s [%s] (draft moved to removed/)", item, c.ID)
+		}
+		return fmt.Sprintf("- %s [%s]", item, c.ID)
+	case "rename":
+		return fmt.Sprintf("~ %s [%s] renamed from %q", item, c.ID, c.OldTitle)
+	default:
+		return fmt.Sprintf("  %s [%s]", item, c.ID)
+	}
+}
+
+// RegenerateOutline proposes a new book outline and merges it with the
+// existing one. The proposal comes from outlinePath when set, otherwise from a
+// previously saved OUTLINE.proposed.yaml, otherwise from the language model,
+// and is saved with its provenance so the preview and the apply step see the
+// same outline. An outline from a file counts as a hand edit of the current
+// one. Without apply only the changes are returned.
+func (h *BookCommandHandler) RegenerateOutline(topic, outlinePath string, apply bool) ([]OutlineChange, error) {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	proposedPath := filepath.Join(bookPath, "OUTLINE.proposed.yaml")
+
+	var proposed *outline.Outline
+	var provenance *state.Provenance
+	switch {
+	case outlinePath != "":
+		proposed, err = outline.ReadOutlineFile(outlinePath)
+		if err != nil {
+			return nil, h.handleError("failed to read proposed outline", err)
+		}
+		provenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+	case fileExists(proposedPath):
+		proposed, provenance, err = readProposedOutline(proposedPath)
+		if err != nil {
+			return nil, h.handleError("failed to read proposed outline", err)
+		}
+		if provenance == nil {
+			provenance = bookState.OutlineProvenance.Edited(time.Now().UTC())
+		}
+		h.Logger.Info(fmt.Sprintf("Using proposed outline from %s", proposedPath))
+	default:
+		proposed, _, provenance, err = h.requestBookOutline(topic, bookPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !apply {
+		data, err := yaml.Marshal(proposedOutline{Outline: *proposed, Provenance: provenance})
+		if err != nil {
+			return nil, h.handleError("failed to marshal proposed outline", err)
+		}
+		err = os.WriteFile(proposedPath, data, 0644)
+		if err != nil {
+			return nil, h.handleError("failed to save proposed outline", err)
+		}
+	}
+
+	merged, changes := mergeOutline(bookState, proposed)
+	if !apply {
+		return changes, nil
+	}
+	merged.OutlineProvenance = provenance
+
+	stamp := time.Now().Format("20060102-150405")
+	var undos []func()
+	for _, change := range changes {
+		if change.Kind != "remove" {
+			continue
+		}
+		dir := filepath.Join(bookPath, filepath.FromSlash(change.ID))
+		undo, err := moveDir(dir, filepath.Join(bookPath, "removed", stamp+"-"+strings.ReplaceAll(change.ID, "/", "-")))
+		if err != nil {
+			for _, u := range undos {
+				u()
+			}
+			return nil, h.handleError("failed to move removed content", err)
+		}
+		undos = append(undos, undo)
+	}
+
+	err = h.saveEditedState(bookPath, merged)
+	if err != nil {
+		for _, u := range undos {
+			u()
+		}
+		return nil, err
+	}
+	os.Remove(proposedPath)
+
+	h.Logger.Info(fmt.Sprintf("Merged regenerated outline into %s", bookPath))
+	return changes, nil
+}
+
+// proposedOutline is the content of OUTLINE.proposed.yaml: the proposed
+// outline and how it was produced, so applying a saved proposal records the
+// model call that made it.
+type proposedOutline struct {
+	outline.Outline `yaml:",inline"`
+	Provenance      *state.Provenance `yaml:"provenance,omitempty"`
+}
+
+// readProposedOutline reads a saved proposal. Proposals saved without
+// provenance return nil for it.
+func readProposedOutline(path string) (*outline.Outline, *state.Provenance, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var proposal proposedOutline
+	err = yaml.Unmarshal(data, &proposal)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(proposal.Chapters) == 0 {
+		return nil, nil, fmt.Errorf("outline %s contains no chapters", path)
+	}
+	return &proposal.Outline, proposal.Provenance, nil
+}
+
+// requestBookOutline asks the language model for a book outline and parses
+// it, returning the raw response and its provenance as well.
+func (h *BookCommandHandler) requestBookOutline(topic, bookPath string) (*outline.Outline, string, *state.Provenance, error) {
+	prompt, err := h.bookOutlinePrompt(topic)
+	if err != nil {
+		return nil, "", nil, h.handleError("failed to generate outline prompt", err)
+	}
+
+	outlineContent, provenance, err := h.send(bookPath, transcript.StageBookOutline, "", prompt)
+	if err != nil {
+		if !h.ErrorHandler.HandleError(h.handleError("failed to generate book outline", err)) {
+			return nil, "", nil, fmt.Errorf("retry attempts exhausted")
+		}
+	}
+
+	h.Logger.Debug(fmt.Sprintf("Generated outline content:\n%s", outlineContent))
+
+	var o outline.Outline
+	err = yaml.Unmarshal([]byte(outlineContent), &o)
+	if err != nil {
+		return nil, "", nil, h.handleError("failed to parse generated outline", err)
+	}
+	template, _ := h.bookOutlinePrompt(templatePlaceholder)
+	provenance.PromptVersion = state.Hash(template)
+	return &o, outlineContent, provenance, nil
+}
+
+// mergeOutline builds a new state from the proposed outline, reusing existing
+// chapters and sections matched by ID or by title similarity so their drafts
+// are kept. Unmatched new items are pending; unmatched old items are reported
+// as removed.
+func mergeOutline(old *state.State, proposed *outline.Outline) (*state.State, []OutlineChange) {
+	merged := *old
+	merged.Chapters = make([]state.ChapterState, len(proposed.Chapters))
+	if proposed.Title != "" {
+		merged.Title = proposed.Title
+	}
+	merged.OutlineGenerated = true
+	merged.PendingApproval = false
+
+	var changes []OutlineChange
+	chapterMatches := matchItems(len(old.Chapters), len(proposed.Chapters),
+		func(i int) (string, string) { return old.Chapters[i].ID, old.Chapters[i].Title },
+		func(j int) (string, string) { return proposed.Chapters[j].ID, proposed.Chapters[j].Title })
+
+	for j, proposedChapter := range proposed.Chapters {
+		i, ok := chapterMatches[j]
+		if !ok {
+			chapter := state.ChapterState{Title: proposedChapter.Title, Description: proposedChapter.Description}
+			for _, section := range proposedChapter.Sections {
+				chapter.Sections = append(chapter.Sections, state.SectionState{Title: section.Title, Description: section.Description})
+			}
+			merged.Chapters[j] = chapter
+			changes = append(changes, OutlineChange{Kind: "add", Chapter: chapter.Title})
+			continue
+		}
+
+		oldChapter := old.Chapters[i]
+		chapter := oldChapter
+		chapter.Title = proposedChapter.Title
+		if proposedChapter.Description != "" {
+			chapter.Description = proposedChapter.Description
+		}
+		changes = append(changes, itemChange(chapter.Title, "", oldChapter.Title, oldChapter.ID))
+
+		if len(proposedChapter.Sections) > 0 && len(oldChapter.Sections) > 0 {
+			chapter.Sections, changes = mergeSections(oldChapter, proposedChapter, changes)
+			chapter.DraftGenerated = true
+			for _, section := range chapter.Sections {
+				chapter.DraftGenerated = chapter.DraftGenerated && section.DraftGenerated
+			}
+		}
+		merged.Chapters[j] = chapter
+	}
+
+	matchedOld := map[int]bool{}
+	for _, i := range chapterMatches {
+		matchedOld[i] = true
+	}
+	for i, chapter := range old.Chapters {
+		if !matchedOld[i] {
+			changes = append(changes, OutlineChange{Kind: "remove", Chapter: chapter.Title, ID: chapter.ID, Drafted: chapterDrafted(chapter)})
+		}
+	}
+
+	// New chapters get IDs after the matched ones have kept theirs. Their
+	// chapter outlines are generated on the next run.
+	usedIDs := map[string]bool{}
+	for _, chapter := range old.Chapters {
+		usedIDs[chapter.ID] = true
+	}
+	for j := range merged.Chapters {
+		if merged.Chapters[j].ID == "" {
+			merged.Chapters[j].ID = state.NextID("ch", j+1, usedIDs)
+		}
+	}
+	merged.AssignIDs()
+
+	return &merged, changes
+}
+
+// mergeSections merges the sections of a proposed chapter into a matched
+// existing chapter.
+func mergeSections(oldChapter state.ChapterState, proposedChapter outline.Chapter, changes []OutlineChange) ([]state.SectionState, []OutlineChange) {
+	sectionMatches := matchItems(len(oldChapter.Sections), len(proposedChapter.Sections),
+		func(i int) (string, string) { return oldChapter.Sections[i].ID, oldChapter.Sections[i].Title },
+		func(j int) (string, string) { return proposedChapter.Sections[j].ID, proposedChapter.Sections[j].Title })
+
+	used := map[string]bool{}
+	for _, section := range oldChapter.Sections {
+		used[section.ID] = true
+	}
+
+	sections := make([]state.SectionState, len(proposedChapter.Sections))
+	for j, proposedSection := range proposedChapter.Sections {
+		i, ok := sectionMatches[j]
+		if !ok {
+			sections[j] = state.SectionState{
+				ID:          state.NextID("section", j+1, used),
+				Title:       proposedSection.Title,
+				Description: proposedSection.Description,
+			}
+			changes = append(changes, OutlineChange{Kind: "add", Chapter: proposedChapter.Title, Section: proposedSection.Title})
+			continue
+		}
+		section := oldChapter.Sections[i]
+		changes = append(changes, itemChange(proposedChapter.Title, proposedSection.Title, section.Title, oldChapter.ID+"/"+section.ID))
+		section.Title = proposedSection.Title
+		if proposedSection.Description != "" {
+			section.Description = proposedSection.Description
+		}
+		sections[j] = section
+	}
+
+	matchedOld := map[int]bool{}
+	for _, i := range sectionMatches {
+		matchedOld[i] = true
+	}
+	for i, section := range oldChapter.Sections {
+		if !matchedOld[i] {
+			changes = append(changes, OutlineChange{Kind: "remove", Chapter: oldChapter.Title, Section: section.Title, ID: oldChapter.ID + "/" + section.ID, Drafted: section.DraftGenerated})
+		}
+	}
+	return sections, changes
+}
+
+// itemChange reports a matched item as kept or renamed.
+func itemChange(chapter, section, oldTitle, id string) OutlineChange {
+	title := chapter
+	if section != "" {
+		title = section
+	}
+	if title == oldTitle {
+		return OutlineChange{Kind: "keep", Chapter: chapter, Section: section, ID: id}
+	}
+	return OutlineChange{Kind: "rename", Chapter: chapter, Section: section, OldTitle: oldTitle, ID: id}
+}
+
+// matchItems pairs proposed items with existing ones, first by ID and then
+// greedily by best title similarity above matchThreshold. The result maps a
+// proposed index to an existing index.
+func matchItems(oldCount, newCount int, oldItem, newItem func(int) (string, string)) map[int]int {
+	matches := map[int]int{}
+	taken := map[int]bool{}
+
+	for j := 0; j < newCount; j++ {
+		id, _ := newItem(j)
+		if id == "" {
+			continue
+		}
+		for i := 0; i < oldCount; i++ {
+			if oldID, _ := oldItem(i); oldID == id && !taken[i] {
+				matches[j] = i
+				taken[i] = true
+				break
+			}
+		}
+	}
+
+	type candidate struct {
+		i, j  int
+		score float64
+	}
+	var candidates []candidate
+	for j := 0; j < newCount; j++ {
+		if _, ok := matches[j]; ok {
+			continue
+		}
+		_, newTitle := newItem(j)
+		for i := 0; i < oldCount; i++ {
+			if taken[i] {
+				continue
+			}
+			_, oldTitle := oldItem(i)
+			if score := titleSimilarity(oldTitle, newTitle); score >= matchThreshold {
+				candidates = append(candidates, candidate{i, j, score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	for _, c := range candidates {
+		if _, ok := matches[c.j]; ok || taken[c.i] {
+			continue
+		}
+		matches[c.j] = c.i
+		taken[c.i] = true
+	}
+	return matches
+}
+
+var (
+	chapterPrefix = regexp.MustCompile(`^(chapter|part|section)\s+[0-9ivxlc]+\s*[:.\-]?\s*`)
+	nonWord       = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// titleSimilarity scores two titles between 0 and 1, ignoring case,
+// punctuation and "Chapter N:" style numbering. It averages word overlap with
+// the edit distance ratio so reworded, extended and retyped titles match.
+func titleSimilarity(a, b string) float64 {
+	a, b = normalizeTitle(a), normalizeTitle(b)
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+
+	wordsA, wordsB := map[string]bool{}, map[string]bool{}
+	for _, w := range strings.Fields(a) {
+		wordsA[w] = true
+	}
+	for _, w := range strings.Fields(b) {
+		wordsB[w] = true
+	}
+	common := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			common++
+		}
+	}
+	jaccard := float64(common) / float64(len(wordsA)+len(wordsB)-common)
+	shorter := len(wordsA)
+	if len(wordsB) < shorter {
+		shorter = len(wordsB)
+	}
+	containment := float64(common) / float64(shorter)
+
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	editRatio := 1 - float64(levenshtein(a, b))/float64(longest)
+
+	return ((jaccard+containment)/2 + editRatio) / 2
+}
+
+func normalizeTitle(title string) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	title = chapterPrefix.ReplaceAllString(title, "")
+	return strings.TrimSpace(nonWord.ReplaceAllString(title, " "))
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// chapterDrafted reports whether any section of the chapter has a draft.
+func chapterDrafted(chapter state.ChapterState) bool {
+	for _, section := range chapter.Sections {
+		if section.DraftGenerated {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
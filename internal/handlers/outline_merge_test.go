@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"go-book-ai/internal/outline"
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTitleSimilarity(t *testing.T) {
+	if s := titleSimilarity("Chapter 1: Getting Started", "Chapter 2: Getting started!"); s != 1 {
+		t.Errorf("Expected numbering and punctuation to be ignored, got %v", s)
+	}
+	if s := titleSimilarity("Concurrency Patterns in Go", "Go Concurrency Patterns"); s < matchThreshold {
+		t.Errorf("Expected reworded titles to match, got %v", s)
+	}
+	if s := titleSimilarity("Testing", "Deployment"); s >= matchThreshold {
+		t.Errorf("Expected unrelated titles not to match, got %v", s)
+	}
+}
+
+func TestMergeOutline(t *testing.T) {
+	old := &state.State{
+		OutlineGenerated: true,
+		Chapters: []state.ChapterState{
+			{ID: "ch1", Title: "Chapter 1: Introduction", OutlineGenerated: true, DraftGenerated: true, Sections: []state.SectionState{
+				{ID: "section1", Title: "Why Go", DraftGenerated: true},
+				{ID: "section2", Title: "Installing Go", DraftGenerated: true},
+			}},
+			{ID: "ch2", Title: "Chapter 2: Legacy Tooling", OutlineGenerated: true},
+		},
+	}
+	proposed := &outline.Outline{Chapters: []outline.Chapter{
+		{Title: "Chapter 1: Introduction to Go", Sections: []outline.Section{
+			{Title: "Installing Go"},
+			{Title: "Your First Program"},
+		}},
+		{Title: "Chapter 2: Concurrency"},
+	}}
+
+	merged, changes := mergeOutline(old, proposed)
+
+	if len(merged.Chapters) != 2 {
+		t.Fatalf("Expected 2 chapters, got %d", len(merged.Chapters))
+	}
+	intro := merged.Chapters[0]
+	if intro.ID != "ch1" || intro.Title != "Chapter 1: Introduction to Go" {
+		t.Errorf("Expected ch1 to be kept and renamed, got %+v", intro)
+	}
+	if intro.Sections[0].ID != "section2" || !intro.Sections[0].DraftGenerated {
+		t.Errorf("Expected the installing section to keep its draft, got %+v", intro.Sections[0])
+	}
+	if intro.Sections[1].ID != "section3" || intro.Sections[1].DraftGenerated {
+		t.Errorf("Expected a new pending section with a fresh ID, got %+v", intro.Sections[1])
+	}
+	if intro.DraftGenerated {
+		t.Errorf("Expected the chapter to need drafting again")
+	}
+	if merged.Chapters[1].ID != "ch3" || merged.Chapters[1].OutlineGenerated {
+		t.Errorf("Expected a new chapter with a fresh ID and pending outline, got %+v", merged.Chapters[1])
+	}
+
+	kinds := map[string]int{}
+	for _, change := range changes {
+		kinds[change.Kind]++
+	}
+	if kinds["remove"] != 2 || kinds["add"] != 2 || kinds["rename"] != 1 || kinds["keep"] != 1 {
+		t.Errorf("Unexpected changes %v", changes)
+	}
+}
+
+func TestRegenerateOutlineKeepsProposalProvenance(t *testing.T) {
+	h := newTestHandler(t, func(prompt string) (string, error) {
+		return "title: Go\nchapters:\n- title: \"Chapter 1: Basics\"\n- title: \"Chapter 2: Tooling\"\n", nil
+	})
+	bookState := generatedBook()
+	bookState.OutlineProvenance.Model = "old-model"
+	bookPath := saveTestBook(t, h, "go", bookState, nil)
+
+	_, err := h.RegenerateOutline("go", "", false)
+	if err != nil {
+		t.Fatalf("RegenerateOutline returned error: %v", err)
+	}
+	_, err = h.RegenerateOutline("go", "", true)
+	if err != nil {
+		t.Fatalf("RegenerateOutline returned error: %v", err)
+	}
+	applied, err := h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := applied.OutlineProvenance
+	if p.Provider != "fake" || p.Model == "old-model" || p.PromptVersion == "" {
+		t.Errorf("Expected the saved proposal's provenance to be applied, got %+v", p)
+	}
+
+	// An outline supplied by hand is an edit of the current one.
+	outlinePath := filepath.Join(t.TempDir(), "outline.yaml")
+	err = os.WriteFile(outlinePath, []byte("chapters:\n- title: \"Chapter 1: Basics\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.RegenerateOutline("go", outlinePath, true)
+	if err != nil {
+		t.Fatalf("RegenerateOutline returned error: %v", err)
+	}
+	applied, err = h.StateStore.Load(bookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited := applied.OutlineProvenance; edited.EditedAt.IsZero() || edited.Provider != "fake" {
+		t.Errorf("Expected the supplied outline to be recorded as an edit, got %+v", edited)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"go-book-ai/internal/bookindex"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/glossary"
+	"go-book-ai/internal/models"
+	"go-book-ai/internal/state"
+	"text/template"
+)
+
+// Data available to prompt template overrides of each stage.
+type (
+	bookOutlinePromptData struct {
+		Topic       string
+		MinChapters int
+		MaxChapters int
+	}
+	chapterOutlinePromptData struct {
+		Title       string
+		Description string
+	}
+	draftPromptData struct {
+		Title       string
+		Description string
+		Subsections []state.SubsectionState
+		Words       int
+	}
+	glossaryPromptData struct {
+		Book  string
+		Terms []glossary.Candidate
+	}
+	indexPromptData struct {
+		Book  string
+		Terms []bookindex.Candidate
+	}
+	matterPromptData struct {
+		Kind     string
+		Title    string
+		Book     string
+		Chapters []string
+		Words    int
+	}
+)
+
+// settings returns the effective configuration of the open book.
+func (h *BookCommandHandler) settings() *config.Config {
+	if h.Config == nil {
+		return config.Defaults()
+	}
+	return h.Config
+}
+
+// stageModel returns the model used for a generation stage: the configured
+// one, or the writing agent's default.
+func (h *BookCommandHandler) stageModel(stage string) string {
+	if h.Config == nil {
+		return h.WritingAgent.ModelName()
+	}
+	return h.Config.Stage(stage).Model
+}
+
+// stageOptions returns the call options of a generation stage.
+func (h *BookCommandHandler) stageOptions(stage string) models.Options {
+	if h.Config == nil {
+		return models.Options{}
+	}
+	settings := h.Config.Stage(stage)
+	return models.Options{Model: settings.Model, Temperature: settings.Temperature}
+}
+
+// modelInput returns the hash of the model parameters of a stage, for
+// staleness tracking.
+func (h *BookCommandHandler) modelInput(stage string) string {
+	options := h.stageOptions(stage)
+	if options.Temperature == nil {
+		return state.Hash(h.stageModel(stage))
+	}
+	return state.Hash(h.stageModel(stage), fmt.Sprint(*options.Temperature))
+}
+
+// bookOutlinePrompt returns the prompt for the book outline, from the
+// configured template or the writing agent.
+func (h *BookCommandHandler) bookOutlinePrompt(topic string) (string, error) {
+	chapters := h.settings().Chapters
+	if text, ok := h.settings().Prompts[config.StageBookOutline]; ok {
+		return renderPrompt(config.StageBookOutline, text, bookOutlinePromptData{Topic: topic, MinChapters: chapters.Min, MaxChapters: chapters.Max})
+	}
+
+	prompt, err := h.WritingAgent.GenerateOutline(topic)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case chapters.Min > 0 && chapters.Max > 0:
+		prompt += fmt.Sprintf("\n\nThe outline should have between %d and %d chapters.", chapters.Min, chapters.Max)
+	case chapters.Min > 0:
+		prompt += fmt.Sprintf("\n\nThe outline should have at least %d chapters.", chapters.Min)
+	case chapters.Max > 0:
+		prompt += fmt.Sprintf("\n\nThe outline should have at most %d chapters.", chapters.Max)
+	}
+	return prompt, nil
+}
+
+// chapterOutlinePrompt returns the prompt for a chapter outline, from the
+// configured template or the writing agent.
+func (h *BookCommandHandler) chapterOutlinePrompt(title, description string) (string, error) {
+	if text, ok := h.settings().Prompts[config.StageChapterOutline]; ok {
+		return renderPrompt(config.StageChapterOutline, text, chapterOutlinePromptData{Title: title, Description: description})
+	}
+	return h.WritingAgent.GenerateChapterOutline(title)
+}
+
+// draftPrompt returns the prompt for a section draft, from the configured
+// template or the writing agent.
+func (h *BookCommandHandler) draftPrompt(section state.SectionState) (string, error) {
+	words := h.settings().SectionWords
+	if text, ok := h.settings().Prompts[config.StageDraft]; ok {
+		return renderPrompt(config.StageDraft, text, draftPromptData{Title: section.Title, Description: section.Description, Subsections: section.Subsections, Words: words})
+	}
+
+	prompt, err := h.WritingAgent.GenerateSectionContent(sectionOutline(section))
+	if err != nil {
+		return "", err
+	}
+	if words > 0 {
+		prompt += fmt.Sprintf("\n\nAim for about %d words.", words)
+	}
+	return prompt, nil
+}
+
+// matterPrompt returns the prompt for an item of front or back matter, from
+// the configured template or the writing agent.
+func (h *BookCommandHandler) matterPrompt(bookState *state.State, item state.MatterState) (string, error) {
+	var chapters []string
+	for _, chapter := range bookState.Chapters {
+		chapters = append(chapters, chapter.Title)
+	}
+	words := h.settings().SectionWords
+	if text, ok := h.settings().Prompts[config.StageMatter]; ok {
+		return renderPrompt(config.StageMatter, text, matterPromptData{Kind: item.ID, Title: item.Title, Book: bookState.Title, Chapters: chapters, Words: words})
+	}
+
+	prompt, err := h.WritingAgent.GenerateMatter(item.ID, item.Title, bookState.Title, chapters)
+	if err != nil {
+		return "", err
+	}
+	if words > 0 {
+		prompt += fmt.Sprintf("\n\nAim for about %d words.", words)
+	}
+	return prompt, nil
+}
+
+// glossaryPrompt returns the prompt asking for definitions of candidate
+// glossary terms, from the configured template or the writing agent.
+func (h *BookCommandHandler) glossaryPrompt(bookTitle string, candidates []glossary.Candidate) (string, error) {
+	if text, ok := h.settings().Prompts[config.StageGlossary]; ok {
+		return renderPrompt(config.StageGlossary, text, glossaryPromptData{Book: bookTitle, Terms: candidates})
+	}
+	return h.WritingAgent.GenerateGlossary(bookTitle, candidates)
+}
+
+// indexPrompt returns the prompt asking which candidate terms belong in the
+// index, from the configured template or the writing agent.
+func (h *BookCommandHandler) indexPrompt(bookTitle string, candidates []bookindex.Candidate) (string, error) {
+	if text, ok := h.settings().Prompts[config.StageIndex]; ok {
+		return renderPrompt(config.StageIndex, text, indexPromptData{Book: bookTitle, Terms: candidates})
+	}
+	return h.WritingAgent.GenerateIndex(bookTitle, candidates)
+}
+
+func renderPrompt(stage, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(stage).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s prompt template: %w", stage, err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s prompt template: %w", stage, err)
+	}
+	return buf.String(), nil
+}
+
+// sectionPlaceholder is a section whose prompt stands for the prompt template.
+var sectionPlaceholder = state.SectionState{Title: templatePlaceholder}
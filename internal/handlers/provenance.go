@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProvenanceReport describes how an artifact of a book was produced.
+type ProvenanceReport struct {
+	Item       string
+	Title      string
+	Path       string
+	Provenance *state.Provenance
+	// Modified is set when the file no longer matches what was generated or
+	// imported, as after a hand edit, or when an outline was edited by hand.
+	Modified bool
+	// Children summarizes the artifacts one level down: the chapters, front
+	// and back matter, glossary and index of the book, or the sections of a
+	// chapter.
+	Children []ProvenanceReport
+}
+
+// Provenance reports how the book outline was produced, or the outline of a
+// chapter when chapterRef is set, or the draft of a section when sectionRef is
+// set too. References take the same forms as outline edits.
+func (h *BookCommandHandler) Provenance(topic, chapterRef, sectionRef string) (*ProvenanceReport, error) {
+	bookPath, bookState, err := h.loadBook(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if chapterRef == "" {
+		if sectionRef != "" {
+			return nil, fmt.Errorf("a section needs a chapter")
+		}
+		report := &ProvenanceReport{Item: "book outline", Title: bookState.Title, Provenance: bookState.OutlineProvenance, Modified: outlineEdited(bookState.OutlineProvenance)}
+		for i := range bookState.Matter {
+			if !bookState.Matter[i].Back() {
+				report.Children = append(report.Children, matterProvenance(bookPath, &bookState.Matter[i]))
+			}
+		}
+		for i := range bookState.Chapters {
+			report.Children = append(report.Children, chapterProvenance(&bookState.Chapters[i]))
+		}
+		for i := range bookState.Matter {
+			if bookState.Matter[i].Back() {
+				report.Children = append(report.Children, matterProvenance(bookPath, &bookState.Matter[i]))
+			}
+		}
+		if bookState.GlossaryProvenance != nil {
+			report.Children = append(report.Children, ProvenanceReport{Item: "glossary", Title: "Glossary", Path: glossaryPath(bookPath), Provenance: bookState.GlossaryProvenance})
+		}
+		if bookState.IndexProvenance != nil {
+			report.Children = append(report.Children, ProvenanceReport{Item: "index", Title: "Index", Path: indexPath(bookPath), Provenance: bookState.IndexProvenance})
+		}
+		return report, nil
+	}
+
+	ref := chapterRef
+	if sectionRef != "" {
+		ref = chapterRef + "/" + sectionRef
+	}
+	r, err := ResolveRef(bookState, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter := &bookState.Chapters[r.Chapter]
+	if r.Section == -1 {
+		report := chapterProvenance(chapter)
+		for j := range chapter.Sections {
+			report.Children = append(report.Children, draftProvenance(bookPath, chapter, &chapter.Sections[j]))
+		}
+		return &report, nil
+	}
+	report := draftProvenance(bookPath, chapter, &chapter.Sections[r.Section])
+	return &report, nil
+}
+
+// MatterProvenance reports how an item of front or back matter was produced.
+// The item is given by its kind, such as "preface".
+func (h *BookCommandHandler) MatterProvenance(topic, kind string) (*ProvenanceReport, error) {
+	bookPath, bookState, err := h.loadBook(topic)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bookState.Matter {
+		if bookState.Matter[i].ID == kind {
+			report := matterProvenance(bookPath, &bookState.Matter[i])
+			return &report, nil
+		}
+	}
+	return nil, fmt.Errorf("no front or back matter %q, expected one of %s", kind, strings.Join(state.MatterKinds, ", "))
+}
+
+func chapterProvenance(chapter *state.ChapterState) ProvenanceReport {
+	return ProvenanceReport{Item: "chapter outline " + chapter.ID, Title: chapter.Title, Provenance: chapter.OutlineProvenance, Modified: outlineEdited(chapter.OutlineProvenance)}
+}
+
+// outlineEdited reports whether an outline was changed by hand after it was
+// generated or imported. Outlines live in the state, so edits are recorded
+// as they happen rather than detected from a content hash.
+func outlineEdited(provenance *state.Provenance) bool {
+	return provenance != nil && !provenance.EditedAt.IsZero()
+}
+
+func draftProvenance(bookPath string, chapter *state.ChapterState, section *state.SectionState) ProvenanceReport {
+	report := ProvenanceReport{
+		Item:       "draft " + chapter.ID + "/" + section.ID,
+		Title:      section.Title,
+		Path:       filepath.Join(sectionPath(bookPath, chapter, section), "draft.md"),
+		Provenance: section.DraftProvenance,
+	}
+	report.Modified = fileModified(report.Path, report.Provenance)
+	return report
+}
+
+func matterProvenance(bookPath string, item *state.MatterState) ProvenanceReport {
+	report := ProvenanceReport{
+		Item:       "matter " + item.ID,
+		Title:      item.Title,
+		Path:       matterPath(bookPath, item),
+		Provenance: item.Provenance,
+	}
+	report.Modified = fileModified(report.Path, report.Provenance)
+	return report
+}
+
+// fileModified reports whether the file at path no longer matches the content
+// hash recorded when it was written.
+func fileModified(path string, provenance *state.Provenance) bool {
+	if provenance == nil || provenance.ContentHash == "" {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	return err != nil || state.Hash(string(content)) != provenance.ContentHash
+}
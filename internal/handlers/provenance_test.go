@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generatedBook returns twoSectionBook with generated book and chapter
+// outlines.
+func generatedBook() *state.State {
+	bookState := twoSectionBook()
+	generated := &state.Provenance{Source: state.SourceGenerated, Model: "fake", FinishedAt: time.Now().UTC()}
+	bookState.OutlineProvenance = generated
+	bookState.Chapters[0].OutlineProvenance = generated
+	return bookState
+}
+
+func TestOutlineCommandsRecordEdits(t *testing.T) {
+	h := newTestHandler(t, nil)
+	saveTestBook(t, h, "go", generatedBook(), nil)
+
+	err := h.RenameOutlineItem("go", "1.2", "Channels and Select")
+	if err != nil {
+		t.Fatalf("RenameOutlineItem returned error: %v", err)
+	}
+	book, err := h.Provenance("go", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Modified || !book.Children[0].Modified {
+		t.Errorf("Expected only the chapter outline to be modified, got book %v, chapter %v", book.Modified, book.Children[0].Modified)
+	}
+	if book.Children[0].Provenance.Source != state.SourceGenerated || book.Children[0].Provenance.Model != "fake" {
+		t.Errorf("Expected the edit to keep how the outline was generated, got %+v", book.Children[0].Provenance)
+	}
+
+	err = h.AddOutlineItem("go", "", "Chapter 2: Testing", 0)
+	if err != nil {
+		t.Fatalf("AddOutlineItem returned error: %v", err)
+	}
+	book, err = h.Provenance("go", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !book.Modified {
+		t.Errorf("Expected a new chapter to modify the book outline")
+	}
+	status, err := h.Status("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Outline != StatusModified || status.Chapters[0].Outline != StatusModified {
+		t.Errorf("Expected edited outlines to show as modified, got %q and %q", status.Outline, status.Chapters[0].Outline)
+	}
+}
+
+func TestApproveRecordsOnlyChangedOutlines(t *testing.T) {
+	h := newTestHandler(t, func(prompt string) (string, error) { return "", nil })
+	bookState := generatedBook()
+	bookState.PendingApproval = true
+	bookPath := saveTestBook(t, h, "go", bookState, nil)
+	err := h.writeOutlineForApproval(bookPath, bookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Approving the outline as written is not an edit.
+	err = h.approveOutlines(bookPath, bookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bookState.OutlineProvenance.EditedAt.IsZero() {
+		t.Errorf("Expected an unchanged outline to keep its provenance, got %+v", bookState.OutlineProvenance)
+	}
+
+	err = h.writeOutlineForApproval(bookPath, bookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outlinePath := filepath.Join(bookPath, "OUTLINE.yaml")
+	content, err := os.ReadFile(outlinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(outlinePath, []byte(strings.Replace(string(content), "Goroutines", "Goroutines and Threads", 1)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.approveOutlines(bookPath, bookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bookState.OutlineProvenance.EditedAt.IsZero() || bookState.OutlineProvenance.Source != state.SourceGenerated {
+		t.Errorf("Expected the edited outline to be recorded as edited, got %+v", bookState.OutlineProvenance)
+	}
+}
+
+func TestMatterProvenance(t *testing.T) {
+	h := newTestHandler(t, func(prompt string) (string, error) { return "Text of " + prompt, nil })
+	bookState := generatedBook()
+	bookPath := saveTestBook(t, h, "go", bookState, nil)
+	err := h.generateMatter(bookPath, bookState)
+	if err != nil {
+		t.Fatalf("generateMatter returned error: %v", err)
+	}
+
+	book, err := h.Provenance("go", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []string
+	for _, child := range book.Children {
+		items = append(items, child.Item)
+	}
+	if strings.Join(items, ", ") != "matter preface, matter acknowledgements, matter introduction, matter how_to_read, chapter outline ch1, matter conclusion, matter about_author" {
+		t.Errorf("Expected front matter, chapters and back matter in book order, got %v", items)
+	}
+
+	err = os.WriteFile(filepath.Join(bookPath, matterDirName, "preface.md"), []byte("A preface of my own.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preface, err := h.MatterProvenance("go", state.MatterPreface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preface.Provenance == nil || preface.Provenance.Provider != "fake" || !preface.Modified {
+		t.Errorf("Expected the generated preface to be reported as modified, got %+v", preface)
+	}
+	if _, err := h.MatterProvenance("go", "epilogue"); err == nil {
+		t.Errorf("Expected an unknown kind of matter to be an error")
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/state"
+	"strings"
+)
+
+// templatePlaceholder stands in for the variable parts of a prompt, so that
+// hashing the rendered prompt captures only the template.
+const templatePlaceholder = "\x00placeholder\x00"
+
+// StaleItem is a generated artifact whose recorded inputs no longer match.
+type StaleItem struct {
+	Kind    string // "chapter outline" or "draft"
+	Chapter int
+	Section int
+	Title   string
+	Reasons []string
+}
+
+func (s StaleItem) String() string {
+	position := fmt.Sprintf("%d", s.Chapter+1)
+	if s.Section >= 0 {
+		position = fmt.Sprintf("%d.%d", s.Chapter+1, s.Section+1)
+	}
+	return fmt.Sprintf("%s %s %q: %s changed", position, s.Kind, s.Title, strings.Join(s.Reasons, ", "))
+}
+
+// chapterOutlineInputs returns the current inputs of a chapter outline.
+func (h *BookCommandHandler) chapterOutlineInputs(chapter *state.ChapterState) state.Inputs {
+	template, _ := h.chapterOutlinePrompt(templatePlaceholder, templatePlaceholder)
+	return state.Inputs{
+		Outline: state.Hash(chapter.Title, chapter.Description),
+		Prompt:  state.Hash(template),
+		Model:   h.modelInput(config.StageChapterOutline),
+	}
+}
+
+// draftInputs returns the current inputs of a section draft. The inputs of
+// the chapter outline it came from are its upstream, so a stale chapter
+// outline makes its drafts stale too.
+func (h *BookCommandHandler) draftInputs(chapter *state.ChapterState, section *state.SectionState) state.Inputs {
+	template, _ := h.draftPrompt(sectionPlaceholder)
+	parts := []string{section.Title, section.Description}
+	for _, subsection := range section.Subsections {
+		parts = append(parts, subsection.Title, subsection.Description)
+	}
+	return state.Inputs{
+		Outline:  state.Hash(parts...),
+		Prompt:   state.Hash(template),
+		Model:    h.modelInput(config.StageDraft),
+		Upstream: h.chapterOutlineInputs(chapter).Digest(),
+	}
+}
+
+// findStale compares the recorded inputs of every generated artifact with the
+// current ones. Artifacts without recorded inputs are never stale.
+func (h *BookCommandHandler) findStale(bookState *state.State) []StaleItem {
+	var stale []StaleItem
+	for i := range bookState.Chapters {
+		chapter := &bookState.Chapters[i]
+		if chapter.OutlineGenerated && !chapter.OutlineInputs.IsZero() {
+			if reasons := chapter.OutlineInputs.Changed(h.chapterOutlineInputs(chapter)); len(reasons) > 0 {
+				stale = append(stale, StaleItem{Kind: "chapter outline", Chapter: i, Section: -1, Title: chapter.Title, Reasons: reasons})
+			}
+		}
+		for j := range chapter.Sections {
+			section := &chapter.Sections[j]
+			if !section.DraftGenerated || section.DraftInputs.IsZero() {
+				continue
+			}
+			if reasons := section.DraftInputs.Changed(h.draftInputs(chapter, section)); len(reasons) > 0 {
+				stale = append(stale, StaleItem{Kind: "draft", Chapter: i, Section: j, Title: section.Title, Reasons: reasons})
+			}
+		}
+	}
+	return stale
+}
+
+// StaleItems lists the artifacts of a book that are out of date.
+func (h *BookCommandHandler) StaleItems(topic string) ([]StaleItem, error) {
+	_, bookState, err := h.loadBook(topic)
+	if err != nil {
+		return nil, err
+	}
+	return h.findStale(bookState), nil
+}
+
+// RebuildStale marks stale artifacts for regeneration and processes the book,
+// so only the affected chapter outlines and drafts are generated again. A
+// stale chapter outline is regenerated along with all of its drafts.
+func (h *BookCommandHandler) RebuildStale(topic string) error {
+	bookPath, bookState, unlock, err := h.openBook(topic)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stale := h.findStale(bookState)
+	for _, item := range stale {
+		chapter := &bookState.Chapters[item.Chapter]
+		h.Logger.Info(fmt.Sprintf("Rebuilding %s", item))
+		if item.Section == -1 {
+			chapter.OutlineGenerated = false
+		} else {
+			chapter.Sections[item.Section].DraftGenerated = false
+		}
+		chapter.DraftGenerated = false
+	}
+	if len(stale) == 0 {
+		h.Logger.Info("Nothing is stale.")
+	}
+
+	err = h.StateStore.Save(bookPath, bookState)
+	if err != nil {
+		return fmt.Errorf("failed to save state before rebuild: %w", err)
+	}
+	return h.ProcessBook(topic)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"go-book-ai/internal/config"
+	"go-book-ai/internal/state"
+	"reflect"
+	"testing"
+)
+
+// staleTestBook returns a book whose chapter outline and first two drafts
+// record the inputs the handler currently computes, and whose third draft
+// was imported, so it records none.
+func staleTestBook(h *BookCommandHandler) *state.State {
+	bookState := state.NewState()
+	bookState.OutlineGenerated = true
+	bookState.Chapters = []state.ChapterState{{ID: "ch1", Title: "Basics", OutlineGenerated: true, DraftGenerated: true, Sections: []state.SectionState{
+		{ID: "section1", Title: "Variables", DraftGenerated: true},
+		{ID: "section2", Title: "Constants", DraftGenerated: true},
+		{ID: "section3", Title: "Imported", DraftGenerated: true},
+	}}}
+	chapter := &bookState.Chapters[0]
+	chapter.OutlineInputs = h.chapterOutlineInputs(chapter)
+	for j := 0; j < 2; j++ {
+		chapter.Sections[j].DraftInputs = h.draftInputs(chapter, &chapter.Sections[j])
+	}
+	return bookState
+}
+
+// staleSummary returns the stale items as "kind title: reasons" strings.
+func staleSummary(items []StaleItem) []string {
+	var summary []string
+	for _, item := range items {
+		summary = append(summary, item.String())
+	}
+	return summary
+}
+
+func TestFindStale(t *testing.T) {
+	temperature := 0.2
+	for _, tc := range []struct {
+		name   string
+		change func(h *BookCommandHandler, bookState *state.State)
+		want   []string
+	}{
+		{
+			name:   "nothing changed",
+			change: func(h *BookCommandHandler, bookState *state.State) {},
+		},
+		{
+			name: "draft prompt",
+			change: func(h *BookCommandHandler, bookState *state.State) {
+				h.Config.Prompts = map[string]string{config.StageDraft: "Write {{.Title}}."}
+			},
+			want: []string{
+				`1.1 draft "Variables": prompt template changed`,
+				`1.2 draft "Constants": prompt template changed`,
+			},
+		},
+		{
+			name: "draft model",
+			change: func(h *BookCommandHandler, bookState *state.State) {
+				h.Config.Stages = map[string]config.Stage{config.StageDraft: {Model: "gpt-4o"}}
+			},
+			want: []string{
+				`1.1 draft "Variables": model parameters changed`,
+				`1.2 draft "Constants": model parameters changed`,
+			},
+		},
+		{
+			name: "temperature",
+			change: func(h *BookCommandHandler, bookState *state.State) {
+				h.Config.Temperature = &temperature
+			},
+			want: []string{
+				`1 chapter outline "Basics": model parameters changed`,
+				`1.1 draft "Variables": model parameters, upstream changed`,
+				`1.2 draft "Constants": model parameters, upstream changed`,
+			},
+		},
+		{
+			name: "chapter outline",
+			change: func(h *BookCommandHandler, bookState *state.State) {
+				bookState.Chapters[0].Description = "Declaring and naming values."
+			},
+			want: []string{
+				`1 chapter outline "Basics": outline changed`,
+				`1.1 draft "Variables": upstream changed`,
+				`1.2 draft "Constants": upstream changed`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			h.Config = config.Defaults()
+			bookState := staleTestBook(h)
+			tc.change(h, bookState)
+
+			got := staleSummary(h.findStale(bookState))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected stale items %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
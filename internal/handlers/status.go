@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"os"
+	"strings"
+	"time"
+)
+
+// States of outlines and drafts in a BookStatus.
+const (
+	StatusPending          = "pending"
+	StatusGenerated        = "generated"
+	StatusImported         = "imported"
+	StatusAwaitingApproval = "awaiting approval"
+	StatusStale            = "stale"
+	StatusModified         = "modified"
+)
+
+// BookStatus is the progress of a book: the state of every outline and draft,
+// word counts, tokens spent and an estimate of the time left.
+type BookStatus struct {
+	Title    string          `json:"title"`
+	Path     string          `json:"path"`
+	Outline  string          `json:"outline"`
+	Chapters []ChapterStatus `json:"chapters"`
+	Sections int             `json:"sections"`
+	Drafted  int             `json:"drafted"`
+	Stale    int             `json:"stale"`
+	Words    int             `json:"words"`
+	Tokens   int             `json:"tokens"`
+	Calls    int             `json:"calls"`
+	Errors   int             `json:"errors"`
+	// AvgDraftSeconds is the mean latency of successful draft calls.
+	AvgDraftSeconds float64 `json:"avg_draft_seconds"`
+	// ETASeconds estimates the time left from the mean latency of each kind
+	// of call still to be made. It is only set when ETAKnown is.
+	ETASeconds float64 `json:"eta_seconds"`
+	ETAKnown   bool    `json:"eta_known"`
+}
+
+// ChapterStatus is the progress of one chapter.
+type ChapterStatus struct {
+	ID       string          `json:"id"`
+	Title    string          `json:"title"`
+	Outline  string          `json:"outline"`
+	Sections []SectionStatus `json:"sections"`
+	Drafted  int             `json:"drafted"`
+	Words    int             `json:"words"`
+	Tokens   int             `json:"tokens"`
+}
+
+// SectionStatus is the progress of one section.
+type SectionStatus struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Draft  string `json:"draft"`
+	Words  int    `json:"words"`
+	Tokens int    `json:"tokens"`
+}
+
+// Status reports the progress of a book.
+func (h *BookCommandHandler) Status(topic string) (*BookStatus, error) {
+	bookPath, bookState, err := h.loadBook(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := transcript.Read(bookPath, transcript.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	tokens := map[string]int{}
+	latency := map[string]time.Duration{}
+	calls := map[string]int{}
+	status := &BookStatus{Title: bookState.Title, Path: bookPath, Outline: outlineStatus(bookState.OutlineGenerated, bookState.PendingApproval, bookState.OutlineProvenance)}
+	for _, entry := range entries {
+		tokens[entry.ItemID] += entry.Usage.TotalTokens
+		status.Tokens += entry.Usage.TotalTokens
+		status.Calls++
+		if entry.Error != "" {
+			status.Errors++
+			continue
+		}
+		latency[entry.Stage] += time.Duration(entry.LatencyMS) * time.Millisecond
+		calls[entry.Stage]++
+	}
+
+	staleItems := map[[2]int]bool{}
+	for _, item := range h.findStale(bookState) {
+		staleItems[[2]int{item.Chapter, item.Section}] = true
+	}
+
+	remaining := map[string]int{}
+	if !bookState.OutlineGenerated {
+		remaining[transcript.StageBookOutline]++
+	}
+	for i := range bookState.Chapters {
+		chapter := &bookState.Chapters[i]
+		chapterStatus := ChapterStatus{
+			ID:      chapter.ID,
+			Title:   chapter.Title,
+			Outline: outlineStatus(chapter.OutlineGenerated, chapter.PendingApproval, chapter.OutlineProvenance),
+			Tokens:  tokens[chapter.ID],
+		}
+		if staleItems[[2]int{i, -1}] {
+			chapterStatus.Outline = StatusStale
+		}
+		if !chapter.OutlineGenerated {
+			remaining[transcript.StageChapterOutline]++
+		}
+
+		for j := range chapter.Sections {
+			section := &chapter.Sections[j]
+			sectionStatus := SectionStatus{ID: section.ID, Title: section.Title, Draft: StatusPending, Tokens: tokens[chapter.ID+"/"+section.ID]}
+			if section.DraftGenerated {
+				report := draftProvenance(bookPath, chapter, section)
+				sectionStatus.Draft = StatusGenerated
+				if report.Provenance != nil && report.Provenance.Source == state.SourceImported {
+					sectionStatus.Draft = StatusImported
+				}
+				if report.Modified {
+					sectionStatus.Draft = StatusModified
+				}
+				if staleItems[[2]int{i, j}] {
+					sectionStatus.Draft = StatusStale
+				}
+				sectionStatus.Words = countWords(report.Path)
+				chapterStatus.Drafted++
+			} else {
+				remaining[transcript.StageDraft]++
+			}
+			chapterStatus.Words += sectionStatus.Words
+			chapterStatus.Tokens += sectionStatus.Tokens
+			chapterStatus.Sections = append(chapterStatus.Sections, sectionStatus)
+		}
+
+		status.Sections += len(chapter.Sections)
+		status.Drafted += chapterStatus.Drafted
+		status.Words += chapterStatus.Words
+		status.Chapters = append(status.Chapters, chapterStatus)
+	}
+	status.Stale = len(staleItems)
+
+	if calls[transcript.StageDraft] > 0 {
+		status.AvgDraftSeconds = (latency[transcript.StageDraft] / time.Duration(calls[transcript.StageDraft])).Seconds()
+	}
+	status.ETAKnown = true
+	for stage, n := range remaining {
+		if calls[stage] == 0 {
+			status.ETAKnown = false
+			break
+		}
+		status.ETASeconds += float64(n) * (latency[stage] / time.Duration(calls[stage])).Seconds()
+	}
+	if !status.ETAKnown {
+		status.ETASeconds = 0
+	}
+	return status, nil
+}
+
+// outlineStatus describes the state of a book or chapter outline.
+func outlineStatus(generated, pendingApproval bool, provenance *state.Provenance) string {
+	switch {
+	case pendingApproval:
+		return StatusAwaitingApproval
+	case !generated:
+		return StatusPending
+	case outlineEdited(provenance):
+		return StatusModified
+	case provenance != nil && provenance.Source == state.SourceImported:
+		return StatusImported
+	default:
+		return StatusGenerated
+	}
+}
+
+// countWords returns the number of words in a file, or 0 if it cannot be read.
+func countWords(path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return len(strings.Fields(string(content)))
+}
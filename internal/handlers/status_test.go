@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"go-book-ai/internal/models"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	h := newTestHandler(t, nil)
+	bookState := twoSectionBook()
+	chapter := &bookState.Chapters[0]
+	chapter.DraftGenerated = false
+	chapter.Sections[1].DraftProvenance = &state.Provenance{Source: state.SourceGenerated, ContentHash: state.Hash("Channels connect goroutines.\n")}
+	chapter.Sections = append(chapter.Sections, state.SectionState{ID: "section3", Title: "Select"})
+	bookState.Chapters = append(bookState.Chapters, state.ChapterState{ID: "ch2", Title: "Chapter 2: Tooling"})
+	bookPath := saveTestBook(t, h, "go", bookState, map[string]string{
+		"ch1/section1": "Goroutines are cheap.\n",
+		"ch1/section2": "Channels connect goroutines, edited by hand.\n",
+	})
+	for _, entry := range []transcript.Entry{
+		{Stage: transcript.StageDraft, ItemID: "ch1/section1", LatencyMS: 2000, Usage: models.Usage{TotalTokens: 100}},
+		{Stage: transcript.StageDraft, ItemID: "ch1/section2", LatencyMS: 4000, Usage: models.Usage{TotalTokens: 50}},
+		{Stage: transcript.StageDraft, ItemID: "ch1/section2", LatencyMS: 30000, Error: "timeout"},
+	} {
+		err := transcript.Append(bookPath, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := h.Status("go")
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if status.Sections != 3 || status.Drafted != 2 || status.Words != 9 || status.Tokens != 150 || status.Calls != 3 || status.Errors != 1 {
+		t.Errorf("Unexpected totals: %+v", status)
+	}
+	sections := status.Chapters[0].Sections
+	if sections[0].Draft != StatusGenerated || sections[1].Draft != StatusModified || sections[2].Draft != StatusPending {
+		t.Errorf("Expected generated, modified and pending drafts, got %+v", sections)
+	}
+	if sections[0].Tokens != 100 || status.Chapters[0].Tokens != 150 {
+		t.Errorf("Expected tokens per section and chapter, got %d and %d", sections[0].Tokens, status.Chapters[0].Tokens)
+	}
+	if status.Chapters[1].Outline != StatusPending {
+		t.Errorf("Expected the second chapter outline to be pending, got %q", status.Chapters[1].Outline)
+	}
+	// Failed calls do not count towards the average latency.
+	if status.AvgDraftSeconds != 3 {
+		t.Errorf("Expected an average draft time of 3s, got %v", status.AvgDraftSeconds)
+	}
+	// No chapter outline has been timed yet, so the time left is unknown.
+	if status.ETAKnown || status.ETASeconds != 0 {
+		t.Errorf("Expected an unknown time left, got %v", status.ETASeconds)
+	}
+
+	err = transcript.Append(bookPath, transcript.Entry{Stage: transcript.StageChapterOutline, ItemID: "ch1", LatencyMS: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = h.Status("go")
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if !status.ETAKnown || status.ETASeconds != 4 {
+		t.Errorf("Expected 4s left for one draft and one chapter outline, got %v (known %v)", status.ETASeconds, status.ETAKnown)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-book-ai/internal/models"
+	"go-book-ai/internal/state"
+	"go-book-ai/internal/transcript"
+	"time"
+)
+
+// send sends a prompt to the writing agent and records the exchange in the
+// book's transcript. It is safe for concurrent use. A transcript that cannot
+// be written is logged but does not fail generation. The returned provenance
+// describes the call; callers add the prompt version and content hash of what
+// they produce from it.
+func (h *BookCommandHandler) send(bookPath, stage, itemID, prompt string) (string, *state.Provenance, error) {
+	start := time.Now()
+	response, call, err := h.WritingAgent.Exchange(prompt, h.stageOptions(stage))
+	finish := time.Now()
+
+	entry := transcript.Entry{
+		Time:      start,
+		RunID:     h.RunID,
+		Stage:     stage,
+		ItemID:    itemID,
+		Model:     h.stageModel(stage),
+		Messages:  []models.Message{{Role: "user", Content: prompt}},
+		Response:  response,
+		LatencyMS: finish.Sub(start).Milliseconds(),
+	}
+	if call != nil {
+		entry.Provider = call.Provider
+		if len(call.Messages) > 0 {
+			entry.Messages = call.Messages
+		}
+		entry.Parameters = call.Parameters
+		entry.RawResponse = call.RawResponse
+		entry.Usage = call.Usage
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if terr := transcript.Append(bookPath, entry); terr != nil {
+		h.Logger.Error(fmt.Sprintf("Failed to record transcript: %v", terr))
+	}
+
+	provenance := &state.Provenance{
+		Source:     state.SourceGenerated,
+		Provider:   entry.Provider,
+		Model:      entry.Model,
+		RunID:      h.RunID,
+		StartedAt:  start.UTC(),
+		FinishedAt: finish.UTC(),
+		Usage: state.TokenUsage{
+			PromptTokens:     entry.Usage.PromptTokens,
+			CompletionTokens: entry.Usage.CompletionTokens,
+			TotalTokens:      entry.Usage.TotalTokens,
+		},
+	}
+	if len(entry.Parameters) > 0 {
+		provenance.Parameters = map[string]string{}
+		for key, value := range entry.Parameters {
+			provenance.Parameters[key] = fmt.Sprint(value)
+		}
+	}
+	return response, provenance, err
+}
+
+// Transcript returns the recorded language model calls of a book.
+func (h *BookCommandHandler) Transcript(topic string, filter transcript.Filter) ([]transcript.Entry, error) {
+	bookPath, _, err := h.loadBook(topic)
+	if err != nil {
+		return nil, err
+	}
+	return transcript.Read(bookPath, filter)
+}
+
+// newRunID returns an identifier for one invocation of the handler, shared by
+// every artifact and transcript entry it produces.
+func newRunID() string {
+	random := make([]byte, 4)
+	rand.Read(random)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(random)
+}
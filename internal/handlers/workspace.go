@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"go-book-ai/internal/file"
+	"go-book-ai/internal/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// archiveDirName is the workspace directory archives are written to by
+// default. Book names never start with a dot, so no book can share it.
+const archiveDirName = ".archive"
+
+// BookSummary is one book of the workspace as listed by ListBooks.
+type BookSummary struct {
+	Name   string      `json:"name"`
+	Status *BookStatus `json:"status,omitempty"`
+	// Error is set instead of Status when the book could not be read.
+	Error string `json:"error,omitempty"`
+}
+
+// ListBooks returns the books of the workspace with their status, sorted by
+// name.
+func (h *BookCommandHandler) ListBooks() ([]BookSummary, error) {
+	entries, err := os.ReadDir(h.workspaceDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace: %w", err)
+	}
+
+	var books []BookSummary
+	for _, entry := range entries {
+		if !entry.IsDir() || !h.StateStore.Exists(h.bookDir(entry.Name())) {
+			continue
+		}
+		summary := BookSummary{Name: entry.Name()}
+		summary.Status, err = h.Status(entry.Name())
+		if err != nil {
+			summary.Error = err.Error()
+		}
+		books = append(books, summary)
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].Name < books[j].Name })
+	return books, nil
+}
+
+// RenameBook moves a book to the directory of a new topic. It returns the new
+// book name.
+func (h *BookCommandHandler) RenameBook(topic, newTopic string) (string, error) {
+	bookPath := h.bookDir(topic)
+	if !h.StateStore.Exists(bookPath) {
+		return "", fmt.Errorf("no book found at %s", bookPath)
+	}
+	newName := utils.CleanName(newTopic)
+	if newName == "" {
+		return "", fmt.Errorf("%q is not a valid book name", newTopic)
+	}
+	newPath := h.bookDir(newName)
+	if _, err := os.Stat(newPath); err == nil {
+		return "", fmt.Errorf("%s already exists", newPath)
+	}
+
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(bookPath, newPath)
+	if err != nil {
+		unlock()
+		return "", h.handleError("failed to rename book", err)
+	}
+	// The lock moved along with the book directory.
+	os.Remove(filepath.Join(newPath, file.LockFileName))
+
+	h.Logger.Info(fmt.Sprintf("Renamed %s to %s", bookPath, newPath))
+	return newName, nil
+}
+
+// ArchiveBook writes a book to a .tar.gz archive and returns its path. When
+// dest is empty the archive goes to the workspace's archive directory. With
+// remove the book is deleted once the archive is written.
+func (h *BookCommandHandler) ArchiveBook(topic, dest string, remove bool) (string, error) {
+	bookPath := h.bookDir(topic)
+	if !h.StateStore.Exists(bookPath) {
+		return "", fmt.Errorf("no book found at %s", bookPath)
+	}
+	if dest == "" {
+		name := fmt.Sprintf("%s-%s.tar.gz", filepath.Base(bookPath), time.Now().Format("20060102-150405"))
+		dest = filepath.Join(h.workspaceDir(), archiveDirName, name)
+	}
+
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	err = h.FileManager.ArchiveDir(bookPath, dest)
+	if err != nil {
+		return "", h.handleError("failed to archive book", err)
+	}
+	h.Logger.Info(fmt.Sprintf("Archived %s to %s", bookPath, dest))
+
+	if remove {
+		err = os.RemoveAll(bookPath)
+		if err != nil {
+			return dest, h.handleError("failed to remove archived book", err)
+		}
+		h.Logger.Info(fmt.Sprintf("Removed %s", bookPath))
+	}
+	return dest, nil
+}
+
+// RemoveBook deletes a book and everything in its directory.
+func (h *BookCommandHandler) RemoveBook(topic string) error {
+	bookPath := h.bookDir(topic)
+	if !h.StateStore.Exists(bookPath) {
+		return fmt.Errorf("no book found at %s", bookPath)
+	}
+
+	unlock, err := h.FileManager.LockBook(bookPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.RemoveAll(bookPath)
+	if err != nil {
+		return h.handleError("failed to remove book", err)
+	}
+	h.Logger.Info(fmt.Sprintf("Removed %s", bookPath))
+	return nil
+}
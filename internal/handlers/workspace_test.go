@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArchiveBookNamedArchive(t *testing.T) {
+	h := newTestHandler(t, nil)
+	bookPath := saveTestBook(t, h, "archive", twoSectionBook(), nil)
+
+	dest, err := h.ArchiveBook("archive", "", true)
+	if err != nil {
+		t.Fatalf("ArchiveBook returned error: %v", err)
+	}
+	if strings.HasPrefix(dest, bookPath+string(filepath.Separator)) {
+		t.Errorf("Expected the archive outside the book, got %s", dest)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("Expected the archive to survive removing the book, got %v", err)
+	}
+	books, err := h.ListBooks()
+	if err != nil || len(books) != 0 {
+		t.Errorf("Expected no books left, got %+v, %v", books, err)
+	}
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"log"
 	"os"
+	"strings"
 )
 
 type Logger interface {
@@ -11,17 +12,30 @@ type Logger interface {
 	Error(message string)
 }
 
+// level is the level of loggers created from now on, set from the
+// configuration with SetLevel.
+var level = "info"
+
+// SetLevel sets the level of loggers created from now on: "debug", "info" or
+// "error".
+func SetLevel(l string) {
+	level = strings.ToLower(l)
+}
+
 type simpleLogger struct {
 	debugEnabled bool
+	infoEnabled  bool
 }
 
 func NewSimpleLogger() Logger {
-	debugEnabled := os.Getenv("DEBUG") == "true"
-	return &simpleLogger{debugEnabled: debugEnabled}
+	debugEnabled := os.Getenv("DEBUG") == "true" || level == "debug"
+	return &simpleLogger{debugEnabled: debugEnabled, infoEnabled: level != "error"}
 }
 
 func (l *simpleLogger) Info(message string) {
-	log.Printf("INFO: %s", message)
+	if l.infoEnabled {
+		log.Printf("INFO: %s", message)
+	}
 }
 
 func (l *simpleLogger) Debug(message string) {
@@ -0,0 +1,496 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	listMarker  = regexp.MustCompile(`^( {0,3})([-*+]|[0-9]{1,9}[.)])([ \t]+|$)`)
+	footnoteDef = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:[ \t]*`)
+	tableDelim  = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	htmlStart   = regexp.MustCompile(`^ {0,3}<(/?[A-Za-z][A-Za-z0-9-]*[\s/>]|/?[A-Za-z][A-Za-z0-9-]*$|!--)`)
+	setextLine  = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+)
+
+// Parse parses Markdown into a Document node.
+func Parse(src string) *Node {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	doc := &Node{Kind: Document}
+	doc.Children = parseBlocks(strings.Split(src, "\n"))
+	return doc
+}
+
+func parseBlocks(lines []string) []*Node {
+	var nodes []*Node
+	i := 0
+	for i < len(lines) {
+		if isBlank(lines[i]) {
+			i++
+			continue
+		}
+		start := i
+		var node *Node
+		node, i = parseBlock(lines, i)
+		node.Line, node.EndLine = start, i
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// parseBlock parses the block starting at the non-blank line i and returns it
+// with the index of the line after it.
+func parseBlock(lines []string, i int) (*Node, int) {
+	line := lines[i]
+	if fence, ok := fenceStart(line); ok {
+		return parseFence(lines, i, fence)
+	}
+	if level, text := atxHeading(line); level > 0 {
+		return &Node{Kind: Heading, Level: level, Children: parseInlines(text)}, i + 1
+	}
+	if isThematicBreak(line) {
+		return &Node{Kind: ThematicBreak}, i + 1
+	}
+	if quoteLine(line) {
+		var inner []string
+		for i < len(lines) && quoteLine(lines[i]) {
+			inner = append(inner, stripQuote(lines[i]))
+			i++
+		}
+		return &Node{Kind: BlockQuote, Children: parseBlocks(inner)}, i
+	}
+	if m := footnoteDef.FindStringSubmatchIndex(line); m != nil {
+		label := line[m[2]:m[3]]
+		inner := []string{line[m[1]:]}
+		i++
+		for i < len(lines) && (isBlank(lines[i]) || indent(lines[i]) >= 4) {
+			if isBlank(lines[i]) && (i+1 >= len(lines) || indent(lines[i+1]) < 4) {
+				break
+			}
+			inner = append(inner, dedent(lines[i], 4))
+			i++
+		}
+		return &Node{Kind: FootnoteDef, Label: label, Children: parseBlocks(inner)}, i
+	}
+	if _, _, ok := parseMarker(line); ok {
+		return parseList(lines, i)
+	}
+	if i+1 < len(lines) && strings.Contains(line, "|") && strings.Contains(lines[i+1], "|") && tableDelim.MatchString(lines[i+1]) {
+		return parseTable(lines, i)
+	}
+	if htmlStart.MatchString(line) {
+		var block []string
+		for i < len(lines) && !isBlank(lines[i]) {
+			block = append(block, lines[i])
+			i++
+		}
+		return &Node{Kind: HTMLBlock, Literal: strings.Join(block, "\n") + "\n"}, i
+	}
+	if indent(line) >= 4 {
+		var code []string
+		for i < len(lines) && (isBlank(lines[i]) || indent(lines[i]) >= 4) {
+			code = append(code, dedent(lines[i], 4))
+			i++
+		}
+		for len(code) > 0 && isBlank(code[len(code)-1]) {
+			code = code[:len(code)-1]
+		}
+		return &Node{Kind: CodeBlock, Literal: strings.Join(code, "\n") + "\n"}, i
+	}
+
+	// A paragraph runs until a blank line or the start of another block,
+	// and becomes a heading when underlined.
+	para := []string{strings.TrimLeft(line, " ")}
+	i++
+	level := 0
+	for i < len(lines) {
+		next := lines[i]
+		if m := setextLine.FindStringSubmatch(next); m != nil {
+			level = 2
+			if m[1][0] == '=' {
+				level = 1
+			}
+			i++
+			break
+		}
+		if isBlank(next) || interruptsParagraph(next) {
+			break
+		}
+		para = append(para, strings.TrimLeft(next, " "))
+		i++
+	}
+	text := strings.Join(para, "\n")
+	if level > 0 {
+		return &Node{Kind: Heading, Level: level, Children: parseInlines(text)}, i
+	}
+	return &Node{Kind: Paragraph, Children: parseInlines(text)}, i
+}
+
+// interruptsParagraph reports whether line starts a block that ends a
+// paragraph without a blank line before it.
+func interruptsParagraph(line string) bool {
+	if _, ok := fenceStart(line); ok {
+		return true
+	}
+	if level, _ := atxHeading(line); level > 0 {
+		return true
+	}
+	if isThematicBreak(line) || quoteLine(line) || htmlStart.MatchString(line) {
+		return true
+	}
+	if marker, _, ok := parseMarker(line); ok {
+		// Only bullets and lists starting at one interrupt a paragraph, so
+		// a sentence that wraps onto "1984." stays a paragraph.
+		if n, err := strconv.Atoi(strings.TrimRight(marker, ".)")); err != nil || n == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func fenceStart(line string) (string, bool) {
+	if indent(line) > 3 {
+		return "", false
+	}
+	trimmed := strings.TrimLeft(line, " ")
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == c {
+			n++
+		}
+		if n >= 3 {
+			if c == '`' && strings.Contains(trimmed[n:], "`") {
+				return "", false
+			}
+			return trimmed[:n], true
+		}
+	}
+	return "", false
+}
+
+func fenceEnd(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return indent(line) <= 3 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+func parseFence(lines []string, start int, fence string) (*Node, int) {
+	first := strings.TrimLeft(lines[start], " ")
+	offset := indent(lines[start])
+	node := &Node{Kind: CodeBlock, Info: strings.TrimSpace(first[len(fence):])}
+
+	var code []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if fenceEnd(lines[i], fence) {
+			i++
+			break
+		}
+		code = append(code, dedent(lines[i], offset))
+	}
+	node.Literal = strings.Join(code, "\n")
+	if len(code) > 0 {
+		node.Literal += "\n"
+	}
+	return node, i
+}
+
+// FenceStart returns the run of backticks or tildes opening a fenced code
+// block, or false if the line does not open one, by the same rules Parse
+// uses.
+func FenceStart(line string) (string, bool) {
+	return fenceStart(strings.ReplaceAll(line, "\t", "    "))
+}
+
+// FenceEnd reports whether a line closes the code block opened by fence.
+// Only a run of the same character at least as long closes it.
+func FenceEnd(line, fence string) bool {
+	return fenceEnd(strings.ReplaceAll(line, "\t", "    "), fence)
+}
+
+// ATXHeading returns the level and text of an ATX heading line, or zero if
+// the line is not one, by the same rules Parse uses.
+func ATXHeading(line string) (int, string) {
+	return atxHeading(strings.ReplaceAll(line, "\t", "    "))
+}
+
+func atxHeading(line string) (int, string) {
+	if indent(line) > 3 {
+		return 0, ""
+	}
+	line = strings.TrimSpace(line)
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0, ""
+	}
+	text := strings.TrimSpace(line[level:])
+	// A closing run of #s is dropped when separated by a space.
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+	return level, text
+}
+
+// ShiftHeadings returns src with the headings of its document moved by
+// offset levels, kept between one and six. Headings are found by parsing, so
+// lines in code blocks are left alone, and setext headings are rewritten as
+// ATX headings. Headings nested in lists and quotes are not moved.
+func ShiftHeadings(src string, offset int) string {
+	if offset == 0 {
+		return src
+	}
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	lines := strings.Split(src, "\n")
+	doc := Parse(src)
+	for i := len(doc.Children) - 1; i >= 0; i-- {
+		n := doc.Children[i]
+		if n.Kind != Heading {
+			continue
+		}
+		level := n.Level + offset
+		if level < 1 {
+			level = 1
+		}
+		if level > 6 {
+			level = 6
+		}
+		heading := strings.TrimRight(strings.Repeat("#", level)+" "+headingText(lines, n), " ")
+		lines = append(lines[:n.Line], append([]string{heading}, lines[n.EndLine:]...)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headingText returns the source text of a heading among the blocks of a
+// document, without its markers.
+func headingText(lines []string, n *Node) string {
+	expanded := strings.ReplaceAll(lines[n.Line], "\t", "    ")
+	if level, text := atxHeading(expanded); level > 0 {
+		return text
+	}
+	var text []string
+	for _, line := range lines[n.Line : n.EndLine-1] {
+		text = append(text, strings.TrimSpace(line))
+	}
+	return strings.Join(text, " ")
+}
+
+func quoteLine(line string) bool {
+	return indent(line) <= 3 && strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+func stripQuote(line string) string {
+	line = strings.TrimLeft(line, " ")[1:]
+	return strings.TrimPrefix(line, " ")
+}
+
+// parseMarker returns the marker of a list item line and the column its
+// content starts at.
+func parseMarker(line string) (string, int, bool) {
+	if isThematicBreak(line) {
+		return "", 0, false
+	}
+	m := listMarker.FindStringSubmatch(line)
+	if m == nil {
+		return "", 0, false
+	}
+	width := len(m[1]) + len(m[2])
+	spaces := len(m[3])
+	if spaces == 0 || spaces > 4 {
+		// Content is on the next line, or indented code inside the item.
+		spaces = 1
+	}
+	return m[2], width + spaces, true
+}
+
+func sameListType(a, b string) bool {
+	la, lb := a[len(a)-1], b[len(b)-1]
+	if la == '.' || la == ')' {
+		return la == lb
+	}
+	return a == b
+}
+
+func parseList(lines []string, start int) (*Node, int) {
+	marker, _, _ := parseMarker(lines[start])
+	list := &Node{Kind: List, Tight: true}
+	if last := marker[len(marker)-1]; last == '.' || last == ')' {
+		list.Ordered = true
+		list.Start, _ = strconv.Atoi(marker[:len(marker)-1])
+	}
+
+	i := start
+	for i < len(lines) {
+		m, contentCol, ok := parseMarker(lines[i])
+		if !ok || !sameListType(marker, m) {
+			break
+		}
+		first := ""
+		if contentCol < len(lines[i]) {
+			first = lines[i][contentCol:]
+		}
+		item := []string{first}
+		i++
+
+		blankBefore := false
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				blankBefore = true
+				item = append(item, "")
+				i++
+				continue
+			}
+			if indent(line) >= contentCol {
+				if blankBefore && len(item) > 1 {
+					list.Tight = false
+				}
+				blankBefore = false
+				item = append(item, dedent(line, contentCol))
+				i++
+				continue
+			}
+			if blankBefore {
+				break
+			}
+			if _, _, ok := parseMarker(line); ok || interruptsParagraph(line) {
+				break
+			}
+			// A lazy continuation of the item's paragraph.
+			item = append(item, strings.TrimLeft(line, " "))
+			i++
+		}
+		for len(item) > 0 && isBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+		}
+
+		list.Children = append(list.Children, &Node{Kind: ListItem, Children: parseBlocks(item)})
+
+		if blankBefore {
+			if i < len(lines) {
+				if m, _, ok := parseMarker(lines[i]); ok && sameListType(marker, m) {
+					list.Tight = false
+					continue
+				}
+			}
+			break
+		}
+	}
+	return list, i
+}
+
+func parseTable(lines []string, start int) (*Node, int) {
+	table := &Node{Kind: Table}
+	var aligns []Align
+	for _, cell := range splitRow(lines[start+1]) {
+		cell = strings.TrimSpace(cell)
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns = append(aligns, AlignCenter)
+		case left:
+			aligns = append(aligns, AlignLeft)
+		case right:
+			aligns = append(aligns, AlignRight)
+		default:
+			aligns = append(aligns, AlignNone)
+		}
+	}
+
+	row := func(line string, header bool) *Node {
+		node := &Node{Kind: TableRow, Header: header}
+		cells := splitRow(line)
+		for j := range aligns {
+			cell := &Node{Kind: TableCell, Align: aligns[j]}
+			if j < len(cells) {
+				cell.Children = parseInlines(strings.TrimSpace(cells[j]))
+			}
+			node.Children = append(node.Children, cell)
+		}
+		return node
+	}
+
+	table.Children = append(table.Children, row(lines[start], true))
+	i := start + 2
+	for i < len(lines) && !isBlank(lines[i]) && strings.Contains(lines[i], "|") {
+		table.Children = append(table.Children, row(lines[i], false))
+		i++
+	}
+	return table, i
+}
+
+// splitRow splits a table row on unescaped pipes outside code spans.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for j := 0; j < len(line); j++ {
+		c := line[j]
+		switch {
+		case c == '\\' && j+1 < len(line) && line[j+1] == '|':
+			cell.WriteByte('|')
+			j++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, cell.String())
+}
+
+// isThematicBreak reports whether line is three or more of the same -, * or _
+// with only spaces between them.
+func isThematicBreak(line string) bool {
+	if indent(line) > 3 {
+		return false
+	}
+	line = strings.TrimSpace(line)
+	if line == "" || strings.IndexByte("-*_", line[0]) < 0 {
+		return false
+	}
+	n := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case line[0]:
+			n++
+		case ' ':
+		default:
+			return false
+		}
+	}
+	return n >= 3
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indent(line string) int {
+	n := 0
+	for n < len(line) && line[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+// dedent removes up to n leading spaces.
+func dedent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
@@ -0,0 +1,367 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	autolink    = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailLink   = regexp.MustCompile(`^<([^\s@<>]+@[^\s@<>]+\.[^\s@<>]+)>`)
+	inlineTag   = regexp.MustCompile("^</?[A-Za-z][A-Za-z0-9-]*(?:\\s+[A-Za-z_:][\\w:.-]*(?:\\s*=\\s*(?:\"[^\"]*\"|'[^']*'|[^\\s\"'=<>`]+))?)*\\s*/?>")
+	htmlComment = regexp.MustCompile(`^<!--[\s\S]*?-->`)
+	footnoteRef = regexp.MustCompile(`^\[\^([^\]\s]+)\]`)
+)
+
+func parseInlines(s string) []*Node {
+	var nodes []*Node
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			nodes = append(nodes, &Node{Kind: Text, Literal: buf.String()})
+			buf.Reset()
+		}
+	}
+	add := func(n *Node) {
+		flush()
+		nodes = append(nodes, n)
+	}
+
+	s = strings.TrimRight(s, " \n")
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			add(&Node{Kind: HardBreak})
+			i = skipSpaces(s, i+2)
+			continue
+
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			buf.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '\n':
+			text := buf.String()
+			buf.Reset()
+			buf.WriteString(strings.TrimRight(text, " "))
+			if strings.HasSuffix(text, "  ") {
+				add(&Node{Kind: HardBreak})
+			} else {
+				add(&Node{Kind: SoftBreak})
+			}
+			i = skipSpaces(s, i+1)
+			continue
+
+		case c == '`':
+			n := runLength(s, i, '`')
+			if end := findCodeClose(s, i+n, n); end >= 0 {
+				code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+					code = code[1 : len(code)-1]
+				}
+				add(&Node{Kind: Code, Literal: code})
+				i = end + n
+				continue
+			}
+			buf.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if text, dest, title, end, ok := parseLink(s, i+1); ok {
+				add(&Node{Kind: Image, Dest: dest, Title: title, Children: parseInlines(text)})
+				i = end
+				continue
+			}
+
+		case c == '[':
+			if m := footnoteRef.FindStringSubmatch(s[i:]); m != nil {
+				add(&Node{Kind: FootnoteRef, Label: m[1]})
+				i += len(m[0])
+				continue
+			}
+			if text, dest, title, end, ok := parseLink(s, i); ok {
+				add(&Node{Kind: Link, Dest: dest, Title: title, Children: parseInlines(text)})
+				i = end
+				continue
+			}
+
+		case c == '<':
+			if m := autolink.FindStringSubmatch(s[i:]); m != nil {
+				add(&Node{Kind: Link, Dest: m[1], Children: []*Node{{Kind: Text, Literal: m[1]}}})
+				i += len(m[0])
+				continue
+			}
+			if m := emailLink.FindStringSubmatch(s[i:]); m != nil {
+				add(&Node{Kind: Link, Dest: "mailto:" + m[1], Children: []*Node{{Kind: Text, Literal: m[1]}}})
+				i += len(m[0])
+				continue
+			}
+			if m := htmlComment.FindString(s[i:]); m != "" {
+				add(&Node{Kind: InlineHTML, Literal: m})
+				i += len(m)
+				continue
+			}
+			if m := inlineTag.FindString(s[i:]); m != "" {
+				add(&Node{Kind: InlineHTML, Literal: m})
+				i += len(m)
+				continue
+			}
+
+		case c == 'h' && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")) && (i == 0 || !isWordByte(s[i-1])):
+			end := bareURLEnd(s, i)
+			add(&Node{Kind: Link, Dest: s[i:end], Children: []*Node{{Kind: Text, Literal: s[i:end]}}})
+			i = end
+			continue
+
+		case c == '~':
+			n := runLength(s, i, '~')
+			if n == 2 && canOpen(s, i, n, c) {
+				if end := findClose(s, i+n, c, n); end >= 0 {
+					add(&Node{Kind: Strikethrough, Children: parseInlines(s[i+n : end])})
+					i = end + n
+					continue
+				}
+			}
+			buf.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case c == '*' || c == '_':
+			n := runLength(s, i, c)
+			if canOpen(s, i, n, c) {
+				if node, end := parseEmphasis(s, i, n, c); node != nil {
+					add(node)
+					i = end
+					continue
+				}
+			}
+			buf.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+
+		buf.WriteByte(c)
+		i++
+	}
+	flush()
+	return nodes
+}
+
+// parseEmphasis parses emphasis or strong emphasis opened by the run of n
+// delimiters c at i, returning the node and the index after its closer.
+func parseEmphasis(s string, i, n int, c byte) (*Node, int) {
+	if n > 3 {
+		return nil, 0
+	}
+	end := findClose(s, i+n, c, n)
+	if end < 0 {
+		return nil, 0
+	}
+	inner := parseInlines(s[i+n : end])
+	switch n {
+	case 3:
+		return &Node{Kind: Strong, Children: []*Node{{Kind: Emphasis, Children: inner}}}, end + n
+	case 2:
+		return &Node{Kind: Strong, Children: inner}, end + n
+	}
+	return &Node{Kind: Emphasis, Children: inner}, end + n
+}
+
+// findClose returns the index of the n delimiters c that close emphasis
+// opened before from, skipping code spans and escapes. A closing run of three
+// closes emphasis of one or two by its last delimiters.
+func findClose(s string, from int, c byte, n int) int {
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			m := runLength(s, j, '`')
+			if end := findCodeClose(s, j+m, m); end >= 0 {
+				j = end + m
+			} else {
+				j += m
+			}
+			continue
+		case c:
+			m := runLength(s, j, c)
+			if j > from && canClose(s, j, m, c) && (m == n || (m == 3 && n < 3)) {
+				return j + m - n
+			}
+			j += m
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+func findCodeClose(s string, from, n int) int {
+	for j := from; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s, j, '`')
+		if m == n {
+			return j
+		}
+		j += m
+	}
+	return -1
+}
+
+// canOpen reports whether the run of n delimiters at i can open emphasis:
+// it is followed by a non-space, and underscores are not inside a word.
+func canOpen(s string, i, n int, c byte) bool {
+	next, _ := utf8.DecodeRuneInString(s[i+n:])
+	if i+n >= len(s) || unicode.IsSpace(next) {
+		return false
+	}
+	if c == '_' && i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(s[:i])
+		return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+	}
+	return true
+}
+
+// canClose reports whether the run of n delimiters at i can close emphasis.
+func canClose(s string, i, n int, c byte) bool {
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	if i == 0 || unicode.IsSpace(prev) {
+		return false
+	}
+	if c == '_' && i+n < len(s) {
+		next, _ := utf8.DecodeRuneInString(s[i+n:])
+		return !unicode.IsLetter(next) && !unicode.IsDigit(next)
+	}
+	return true
+}
+
+// parseLink parses "[text](dest "title")" starting at the bracket at i.
+func parseLink(s string, i int) (text, dest, title string, end int, ok bool) {
+	depth := 0
+	j := i
+	for ; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			m := runLength(s, j, '`')
+			if close := findCodeClose(s, j+m, m); close >= 0 {
+				j = close + m - 1
+			} else {
+				j += m - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if j >= len(s) || j+1 >= len(s) || s[j+1] != '(' {
+		return "", "", "", 0, false
+	}
+	text = s[i+1 : j]
+
+	k := skipSpaces(s, j+2)
+	if k < len(s) && s[k] == '<' {
+		close := strings.IndexByte(s[k:], '>')
+		if close < 0 {
+			return "", "", "", 0, false
+		}
+		dest = s[k+1 : k+close]
+		k += close + 1
+	} else {
+		start := k
+		parens := 0
+		for ; k < len(s); k++ {
+			if s[k] == '\\' && k+1 < len(s) {
+				k++
+				continue
+			}
+			if s[k] == '(' {
+				parens++
+			} else if s[k] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			} else if s[k] == ' ' || s[k] == '\n' {
+				break
+			}
+		}
+		dest = s[start:k]
+	}
+
+	k = skipSpaces(s, k)
+	if k < len(s) && (s[k] == '"' || s[k] == '\'' || s[k] == '(') {
+		closer := s[k]
+		if closer == '(' {
+			closer = ')'
+		}
+		close := strings.IndexByte(s[k+1:], closer)
+		if close < 0 {
+			return "", "", "", 0, false
+		}
+		title = s[k+1 : k+1+close]
+		k = skipSpaces(s, k+close+2)
+	}
+	if k >= len(s) || s[k] != ')' {
+		return "", "", "", 0, false
+	}
+	return text, dest, title, k + 1, true
+}
+
+// bareURLEnd returns the end of a URL written without angle brackets,
+// leaving trailing punctuation and unbalanced parentheses outside it.
+func bareURLEnd(s string, i int) int {
+	end := i
+	for end < len(s) && s[end] != ' ' && s[end] != '\n' && s[end] != '<' {
+		end++
+	}
+	for end > i {
+		last := s[end-1]
+		if strings.IndexByte(".,:;!?\"'*_~", last) >= 0 {
+			end--
+			continue
+		}
+		if last == ')' && strings.Count(s[i:end], "(") < strings.Count(s[i:end], ")") {
+			end--
+			continue
+		}
+		break
+	}
+	return end
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '/' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,88 @@
+package markdown
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	src := "# Title\n\nSome *emphasis*, **strong** and `code`, a [link](http://x.io \"X\") and snake_case_name.[^1]\n\n" +
+		"- one\n- two\n  - nested\n\n1. first\n2. second\n\n```go\n# not a heading\nfmt.Println()\n```\n\n" +
+		"> quoted\n\n| Name | Size |\n|:-----|-----:|\n| a \\| b | 1 |\n\nSetext\n---\n\n[^1]: The note.\n"
+
+	doc := Parse(src)
+	kinds := []Kind{Heading, Paragraph, List, List, CodeBlock, BlockQuote, Table, Heading, FootnoteDef}
+	if len(doc.Children) != len(kinds) {
+		t.Fatalf("Expected %d blocks, got %d", len(kinds), len(doc.Children))
+	}
+	for i, kind := range kinds {
+		if doc.Children[i].Kind != kind {
+			t.Errorf("Block %d: expected kind %d, got %d", i, kind, doc.Children[i].Kind)
+		}
+	}
+
+	para := doc.Children[1]
+	var inline []Kind
+	for _, n := range para.Children {
+		inline = append(inline, n.Kind)
+	}
+	want := []Kind{Text, Emphasis, Text, Strong, Text, Code, Text, Link, Text, FootnoteRef}
+	if len(inline) != len(want) {
+		t.Fatalf("Expected inlines %v, got %v", want, inline)
+	}
+	for i := range want {
+		if inline[i] != want[i] {
+			t.Fatalf("Expected inlines %v, got %v", want, inline)
+		}
+	}
+	if link := para.Children[7]; link.Dest != "http://x.io" || link.Title != "X" || link.PlainText() != "link" {
+		t.Errorf("Unexpected link %+v", link)
+	}
+	if text := para.Children[8].Literal; text != " and snake_case_name." {
+		t.Errorf("Expected intraword underscores to stay text, got %q", text)
+	}
+
+	bullets := doc.Children[2]
+	if !bullets.Tight || len(bullets.Children) != 2 || bullets.Children[1].Children[1].Kind != List {
+		t.Errorf("Expected a tight list with a nested list, got %+v", bullets)
+	}
+	if ordered := doc.Children[3]; !ordered.Ordered || ordered.Start != 1 {
+		t.Errorf("Expected an ordered list, got %+v", ordered)
+	}
+	if code := doc.Children[4]; code.Lang() != "go" || code.Literal != "# not a heading\nfmt.Println()\n" {
+		t.Errorf("Unexpected code block %+v", code)
+	}
+
+	table := doc.Children[6]
+	if len(table.Children) != 2 || !table.Children[0].Header {
+		t.Fatalf("Expected a header and one row, got %+v", table)
+	}
+	cells := table.Children[1].Children
+	if cells[0].PlainText() != "a | b" || cells[0].Align != AlignLeft || cells[1].Align != AlignRight {
+		t.Errorf("Unexpected cells %q %v %v", cells[0].PlainText(), cells[0].Align, cells[1].Align)
+	}
+
+	if h := doc.Children[7]; h.Level != 2 || h.PlainText() != "Setext" {
+		t.Errorf("Expected a setext heading, got %+v", h)
+	}
+	if def := doc.Children[8]; def.Label != "1" || def.PlainText() != "The note." {
+		t.Errorf("Unexpected footnote %+v", def)
+	}
+}
+
+func TestParseUnterminatedLink(t *testing.T) {
+	for _, src := range []string{"[](\\", "see [the docs](\\", "![x](\\"} {
+		doc := Parse(src)
+		if len(doc.Children) != 1 || doc.Children[0].Kind != Paragraph {
+			t.Errorf("Expected %q to parse as a paragraph, got %+v", src, doc.Children)
+		}
+	}
+}
+
+func TestShiftHeadings(t *testing.T) {
+	src := "# Title #\n\nText\n\nSetext\nheading\n---\n\n```\n# fenced\n```\n\n    # indented\n\n- # in a list\n"
+	want := "### Title\n\nText\n\n#### Setext heading\n\n```\n# fenced\n```\n\n    # indented\n\n- # in a list\n"
+	if got := ShiftHeadings(src, 2); got != want {
+		t.Errorf("ShiftHeadings returned:\n%s\nwant:\n%s", got, want)
+	}
+	if got := ShiftHeadings("###### Deep\n", 3); got != "###### Deep\n" {
+		t.Errorf("Expected levels to stop at six, got %q", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package markdown parses the Markdown of drafts into a tree that exporters
+// render into other formats. It covers what drafts use: ATX and setext
+// headings, paragraphs, fenced and indented code, block quotes, nested lists,
+// tables, footnotes, thematic breaks and raw HTML, with emphasis, strong,
+// strikethrough, code spans, links, images and autolinks inline.
+package markdown
+
+// Kind is the type of a Node.
+type Kind int
+
+// Block kinds.
+const (
+	Document Kind = iota
+	Heading
+	Paragraph
+	CodeBlock
+	BlockQuote
+	List
+	ListItem
+	Table
+	TableRow
+	TableCell
+	ThematicBreak
+	HTMLBlock
+	FootnoteDef
+)
+
+// Inline kinds.
+const (
+	Text Kind = iota + 100
+	SoftBreak
+	HardBreak
+	Emphasis
+	Strong
+	Strikethrough
+	Code
+	Link
+	Image
+	InlineHTML
+	FootnoteRef
+)
+
+// Align is the alignment of a table column.
+type Align int
+
+const (
+	AlignNone Align = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Node is an element of a parsed document. Only the fields that apply to its
+// Kind are set.
+type Node struct {
+	Kind     Kind
+	Children []*Node
+
+	// Level is the level of a Heading, from one to six.
+	Level int
+	// Ordered, Start and Tight describe a List. Tight lists have no blank
+	// lines between items, so their paragraphs render without spacing.
+	Ordered bool
+	Start   int
+	Tight   bool
+	// Header marks the header row of a Table; Align is the alignment of a
+	// TableCell.
+	Header bool
+	Align  Align
+	// Info is the info string of a CodeBlock, whose first word is the
+	// language.
+	Info string
+	// Literal is the content of Text, Code, CodeBlock, HTMLBlock and
+	// InlineHTML nodes.
+	Literal string
+	// Dest and Title are the destination and title of a Link or Image. The
+	// alt text of an Image is its children.
+	Dest  string
+	Title string
+	// Label names a FootnoteDef and the FootnoteRef nodes pointing at it.
+	Label string
+	// Line and EndLine are the first line of a block and the line after
+	// its last, counting from zero within its container. For the blocks of
+	// a Document they are lines of the source.
+	Line, EndLine int
+}
+
+// Lang returns the language of a CodeBlock from its info string.
+func (n *Node) Lang() string {
+	for i, r := range n.Info {
+		if r == ' ' || r == '\t' || r == '{' {
+			return n.Info[:i]
+		}
+	}
+	return n.Info
+}
+
+// PlainText returns the text of the node and its descendants without markup.
+// The text of separate blocks is separated by a newline.
+func (n *Node) PlainText() string {
+	var buf []byte
+	var walk func(*Node)
+	walk = func(n *Node) {
+		if n.Kind < Text && len(buf) > 0 && buf[len(buf)-1] != '\n' {
+			buf = append(buf, '\n')
+		}
+		switch n.Kind {
+		case Text, Code, CodeBlock:
+			buf = append(buf, n.Literal...)
+		case SoftBreak, HardBreak:
+			buf = append(buf, ' ')
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(n)
+	return string(buf)
+}
+
+// Walk calls fn for n and every node below it in document order. Returning
+// false from fn skips the children of that node.
+func Walk(n *Node, fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Children {
+		Walk(child, fn)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+// Usage is the token usage reported for a completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// CallInfo describes the last request a model sent and the response it got
+// back, for transcripts.
+type CallInfo struct {
+	Provider    string
+	Messages    []Message
+	Parameters  map[string]interface{}
+	RawResponse string
+	Usage       Usage
+}
+
+// Options adjusts a single chat call. Zero fields keep the model's defaults.
+type Options struct {
+	Model       string
+	Temperature *float64
+}
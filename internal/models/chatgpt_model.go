@@ -13,12 +13,21 @@ import (
 )
 
 type ChatGPTModel struct {
+	Model        string
 	Parameters   map[string]interface{}
 	ErrorHandler *errors.ErrorHandler
+	// APIKey is used for requests, falling back to the OPENAI_API_KEY
+	// environment variable when empty.
+	APIKey string
 }
 
 func NewChatGPTModel(errorHandler *errors.ErrorHandler) *ChatGPTModel {
-	return &ChatGPTModel{ErrorHandler: errorHandler}
+	return &ChatGPTModel{Model: "gpt-4", ErrorHandler: errorHandler}
+}
+
+// Name returns the model used for chat completions.
+func (model *ChatGPTModel) Name() string {
+	return model.Model
 }
 
 func (model *ChatGPTModel) SetParameters(params map[string]interface{}) error {
@@ -27,52 +36,75 @@ func (model *ChatGPTModel) SetParameters(params map[string]interface{}) error {
 }
 
 func (model *ChatGPTModel) Generate(prompt string) (string, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
+	var messages []Message
+	if data, err := json.Marshal(model.Parameters["messages"]); err == nil {
+		json.Unmarshal(data, &messages)
+	}
+	content, _, err := model.Chat(messages, Options{})
+	return content, err
+}
+
+// Chat sends messages to the chat completions API and returns the reply along
+// with a record of the call. Unlike Generate it keeps no state between calls,
+// so it is safe for concurrent use.
+func (model *ChatGPTModel) Chat(messages []Message, opts Options) (string, *CallInfo, error) {
+	apiKey := model.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
 	if apiKey == "" {
-		return "", fmt.Errorf("API key not set. Please set the OPENAI_API_KEY environment variable.")
+		return "", nil, fmt.Errorf("API key not set. Please set the OPENAI_API_KEY environment variable.")
 	}
 
 	url := "https://api.openai.com/v1/chat/completions"
 	body := map[string]interface{}{
-		"model":    "gpt-4",
-		"messages": model.Parameters["messages"],
+		"model":    model.Model,
+		"messages": messages,
 	}
-
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	if opts.Model != "" {
+		body["model"] = opts.Model
 	}
+	if opts.Temperature != nil {
+		body["temperature"] = *opts.Temperature
+	}
+	call := newCallInfo(body)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", call, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
-	req.Header.Set("Content-Type", "application/json")
-
 	client := &http.Client{Timeout: 60 * time.Second}
 
 	var resp *http.Response
 	for retries := 0; retries <= model.ErrorHandler.RetryLimit; retries++ {
+		var req *http.Request
+		req, err = http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+		if err != nil {
+			return "", call, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+		req.Header.Set("Content-Type", "application/json")
+
 		resp, err = client.Do(req)
 		if err == nil {
 			break
 		}
 		model.ErrorHandler.LogError(fmt.Errorf("failed to execute request: %w", err))
 		if !model.ErrorHandler.HandleError(err) {
-			return "", fmt.Errorf("retry attempts exhausted")
+			return "", call, fmt.Errorf("retry attempts exhausted")
 		}
 	}
-
-	if resp != nil {
-		defer resp.Body.Close()
+	if resp == nil {
+		return "", call, fmt.Errorf("failed to execute request: %w", err)
 	}
+	defer resp.Body.Close()
 
-	if resp == nil || resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK {
 		responseBody, _ := io.ReadAll(resp.Body)
+		call.RawResponse = string(responseBody)
 		log.Printf("Request body: %s", jsonBody)
-		return "", fmt.Errorf("API request failed with status: %s, response: %s", resp.Status, string(responseBody))
+		return "", call, fmt.Errorf("API request failed with status: %s, response: %s", resp.Status, string(responseBody))
 	}
 
 	var respBody struct {
@@ -81,16 +113,39 @@ func (model *ChatGPTModel) Generate(prompt string) (string, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", call, fmt.Errorf("failed to read response body: %w", err)
 	}
+	call.RawResponse = string(responseBody)
 
-	err = json.NewDecoder(resp.Body).Decode(&respBody)
+	err = json.Unmarshal(responseBody, &respBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode response body: %w", err)
+		return "", call, fmt.Errorf("failed to decode response body: %w", err)
 	}
 
+	call.Usage = respBody.Usage
+
 	if len(respBody.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response body")
+		return "", call, fmt.Errorf("no choices in response body")
 	}
 
-	return respBody.Choices[0].Message.Content, nil
+	return respBody.Choices[0].Message.Content, call, nil
+}
+
+// newCallInfo records the messages and parameters of a request body.
+func newCallInfo(body map[string]interface{}) *CallInfo {
+	call := &CallInfo{Provider: "openai", Parameters: map[string]interface{}{}}
+	for key, value := range body {
+		if key != "messages" {
+			call.Parameters[key] = value
+		}
+	}
+	if data, err := json.Marshal(body["messages"]); err == nil {
+		json.Unmarshal(data, &call.Messages)
+	}
+	return call
 }
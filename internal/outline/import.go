@@ -0,0 +1,129 @@
+package outline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go-book-ai/internal/markdown"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ReadOutlineFile reads a human-written outline from a Markdown or YAML file,
+// chosen by the file extension.
+func ReadOutlineFile(path string) (*Outline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outline file: %w", err)
+	}
+
+	var o *Outline
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		o = &Outline{}
+		err = yaml.Unmarshal(data, o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outline: %w", err)
+		}
+	case ".md", ".markdown", ".txt":
+		o, err = ParseMarkdown(data)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported outline format %q, expected .md or .yaml", filepath.Ext(path))
+	}
+
+	if len(o.Chapters) == 0 {
+		return nil, fmt.Errorf("outline %s contains no chapters", path)
+	}
+	return o, nil
+}
+
+// ParseMarkdown parses a table of contents written as a Markdown heading
+// hierarchy. If the shallowest heading level is used exactly once it is taken
+// as the book title; the next levels down become chapters, sections and
+// subsections. Text under a heading becomes that item's description.
+func ParseMarkdown(data []byte) (*Outline, error) {
+	type heading struct {
+		level int
+		title string
+		text  []string
+	}
+
+	var headings []*heading
+	fence := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if markdown.FenceEnd(line, fence) {
+				fence = ""
+				continue
+			}
+		} else if open, ok := markdown.FenceStart(line); ok {
+			fence = open
+			continue
+		} else if level, title := markdown.ATXHeading(line); level > 0 && title != "" {
+			headings = append(headings, &heading{level: level, title: title})
+			continue
+		}
+		if len(headings) > 0 && trimmed != "" {
+			last := headings[len(headings)-1]
+			last.text = append(last.text, trimmed)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read markdown outline: %w", err)
+	}
+	if len(headings) == 0 {
+		return nil, fmt.Errorf("markdown outline contains no headings")
+	}
+
+	top := headings[0].level
+	for _, h := range headings {
+		if h.level < top {
+			top = h.level
+		}
+	}
+	topCount := 0
+	for _, h := range headings {
+		if h.level == top {
+			topCount++
+		}
+	}
+
+	o := &Outline{}
+	chapterLevel := top
+	if topCount == 1 && headings[0].level == top && len(headings) > 1 {
+		o.Title = headings[0].title
+		chapterLevel = top + 1
+		headings = headings[1:]
+	}
+
+	for _, h := range headings {
+		description := strings.Join(h.text, " ")
+		switch {
+		case h.level <= chapterLevel:
+			o.Chapters = append(o.Chapters, Chapter{Title: h.title, Description: description})
+		case len(o.Chapters) == 0:
+			return nil, fmt.Errorf("heading %q appears before the first chapter", h.title)
+		case h.level == chapterLevel+1:
+			chapter := &o.Chapters[len(o.Chapters)-1]
+			chapter.Sections = append(chapter.Sections, Section{Title: h.title, Description: description})
+		default:
+			chapter := &o.Chapters[len(o.Chapters)-1]
+			if len(chapter.Sections) == 0 {
+				return nil, fmt.Errorf("heading %q appears before the first section of chapter %q", h.title, chapter.Title)
+			}
+			section := &chapter.Sections[len(chapter.Sections)-1]
+			section.Subsections = append(section.Subsections, Subsection{Title: h.title, Description: description})
+		}
+	}
+
+	return o, nil
+}
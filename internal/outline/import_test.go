@@ -0,0 +1,69 @@
+package outline
+
+import "testing"
+
+func TestParseMarkdown(t *testing.T) {
+	toc := `# Go in Practice
+
+Intro text is ignored as a title description.
+
+## Getting Started
+
+Why Go, and how to install it.
+
+### Installing Go
+### Your First Program
+
+Hello world.
+
+#### Building
+#### Running
+
+## Concurrency
+### Goroutines
+`
+	o, err := ParseMarkdown([]byte(toc))
+	if err != nil {
+		t.Fatalf("ParseMarkdown returned error: %v", err)
+	}
+
+	if o.Title != "Go in Practice" {
+		t.Errorf("Expected title %q, got %q", "Go in Practice", o.Title)
+	}
+	if len(o.Chapters) != 2 {
+		t.Fatalf("Expected 2 chapters, got %d", len(o.Chapters))
+	}
+	if o.Chapters[0].Description != "Why Go, and how to install it." {
+		t.Errorf("Unexpected chapter description %q", o.Chapters[0].Description)
+	}
+	if len(o.Chapters[0].Sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(o.Chapters[0].Sections))
+	}
+	section := o.Chapters[0].Sections[1]
+	if section.Title != "Your First Program" || section.Description != "Hello world." {
+		t.Errorf("Unexpected section %+v", section)
+	}
+	if len(section.Subsections) != 2 || section.Subsections[1].Title != "Running" {
+		t.Errorf("Unexpected subsections %+v", section.Subsections)
+	}
+}
+
+func TestParseMarkdownWithoutTitle(t *testing.T) {
+	o, err := ParseMarkdown([]byte("## One\n### A\n## Two\n"))
+	if err != nil {
+		t.Fatalf("ParseMarkdown returned error: %v", err)
+	}
+	if o.Title != "" {
+		t.Errorf("Expected no title, got %q", o.Title)
+	}
+	if len(o.Chapters) != 2 || len(o.Chapters[0].Sections) != 1 {
+		t.Errorf("Unexpected outline %+v", o)
+	}
+}
+
+func TestParseMarkdownSectionBeforeChapter(t *testing.T) {
+	_, err := ParseMarkdown([]byte("# Book\n### Orphan\n## Chapter\n"))
+	if err == nil {
+		t.Errorf("Expected an error for a section before the first chapter")
+	}
+}
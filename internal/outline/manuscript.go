@@ -0,0 +1,193 @@
+package outline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go-book-ai/internal/markdown"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Manuscript is an existing Markdown book split into chapters and sections.
+type Manuscript struct {
+	Title    string
+	Chapters []ManuscriptChapter
+}
+
+type ManuscriptChapter struct {
+	Title    string
+	Sections []ManuscriptSection
+}
+
+// ManuscriptSection holds a section's Markdown with the section heading at
+// level one and deeper headings shifted to match. Content is empty when the
+// manuscript only has the heading.
+type ManuscriptSection struct {
+	Title       string
+	Subsections []string
+	Content     string
+}
+
+// ReadManuscript reads a Markdown manuscript from a single file, or from every
+// Markdown file in a directory concatenated in file name order.
+func ReadManuscript(path string) (*Manuscript, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manuscript: %w", err)
+	}
+
+	var data []byte
+	if info.IsDir() {
+		var files []string
+		err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(p))
+			if !fi.IsDir() && (ext == ".md" || ext == ".markdown") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list manuscript files: %w", err)
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			content, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read manuscript file: %w", err)
+			}
+			data = append(data, content...)
+			data = append(data, '\n')
+		}
+	} else {
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manuscript file: %w", err)
+		}
+	}
+
+	m, err := ParseManuscript(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Chapters) == 0 {
+		return nil, fmt.Errorf("manuscript %s contains no chapters", path)
+	}
+	return m, nil
+}
+
+// ParseManuscript splits Markdown into chapters and sections using the same
+// heading rules as ParseMarkdown. Text between a chapter heading and its first
+// section is kept as an "Introduction" section.
+func ParseManuscript(data []byte) (*Manuscript, error) {
+	type block struct {
+		level int
+		title string
+		lines []string
+	}
+
+	var blocks []*block
+	fence := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if fence != "" {
+			if markdown.FenceEnd(line, fence) {
+				fence = ""
+			}
+		} else if open, ok := markdown.FenceStart(line); ok {
+			fence = open
+		} else if level, title := markdown.ATXHeading(line); level > 0 && title != "" {
+			blocks = append(blocks, &block{level: level, title: title})
+			continue
+		}
+		if len(blocks) == 0 {
+			// Text before the first heading has nowhere to go.
+			continue
+		}
+		last := blocks[len(blocks)-1]
+		last.lines = append(last.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manuscript: %w", err)
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("manuscript contains no headings")
+	}
+
+	top := blocks[0].level
+	topCount := 0
+	for _, b := range blocks {
+		if b.level < top {
+			top = b.level
+		}
+	}
+	for _, b := range blocks {
+		if b.level == top {
+			topCount++
+		}
+	}
+
+	m := &Manuscript{}
+	chapterLevel := top
+	if topCount == 1 && blocks[0].level == top && len(blocks) > 1 {
+		m.Title = blocks[0].title
+		chapterLevel = top + 1
+		blocks = blocks[1:]
+	}
+	sectionLevel := chapterLevel + 1
+
+	var section *ManuscriptSection
+	var body []string
+	flush := func() {
+		if section != nil {
+			section.Content = strings.TrimSpace(strings.Join(body, "\n"))
+			if section.Content != "" {
+				section.Content = "# " + section.Title + "\n\n" + section.Content + "\n"
+			}
+		}
+		section = nil
+		body = nil
+	}
+
+	for _, b := range blocks {
+		switch {
+		case b.level <= chapterLevel:
+			flush()
+			m.Chapters = append(m.Chapters, ManuscriptChapter{Title: b.title})
+			chapter := &m.Chapters[len(m.Chapters)-1]
+			if strings.TrimSpace(strings.Join(b.lines, "\n")) != "" {
+				chapter.Sections = append(chapter.Sections, ManuscriptSection{Title: "Introduction"})
+				section = &chapter.Sections[len(chapter.Sections)-1]
+				body = append(body, b.lines...)
+			}
+		case len(m.Chapters) == 0:
+			return nil, fmt.Errorf("heading %q appears before the first chapter", b.title)
+		case b.level == sectionLevel:
+			flush()
+			chapter := &m.Chapters[len(m.Chapters)-1]
+			chapter.Sections = append(chapter.Sections, ManuscriptSection{Title: b.title})
+			section = &chapter.Sections[len(chapter.Sections)-1]
+			body = append(body, b.lines...)
+		default:
+			if section == nil {
+				chapter := &m.Chapters[len(m.Chapters)-1]
+				return nil, fmt.Errorf("heading %q appears before the first section of chapter %q", b.title, chapter.Title)
+			}
+			if b.level == sectionLevel+1 {
+				section.Subsections = append(section.Subsections, b.title)
+			}
+			body = append(body, "", strings.Repeat("#", b.level-sectionLevel+1)+" "+b.title)
+			body = append(body, b.lines...)
+		}
+	}
+	flush()
+
+	return m, nil
+}
@@ -0,0 +1,38 @@
+package outline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseManuscript(t *testing.T) {
+	text := "# My Book\n\n## Basics\n\nChapter intro.\n\n### Types\n\nInts and strings.\n\n#### Integers\n\nSigned and unsigned.\n\n```go\n~~~\n# not a heading\n```\n\n    # indented code\n\n### Control Flow\n\n## Advanced\n\n### Generics\n\nType parameters.\n"
+
+	m, err := ParseManuscript([]byte(text))
+	if err != nil {
+		t.Fatalf("ParseManuscript returned error: %v", err)
+	}
+	if m.Title != "My Book" || len(m.Chapters) != 2 {
+		t.Fatalf("Unexpected manuscript %+v", m)
+	}
+
+	basics := m.Chapters[0]
+	if len(basics.Sections) != 3 || basics.Sections[0].Title != "Introduction" {
+		t.Fatalf("Expected an introduction and two sections, got %+v", basics.Sections)
+	}
+
+	types := basics.Sections[1]
+	if !strings.HasPrefix(types.Content, "# Types\n") {
+		t.Errorf("Expected section heading at level one, got %q", types.Content)
+	}
+	if !strings.Contains(types.Content, "\n## Integers\n") || !strings.Contains(types.Content, "# not a heading") || !strings.Contains(types.Content, "\n    # indented code") {
+		t.Errorf("Expected shifted subsection heading and untouched code block, got %q", types.Content)
+	}
+	if len(types.Subsections) != 1 || types.Subsections[0] != "Integers" {
+		t.Errorf("Unexpected subsections %v", types.Subsections)
+	}
+
+	if basics.Sections[2].Content != "" {
+		t.Errorf("Expected empty content for a heading-only section, got %q", basics.Sections[2].Content)
+	}
+}
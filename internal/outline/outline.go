@@ -14,14 +14,17 @@ type Subsection struct {
 }
 
 type Section struct {
+	ID          string       `yaml:"id,omitempty"`
 	Title       string       `yaml:"title"`
 	Description string       `yaml:"description"`
 	Subsections []Subsection `yaml:"subsections"`
 }
 
 type Chapter struct {
-	Title    string    `yaml:"title"`
-	Sections []Section `yaml:"sections"`
+	ID          string    `yaml:"id,omitempty"`
+	Title       string    `yaml:"title"`
+	Description string    `yaml:"description,omitempty"`
+	Sections    []Section `yaml:"sections"`
 }
 
 type Outline struct {
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Inputs records hashes of everything a generated artifact was built from,
+// so the artifact can be found stale when one of them changes.
+type Inputs struct {
+	Outline  string `yaml:"outline,omitempty"`
+	Prompt   string `yaml:"prompt,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+	Upstream string `yaml:"upstream,omitempty"`
+}
+
+// IsZero reports whether no inputs were recorded, as for artifacts generated
+// before inputs were tracked or written by hand.
+func (i Inputs) IsZero() bool {
+	return i == Inputs{}
+}
+
+// Changed returns the names of the inputs that differ from current.
+func (i Inputs) Changed(current Inputs) []string {
+	var changed []string
+	if i.Outline != current.Outline {
+		changed = append(changed, "outline")
+	}
+	if i.Prompt != current.Prompt {
+		changed = append(changed, "prompt template")
+	}
+	if i.Model != current.Model {
+		changed = append(changed, "model parameters")
+	}
+	if i.Upstream != current.Upstream {
+		changed = append(changed, "upstream")
+	}
+	return changed
+}
+
+// Digest returns a single hash over all the inputs, for use as the upstream
+// input of dependent artifacts.
+func (i Inputs) Digest() string {
+	return Hash(i.Outline, i.Prompt, i.Model, i.Upstream)
+}
+
+// Hash returns a short, stable hash of the given parts.
+func Hash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,50 @@
+package state
+
+// Kinds of front and back matter.
+const (
+	MatterPreface          = "preface"
+	MatterAcknowledgements = "acknowledgements"
+	MatterIntroduction     = "introduction"
+	MatterHowToRead        = "how_to_read"
+	MatterConclusion       = "conclusion"
+	MatterAboutAuthor      = "about_author"
+)
+
+// MatterKinds lists the kinds of front and back matter in the order they are
+// placed in a book.
+var MatterKinds = []string{MatterPreface, MatterAcknowledgements, MatterIntroduction, MatterHowToRead, MatterConclusion, MatterAboutAuthor}
+
+var matterTitles = map[string]string{
+	MatterPreface:          "Preface",
+	MatterAcknowledgements: "Acknowledgements",
+	MatterIntroduction:     "Introduction",
+	MatterHowToRead:        "How to Read This Book",
+	MatterConclusion:       "Conclusion",
+	MatterAboutAuthor:      "About the Author",
+}
+
+// MatterState is an item of front or back matter: an unnumbered part of the
+// book placed before or after its chapters. Its ID is its kind and names its
+// file in the matter directory of the book.
+type MatterState struct {
+	ID        string `yaml:"id"`
+	Title     string `yaml:"title"`
+	Generated bool   `yaml:"generated"`
+	// Provenance records how the item was produced.
+	Provenance *Provenance `yaml:"provenance,omitempty"`
+}
+
+// DefaultMatter returns every kind of front and back matter, not yet
+// generated.
+func DefaultMatter() []MatterState {
+	matter := make([]MatterState, len(MatterKinds))
+	for i, kind := range MatterKinds {
+		matter[i] = MatterState{ID: kind, Title: matterTitles[kind]}
+	}
+	return matter
+}
+
+// Back reports whether the item goes after the chapters.
+func (m MatterState) Back() bool {
+	return m.ID == MatterConclusion || m.ID == MatterAboutAuthor
+}
@@ -0,0 +1,72 @@
+package state
+
+import (
+	"fmt"
+	"os"
+)
+
+// CurrentVersion is the schema version written by this build. Bump it and
+// append to migrations whenever the layout of state.yaml changes.
+const CurrentVersion = 1
+
+// migrations[n] upgrades a state from version n to version n+1.
+var migrations = []func(*State) error{
+	migrateV0ToV1,
+}
+
+// Migrate upgrades a state to CurrentVersion and reports whether anything
+// changed. Stores keep a backup of the old data before saving the result.
+func Migrate(s *State) (bool, error) {
+	if s.Version > CurrentVersion {
+		return false, fmt.Errorf("state has schema version %d, but this bookcli only supports up to version %d; upgrade bookcli", s.Version, CurrentVersion)
+	}
+	if s.Version == CurrentVersion {
+		return false, nil
+	}
+
+	for s.Version < CurrentVersion {
+		err := migrations[s.Version](s)
+		if err != nil {
+			return false, fmt.Errorf("failed to migrate state from version %d: %w", s.Version, err)
+		}
+		s.Version++
+	}
+	return true, nil
+}
+
+// migrateFile upgrades a state loaded from path, keeping a copy of the
+// original file as path.v<old version>.bak.
+func migrateFile(path string, data []byte, s *State) (bool, error) {
+	if s.Version < CurrentVersion {
+		backupPath := fmt.Sprintf("%s.v%d.bak", path, s.Version)
+		err := os.WriteFile(backupPath, data, 0644)
+		if err != nil {
+			return false, fmt.Errorf("failed to back up state before migration: %w", err)
+		}
+	}
+	migrated, err := Migrate(s)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return migrated, nil
+}
+
+// migrateV0ToV1 upgrades states written before the schema was versioned.
+// Chapters and sections get IDs matching the ch%d/section%d directories they
+// were stored in, and chapter draft flags, which older versions did not
+// always set, are recomputed from their sections.
+func migrateV0ToV1(s *State) error {
+	s.AssignIDs()
+	for i := range s.Chapters {
+		chapter := &s.Chapters[i]
+		if !chapter.OutlineGenerated || len(chapter.Sections) == 0 {
+			continue
+		}
+		drafted := true
+		for _, section := range chapter.Sections {
+			drafted = drafted && section.DraftGenerated
+		}
+		chapter.DraftGenerated = drafted
+	}
+	return nil
+}
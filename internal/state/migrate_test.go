@@ -0,0 +1,96 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const legacyState = `outline_generated: true
+chapters:
+- title: Chapter 1
+  outline_generated: true
+  draft_generated: false
+  sections:
+  - title: Section A
+    draft_generated: true
+    subsections: []
+  - title: Section B
+    draft_generated: true
+    subsections: []
+message_history: []
+`
+
+func TestLoadStateMigratesLegacyState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	if err := os.WriteFile(path, []byte(legacyState), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	s, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState returned error: %v", err)
+	}
+
+	if s.Version != CurrentVersion {
+		t.Errorf("Expected version %d, got %d", CurrentVersion, s.Version)
+	}
+	chapter := s.Chapters[0]
+	if chapter.ID != "ch1" || chapter.Sections[1].ID != "section2" {
+		t.Errorf("Expected positional IDs, got %q and %q", chapter.ID, chapter.Sections[1].ID)
+	}
+	if !chapter.DraftGenerated {
+		t.Errorf("Expected the chapter to be marked drafted")
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil || string(backup) != legacyState {
+		t.Errorf("Expected the original state to be backed up, got %v", err)
+	}
+
+	reloaded, err := LoadState(path)
+	if err != nil || reloaded.Version != CurrentVersion {
+		t.Errorf("Expected the migrated state to be saved, got %+v, %v", reloaded, err)
+	}
+}
+
+func TestReadStateMigratesInMemory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.yaml")
+	if err := os.WriteFile(path, []byte(legacyState), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	s, err := ReadState(path)
+	if err != nil {
+		t.Fatalf("ReadState returned error: %v", err)
+	}
+	if s.Version != CurrentVersion || s.Chapters[0].ID != "ch1" || !s.Chapters[0].DraftGenerated {
+		t.Errorf("Expected the state to be migrated, got %+v", s)
+	}
+
+	data, _ := os.ReadFile(path)
+	entries, _ := os.ReadDir(dir)
+	if string(data) != legacyState || len(entries) != 1 {
+		t.Errorf("Expected ReadState to leave the directory alone, got %d files", len(entries))
+	}
+
+	_, err = ReadState(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Errorf("Expected a missing state to read as new, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected ReadState not to create a state file")
+	}
+}
+
+func TestLoadStateRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	if err := os.WriteFile(path, []byte("version: 999\n"), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	if _, err := LoadState(path); err == nil {
+		t.Errorf("Expected an error for a newer schema version")
+	}
+}
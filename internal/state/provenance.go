@@ -0,0 +1,51 @@
+package state
+
+import "time"
+
+// Sources of generated artifacts.
+const (
+	SourceGenerated = "generated"
+	SourceImported  = "imported"
+	// SourceEdited marks an outline that was first recorded when it was
+	// edited by hand.
+	SourceEdited = "edited"
+)
+
+// Provenance records how an artifact was produced: by which provider, model,
+// parameters and prompt template version, in which run, when, and at what
+// token cost. Imported artifacts only record their source and time.
+type Provenance struct {
+	Source        string            `yaml:"source"`
+	Provider      string            `yaml:"provider,omitempty"`
+	Model         string            `yaml:"model,omitempty"`
+	Parameters    map[string]string `yaml:"parameters,omitempty"`
+	PromptVersion string            `yaml:"prompt_version,omitempty"`
+	RunID         string            `yaml:"run_id,omitempty"`
+	StartedAt     time.Time         `yaml:"started_at"`
+	FinishedAt    time.Time         `yaml:"finished_at"`
+	Usage         TokenUsage        `yaml:"usage,omitempty"`
+	// ContentHash is the hash of the file as written, so later hand edits
+	// can be detected.
+	ContentHash string `yaml:"content_hash,omitempty"`
+	// EditedAt is when an outline was last changed by hand, through an
+	// edit before approval or an outline command.
+	EditedAt time.Time `yaml:"edited_at,omitempty"`
+}
+
+// Edited returns a copy of p that records a hand edit at t, or a new
+// provenance with source SourceEdited when p is nil.
+func (p *Provenance) Edited(t time.Time) *Provenance {
+	if p == nil {
+		return &Provenance{Source: SourceEdited, StartedAt: t, FinishedAt: t, EditedAt: t}
+	}
+	edited := *p
+	edited.EditedAt = t
+	return &edited
+}
+
+// TokenUsage is the number of tokens a model call consumed.
+type TokenUsage struct {
+	PromptTokens     int `yaml:"prompt_tokens,omitempty"`
+	CompletionTokens int `yaml:"completion_tokens,omitempty"`
+	TotalTokens      int `yaml:"total_tokens,omitempty"`
+}
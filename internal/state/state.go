@@ -2,32 +2,75 @@ package state
 
 import (
 	"fmt"
+	"go-book-ai/internal/utils"
 	"os"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
 type SubsectionState struct {
-	Title string `yaml:"title"`
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
 }
 
 type SectionState struct {
+	// ID is stable for the life of the section and names its directory
+	// inside the chapter directory.
+	ID             string            `yaml:"id"`
 	Title          string            `yaml:"title"`
+	Description    string            `yaml:"description,omitempty"`
 	DraftGenerated bool              `yaml:"draft_generated"`
 	Subsections    []SubsectionState `yaml:"subsections"`
+	// DraftInputs records what the draft was generated from.
+	DraftInputs Inputs `yaml:"draft_inputs,omitempty"`
+	// DraftProvenance records how the draft was produced.
+	DraftProvenance *Provenance `yaml:"draft_provenance,omitempty"`
 }
 
 type ChapterState struct {
+	// ID is stable for the life of the chapter and names its directory
+	// inside the book directory.
+	ID               string         `yaml:"id"`
 	Title            string         `yaml:"title"`
+	Description      string         `yaml:"description,omitempty"`
 	OutlineGenerated bool           `yaml:"outline_generated"`
 	DraftGenerated   bool           `yaml:"draft_generated"`
 	Sections         []SectionState `yaml:"sections"`
+	// PendingApproval is set while the chapter outline waits for a human
+	// to review its OUTLINE.yaml and run the approve command.
+	PendingApproval bool `yaml:"pending_approval,omitempty"`
+	// OutlineInputs records what the chapter outline was generated from.
+	OutlineInputs Inputs `yaml:"outline_inputs,omitempty"`
+	// OutlineProvenance records how the chapter outline was produced.
+	OutlineProvenance *Provenance `yaml:"outline_provenance,omitempty"`
 }
 
 type State struct {
+	// Version is the schema version of the state file, see CurrentVersion.
+	Version          int            `yaml:"version"`
+	Title            string         `yaml:"title,omitempty"`
 	OutlineGenerated bool           `yaml:"outline_generated"`
 	Chapters         []ChapterState `yaml:"chapters"`
 	MessageHistory   []Message      `yaml:"message_history"`
+	// PendingApproval is set while the book outline waits for a human to
+	// review OUTLINE.yaml and run the approve command.
+	PendingApproval bool `yaml:"pending_approval,omitempty"`
+	// OutlineProvenance records how the book outline was produced.
+	OutlineProvenance *Provenance `yaml:"outline_provenance,omitempty"`
+	// RequireChapterApproval keeps the chapter approval gate on across runs
+	// once it has been asked for, until every chapter outline is approved.
+	RequireChapterApproval bool `yaml:"require_chapter_approval,omitempty"`
+	// Matter holds the front and back matter of the book in the order it is
+	// placed. It is filled in when the matter stage first runs.
+	Matter []MatterState `yaml:"matter,omitempty"`
+	// GlossaryProvenance records the model call that last added
+	// definitions to glossary.yaml.
+	GlossaryProvenance *Provenance `yaml:"glossary_provenance,omitempty"`
+	// IndexProvenance records the model call that last added terms to
+	// index.yaml.
+	IndexProvenance *Provenance `yaml:"index_provenance,omitempty"`
 }
 
 type Message struct {
@@ -37,24 +80,155 @@ type Message struct {
 
 func NewState() *State {
 	return &State{
+		Version:          CurrentVersion,
 		OutlineGenerated: false,
 		Chapters:         []ChapterState{},
 		MessageHistory:   []Message{},
 	}
 }
 
+// ChaptersOutlined reports whether the book outline and every chapter
+// outline have been generated.
+func (s *State) ChaptersOutlined() bool {
+	if !s.OutlineGenerated {
+		return false
+	}
+	for _, chapter := range s.Chapters {
+		if !chapter.OutlineGenerated {
+			return false
+		}
+	}
+	return true
+}
+
+// AwaitingApproval reports whether the book outline or any chapter outline is
+// waiting for a human review before drafting can continue.
+func (s *State) AwaitingApproval() bool {
+	if s.PendingApproval {
+		return true
+	}
+	for _, chapter := range s.Chapters {
+		if chapter.PendingApproval {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignIDs gives every chapter and section without an ID a stable one, and
+// a fresh one to any item repeating an ID already used before it. Items are
+// given the ID matching their position (ch3, section2) when it is free, which
+// is the directory layout books used before IDs existed, and the next unused
+// number otherwise.
+func (s *State) AssignIDs() {
+	used := uniqueIDs(len(s.Chapters), func(i int) *string { return &s.Chapters[i].ID })
+	for i := range s.Chapters {
+		chapter := &s.Chapters[i]
+		if chapter.ID == "" {
+			chapter.ID = NextID("ch", i+1, used)
+		}
+
+		usedSections := uniqueIDs(len(chapter.Sections), func(j int) *string { return &chapter.Sections[j].ID })
+		for j := range chapter.Sections {
+			if chapter.Sections[j].ID == "" {
+				chapter.Sections[j].ID = NextID("section", j+1, usedSections)
+			}
+		}
+	}
+}
+
+// uniqueIDs clears repeated IDs among n items and returns the set in use.
+func uniqueIDs(n int, id func(i int) *string) map[string]bool {
+	used := map[string]bool{}
+	for i := 0; i < n; i++ {
+		p := id(i)
+		if used[*p] {
+			*p = ""
+		}
+		if *p != "" {
+			used[*p] = true
+		}
+	}
+	return used
+}
+
+// NewChapterID returns an ID that no chapter in the state uses.
+func (s *State) NewChapterID() string {
+	used := map[string]bool{}
+	for _, chapter := range s.Chapters {
+		used[chapter.ID] = true
+	}
+	return NextID("ch", len(s.Chapters)+1, used)
+}
+
+// NewSectionID returns an ID that no section in the chapter uses.
+func (c *ChapterState) NewSectionID() string {
+	used := map[string]bool{}
+	for _, section := range c.Sections {
+		used[section.ID] = true
+	}
+	return NextID("section", len(c.Sections)+1, used)
+}
+
+// NextID returns prefix+position if unused, and otherwise the first number
+// after the highest one in use, marking the result as used. All chapter and
+// section IDs are allocated with it.
+func NextID(prefix string, position int, used map[string]bool) string {
+	id := prefix + strconv.Itoa(position)
+	if used[id] {
+		highest := 0
+		for existing := range used {
+			n, err := strconv.Atoi(strings.TrimPrefix(existing, prefix))
+			if err == nil && strings.HasPrefix(existing, prefix) && n > highest {
+				highest = n
+			}
+		}
+		id = prefix + strconv.Itoa(highest+1)
+	}
+	used[id] = true
+	return id
+}
+
 func (s *State) Save(path string) error {
 	data, err := yaml.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
-	err = os.WriteFile(path, data, 0644)
+	err = utils.WriteFileAtomic(path, data, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 	return nil
 }
 
+// ReadState loads the state at path without writing anything, so it is safe
+// without the book lock. A missing file reads as a new state, and states
+// written by older versions are migrated in memory only.
+func ReadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	var state State
+	err = yaml.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	_, err = Migrate(&state)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	state.AssignIDs()
+	return &state, nil
+}
+
+// LoadState loads the state at path, creating a new state file if none
+// exists. States written by older versions are migrated to CurrentVersion
+// and saved in place, with the original kept as a backup. Callers must hold
+// the book lock; readers use ReadState.
 func LoadState(path string) (*State, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -74,5 +248,17 @@ func LoadState(path string) (*State, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
 	}
+
+	migrated, err := migrateFile(path, data, &state)
+	if err != nil {
+		return nil, err
+	}
+	state.AssignIDs()
+	if migrated {
+		err = state.Save(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save migrated state: %w", err)
+		}
+	}
 	return &state, nil
 }
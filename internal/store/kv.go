@@ -0,0 +1,312 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Record operations in the KV log.
+const (
+	opPut    byte = 1
+	opDelete byte = 2
+	opCommit byte = 3
+)
+
+// kvFile is a small embedded key-value database kept in a single append-only
+// log file. Every write is a batch of put and delete records followed by a
+// commit record, each with a CRC. When the file is opened the log is replayed
+// and only complete, uncorrupted batches are applied, so a crash in the
+// middle of a write loses at most that batch. The log is compacted when it
+// grows well past the size of the live data.
+type kvFile struct {
+	path string
+	f    *os.File
+	data map[string][]byte
+	size int64
+	live int64
+}
+
+// openKV opens or creates the KV log at path for writing and loads it into
+// memory, dropping a partially written batch at its end. Only a writer
+// holding the book lock may open the log this way, since another writer could
+// still be appending that batch.
+func openKV(path string) (*kvFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db := &kvFile{path: path, f: f, data: map[string][]byte{}}
+	committed, err := db.replay(f)
+	if err == nil {
+		err = f.Truncate(committed)
+	}
+	if err == nil {
+		_, err = f.Seek(committed, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.size = committed
+	db.live = db.liveSize()
+	return db, nil
+}
+
+// readKV loads the complete batches of the KV log at path without changing
+// the file, so it is safe without the book lock. A missing log reads as
+// empty. The result can only be read from.
+func readKV(path string) (*kvFile, error) {
+	db := &kvFile{path: path, data: map[string][]byte{}}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer f.Close()
+	db.size, err = db.replay(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	db.live = db.liveSize()
+	return db, nil
+}
+
+// replay reads the log from the start, applies complete batches and returns
+// the offset just after the last one.
+func (db *kvFile) replay(f *os.File) (int64, error) {
+	_, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(f)
+
+	var offset, committed int64
+	pending := map[string][]byte{}
+	var deleted []string
+	for {
+		op, key, value, n, err := readRecord(r)
+		if err != nil {
+			break
+		}
+		offset += n
+		switch op {
+		case opPut:
+			pending[key] = value
+		case opDelete:
+			delete(pending, key)
+			deleted = append(deleted, key)
+		case opCommit:
+			for _, key := range deleted {
+				delete(db.data, key)
+			}
+			for key, value := range pending {
+				db.data[key] = value
+			}
+			pending = map[string][]byte{}
+			deleted = nil
+			committed = offset
+		}
+	}
+	return committed, nil
+}
+
+// changed reports whether the log on disk is no longer the one this writer
+// last wrote, because another process appended to or compacted it.
+func (db *kvFile) changed() bool {
+	onDisk, err := os.Stat(db.path)
+	if err != nil {
+		return true
+	}
+	open, err := db.f.Stat()
+	return err != nil || !os.SameFile(onDisk, open) || onDisk.Size() != db.size
+}
+
+// Get returns the value stored under key.
+func (db *kvFile) Get(key string) ([]byte, bool) {
+	value, ok := db.data[key]
+	return value, ok
+}
+
+// Keys returns all keys with the given prefix in sorted order.
+func (db *kvFile) Keys(prefix string) []string {
+	var keys []string
+	for key := range db.data {
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Write applies puts and deletes as one atomic batch. Puts of unchanged values
+// are skipped, so only what changed is appended to the log.
+func (db *kvFile) Write(puts map[string][]byte, deletes []string) error {
+	var buf bytes.Buffer
+	keys := make([]string, 0, len(puts))
+	for key := range puts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if current, ok := db.data[key]; ok && bytes.Equal(current, puts[key]) {
+			continue
+		}
+		writeRecord(&buf, opPut, key, puts[key])
+	}
+	for _, key := range deletes {
+		if _, ok := db.data[key]; ok {
+			writeRecord(&buf, opDelete, key, nil)
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	writeRecord(&buf, opCommit, "", nil)
+
+	_, err := db.f.Write(buf.Bytes())
+	if err == nil {
+		err = db.f.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	db.size += int64(buf.Len())
+
+	for _, key := range deletes {
+		delete(db.data, key)
+	}
+	for key, value := range puts {
+		db.data[key] = value
+	}
+	db.live = db.liveSize()
+
+	if db.size > 4096 && db.size > 2*db.live {
+		return db.compact()
+	}
+	return nil
+}
+
+// compact rewrites the log with only the live data, replacing the old file
+// atomically.
+func (db *kvFile) compact() error {
+	var buf bytes.Buffer
+	for _, key := range db.Keys("") {
+		writeRecord(&buf, opPut, key, db.data[key])
+	}
+	writeRecord(&buf, opCommit, "", nil)
+
+	tmpPath := db.path + ".compact"
+	err := os.WriteFile(tmpPath, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR, 0644)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	err = os.Rename(tmpPath, db.path)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	if d, err := os.Open(filepath.Dir(db.path)); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	db.f.Close()
+	db.f = tmp
+	_, err = db.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	db.size = int64(buf.Len())
+	return nil
+}
+
+// Close closes the log file.
+func (db *kvFile) Close() error {
+	return db.f.Close()
+}
+
+func (db *kvFile) liveSize() int64 {
+	var size int64
+	for key, value := range db.data {
+		size += int64(len(key) + len(value) + 16)
+	}
+	return size
+}
+
+// writeRecord appends op | key length | value length | key | value | CRC32.
+func writeRecord(buf *bytes.Buffer, op byte, key string, value []byte) {
+	start := buf.Len()
+	buf.WriteByte(op)
+	var n [binary.MaxVarintLen64]byte
+	buf.Write(n[:binary.PutUvarint(n[:], uint64(len(key)))])
+	buf.Write(n[:binary.PutUvarint(n[:], uint64(len(value)))])
+	buf.WriteString(key)
+	buf.Write(value)
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(buf.Bytes()[start:]))
+	buf.Write(sum[:])
+}
+
+var errCorrupt = errors.New("corrupt record")
+
+// readRecord reads one record, returning its size in bytes. Truncated or
+// corrupted records return an error.
+func readRecord(r *bufio.Reader) (byte, string, []byte, int64, error) {
+	var raw bytes.Buffer
+	op, err := r.ReadByte()
+	if err != nil {
+		return 0, "", nil, 0, err
+	}
+	raw.WriteByte(op)
+	if op != opPut && op != opDelete && op != opCommit {
+		return 0, "", nil, 0, errCorrupt
+	}
+
+	lengths := [2]uint64{}
+	for i := range lengths {
+		lengths[i], err = binary.ReadUvarint(r)
+		if err != nil {
+			return 0, "", nil, 0, err
+		}
+		var n [binary.MaxVarintLen64]byte
+		raw.Write(n[:binary.PutUvarint(n[:], lengths[i])])
+	}
+	if lengths[0] > 1<<20 || lengths[1] > 1<<30 {
+		return 0, "", nil, 0, errCorrupt
+	}
+
+	payload := make([]byte, lengths[0]+lengths[1]+4)
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return 0, "", nil, 0, err
+	}
+	body := payload[:len(payload)-4]
+	raw.Write(body)
+	if crc32.ChecksumIEEE(raw.Bytes()) != binary.LittleEndian.Uint32(payload[len(payload)-4:]) {
+		return 0, "", nil, 0, errCorrupt
+	}
+
+	key := string(body[:lengths[0]])
+	value := body[lengths[0]:]
+	return op, key, value, int64(raw.Len() + 4), nil
+}
@@ -0,0 +1,375 @@
+package store
+
+import (
+	"fmt"
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Backend names, as used in configuration and by the state migrate command.
+const (
+	BackendYAML = "yaml"
+	BackendDB   = "db"
+)
+
+// File names of the backends inside a book directory.
+const (
+	YAMLFileName = "state.yaml"
+	DBFileName   = "state.db"
+)
+
+// StateStore loads and saves the state of the book in a book directory.
+type StateStore interface {
+	// Load returns the state of the book without writing anything, so it
+	// can be called without the book lock. A book with no state yet gets an
+	// empty one, and old schema versions are migrated in memory.
+	Load(bookPath string) (*state.State, error)
+	// Open returns the state of the book for a caller holding the book
+	// lock, creating and saving an empty one if the book has no state yet
+	// and saving a migrated state with a backup of the old one.
+	Open(bookPath string) (*state.State, error)
+	Save(bookPath string, bookState *state.State) error
+	// Exists reports whether the book has saved state.
+	Exists(bookPath string) bool
+}
+
+// NewStore returns a store for the named backend.
+func NewStore(backend string) (StateStore, error) {
+	switch backend {
+	case BackendYAML, "":
+		return &YAMLStore{}, nil
+	case BackendDB:
+		return NewDBStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown state backend %q, expected %q or %q", backend, BackendYAML, BackendDB)
+	}
+}
+
+// YAMLStore keeps the whole state in a single state.yaml file.
+type YAMLStore struct{}
+
+func (s *YAMLStore) Load(bookPath string) (*state.State, error) {
+	return state.ReadState(filepath.Join(bookPath, YAMLFileName))
+}
+
+func (s *YAMLStore) Open(bookPath string) (*state.State, error) {
+	return state.LoadState(filepath.Join(bookPath, YAMLFileName))
+}
+
+func (s *YAMLStore) Save(bookPath string, bookState *state.State) error {
+	return bookState.Save(filepath.Join(bookPath, YAMLFileName))
+}
+
+func (s *YAMLStore) Exists(bookPath string) bool {
+	_, err := os.Stat(filepath.Join(bookPath, YAMLFileName))
+	return err == nil
+}
+
+// DBStore keeps the state in an embedded key-value database, state.db, with
+// the book metadata, each chapter and each history message under their own
+// keys. Saving only appends what changed, which keeps saves fast for books
+// with many sections and long message histories. Loads read the file afresh
+// without changing it; saves must hold the book lock.
+type DBStore struct {
+	mu  sync.Mutex
+	dbs map[string]*kvFile
+}
+
+func NewDBStore() *DBStore {
+	return &DBStore{dbs: map[string]*kvFile{}}
+}
+
+// Keys used by DBStore.
+const (
+	metaKey          = "meta"
+	chapterOrderKey  = "chapters"
+	chapterKeyPrefix = "chapter/"
+	historyKeyPrefix = "history/"
+)
+
+// open returns the database of a book for writing, reopening it when another
+// process has changed the file since it was last used.
+func (s *DBStore) open(bookPath string) (*kvFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := filepath.Join(bookPath, DBFileName)
+	if db, ok := s.dbs[path]; ok {
+		if !db.changed() {
+			return db, nil
+		}
+		db.Close()
+		delete(s.dbs, path)
+	}
+	db, err := openKV(path)
+	if err != nil {
+		return nil, err
+	}
+	s.dbs[path] = db
+	return db, nil
+}
+
+func (s *DBStore) Load(bookPath string) (*state.State, error) {
+	bookState, _, err := s.read(bookPath)
+	return bookState, err
+}
+
+func (s *DBStore) Open(bookPath string) (*state.State, error) {
+	bookState, version, err := s.read(bookPath)
+	if err != nil {
+		return nil, err
+	}
+	if version < state.CurrentVersion {
+		if version >= 0 {
+			backupPath := fmt.Sprintf("%s.v%d.bak", filepath.Join(bookPath, DBFileName), version)
+			err = copyFile(filepath.Join(bookPath, DBFileName), backupPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to back up state before migration: %w", err)
+			}
+		}
+		err = s.Save(bookPath, bookState)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+	return bookState, nil
+}
+
+// read loads the state from the database without changing it, migrating it
+// in memory, and returns the schema version it was stored with, or -1 when
+// the book has no state yet.
+func (s *DBStore) read(bookPath string) (*state.State, int, error) {
+	db, err := readKV(filepath.Join(bookPath, DBFileName))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	meta, ok := db.Get(metaKey)
+	if !ok {
+		return state.NewState(), -1, nil
+	}
+
+	var bookState state.State
+	err = yaml.Unmarshal(meta, &bookState)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	var order []string
+	if data, ok := db.Get(chapterOrderKey); ok {
+		err = yaml.Unmarshal(data, &order)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal chapter order: %w", err)
+		}
+	}
+	bookState.Chapters = make([]state.ChapterState, 0, len(order))
+	for _, id := range order {
+		data, ok := db.Get(chapterKeyPrefix + id)
+		if !ok {
+			return nil, 0, fmt.Errorf("state database is missing chapter %s", id)
+		}
+		var chapter state.ChapterState
+		err = yaml.Unmarshal(data, &chapter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal chapter %s: %w", id, err)
+		}
+		bookState.Chapters = append(bookState.Chapters, chapter)
+	}
+
+	bookState.MessageHistory = []state.Message{}
+	for _, key := range db.Keys(historyKeyPrefix) {
+		data, _ := db.Get(key)
+		var message state.Message
+		err = yaml.Unmarshal(data, &message)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal message %s: %w", key, err)
+		}
+		bookState.MessageHistory = append(bookState.MessageHistory, message)
+	}
+
+	version := bookState.Version
+	_, err = state.Migrate(&bookState)
+	if err != nil {
+		return nil, 0, err
+	}
+	bookState.AssignIDs()
+	return &bookState, version, nil
+}
+
+func (s *DBStore) Save(bookPath string, bookState *state.State) error {
+	db, err := s.open(bookPath)
+	if err != nil {
+		return err
+	}
+
+	puts := map[string][]byte{}
+
+	meta := *bookState
+	meta.Chapters = nil
+	meta.MessageHistory = nil
+	puts[metaKey], err = yaml.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	order := make([]string, len(bookState.Chapters))
+	chapterKeys := map[string]bool{}
+	for i, chapter := range bookState.Chapters {
+		order[i] = chapter.ID
+		chapterKeys[chapterKeyPrefix+chapter.ID] = true
+		puts[chapterKeyPrefix+chapter.ID], err = yaml.Marshal(chapter)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chapter %s: %w", chapter.ID, err)
+		}
+	}
+	puts[chapterOrderKey], err = yaml.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chapter order: %w", err)
+	}
+
+	historyKeys := map[string]bool{}
+	for i, message := range bookState.MessageHistory {
+		key := historyKeyPrefix + fmt.Sprintf("%09d", i)
+		historyKeys[key] = true
+		puts[key], err = yaml.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+	}
+
+	var deletes []string
+	for _, key := range db.Keys(chapterKeyPrefix) {
+		if !chapterKeys[key] {
+			deletes = append(deletes, key)
+		}
+	}
+	for _, key := range db.Keys(historyKeyPrefix) {
+		if !historyKeys[key] {
+			deletes = append(deletes, key)
+		}
+	}
+
+	return db.Write(puts, deletes)
+}
+
+func (s *DBStore) Exists(bookPath string) bool {
+	_, err := os.Stat(filepath.Join(bookPath, DBFileName))
+	return err == nil
+}
+
+// AutoStore picks the backend per book: books with a state.db use DBStore,
+// books with a state.yaml use YAMLStore, and new books use the default
+// backend.
+type AutoStore struct {
+	YAML    *YAMLStore
+	DB      *DBStore
+	Default string
+}
+
+// NewAutoStore returns an AutoStore creating new books with the named
+// backend.
+func NewAutoStore(defaultBackend string) (*AutoStore, error) {
+	if _, err := NewStore(defaultBackend); err != nil {
+		return nil, err
+	}
+	return &AutoStore{YAML: &YAMLStore{}, DB: NewDBStore(), Default: defaultBackend}, nil
+}
+
+// Backend returns the name of the backend used for a book.
+func (s *AutoStore) Backend(bookPath string) string {
+	switch {
+	case s.DB.Exists(bookPath):
+		return BackendDB
+	case s.YAML.Exists(bookPath):
+		return BackendYAML
+	case s.Default == "":
+		return BackendYAML
+	default:
+		return s.Default
+	}
+}
+
+func (s *AutoStore) backend(bookPath string) StateStore {
+	if s.Backend(bookPath) == BackendDB {
+		return s.DB
+	}
+	return s.YAML
+}
+
+func (s *AutoStore) Load(bookPath string) (*state.State, error) {
+	return s.backend(bookPath).Load(bookPath)
+}
+
+func (s *AutoStore) Open(bookPath string) (*state.State, error) {
+	return s.backend(bookPath).Open(bookPath)
+}
+
+func (s *AutoStore) Save(bookPath string, bookState *state.State) error {
+	return s.backend(bookPath).Save(bookPath, bookState)
+}
+
+func (s *AutoStore) Exists(bookPath string) bool {
+	return s.DB.Exists(bookPath) || s.YAML.Exists(bookPath)
+}
+
+// Convert moves the state of a book to the named backend. The old state file
+// is kept with a .migrated.bak suffix so the new backend is picked up from
+// then on.
+func (s *AutoStore) Convert(bookPath, backend string) error {
+	from := s.Backend(bookPath)
+	if from == backend {
+		return fmt.Errorf("book already uses the %s backend", backend)
+	}
+	if !s.Exists(bookPath) {
+		return fmt.Errorf("no state found in %s", bookPath)
+	}
+
+	bookState, err := s.backend(bookPath).Load(bookPath)
+	if err != nil {
+		return err
+	}
+
+	var target StateStore = s.YAML
+	oldFile, newFile := DBFileName, YAMLFileName
+	if backend == BackendDB {
+		target = s.DB
+		oldFile, newFile = YAMLFileName, DBFileName
+	}
+	if _, err := os.Stat(filepath.Join(bookPath, newFile)); err == nil {
+		return fmt.Errorf("%s already exists in %s", newFile, bookPath)
+	}
+
+	err = target.Save(bookPath, bookState)
+	if err != nil {
+		return err
+	}
+
+	s.DB.close(bookPath)
+	err = os.Rename(filepath.Join(bookPath, oldFile), filepath.Join(bookPath, oldFile+".migrated.bak"))
+	if err != nil {
+		return fmt.Errorf("failed to retire %s: %w", oldFile, err)
+	}
+	return nil
+}
+
+// close closes the database of a book if it is open.
+func (s *DBStore) close(bookPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := filepath.Join(bookPath, DBFileName)
+	if db, ok := s.dbs[path]; ok {
+		db.Close()
+		delete(s.dbs, path)
+	}
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"go-book-ai/internal/state"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func sampleState() *state.State {
+	bookState := state.NewState()
+	bookState.Title = "Go"
+	bookState.OutlineGenerated = true
+	bookState.Chapters = []state.ChapterState{
+		{ID: "ch1", Title: "Basics", OutlineGenerated: true, Sections: []state.SectionState{{ID: "section1", Title: "Types", DraftGenerated: true}}},
+		{ID: "ch2", Title: "Concurrency"},
+	}
+	bookState.MessageHistory = []state.Message{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "hi"}}
+	return bookState
+}
+
+func TestDBStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	db := NewDBStore()
+	err := db.Save(dir, sampleState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bookState := sampleState()
+	bookState.Chapters = bookState.Chapters[:1]
+	bookState.MessageHistory = bookState.MessageHistory[:1]
+	err = db.Save(dir, bookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.close(dir)
+
+	loaded, err := NewDBStore().Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Title != "Go" || len(loaded.Chapters) != 1 || len(loaded.MessageHistory) != 1 {
+		t.Fatalf("unexpected state after reload: %+v", loaded)
+	}
+	if !loaded.Chapters[0].Sections[0].DraftGenerated {
+		t.Errorf("section draft flag was lost")
+	}
+}
+
+func TestDBStoreIgnoresTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	db := NewDBStore()
+	err := db.Save(dir, sampleState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.close(dir)
+
+	// Simulate a crash part way through appending a batch.
+	f, err := os.OpenFile(filepath.Join(dir, DBFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{opPut, 4, 10, 'm', 'e'})
+	f.Close()
+
+	loaded, err := NewDBStore().Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Chapters) != 2 {
+		t.Fatalf("expected the last complete batch, got %d chapters", len(loaded.Chapters))
+	}
+}
+
+func TestDBStoreLoadLeavesFileAlone(t *testing.T) {
+	dir := t.TempDir()
+	db := NewDBStore()
+	err := db.Save(dir, sampleState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A batch another process is still appending must survive a load.
+	path := filepath.Join(dir, DBFileName)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{opPut, 4, 10, 'm', 'e'})
+	f.Close()
+	before, _ := os.Stat(path)
+
+	_, err = NewDBStore().Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() != before.Size() {
+		t.Errorf("expected load not to truncate the log, size went from %d to %d", before.Size(), after.Size())
+	}
+}
+
+func TestDBStoreSeesOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	first, second := NewDBStore(), NewDBStore()
+	err := first.Save(dir, sampleState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = second.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = second.Save(dir, sampleState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another store writes, then the first writes again from its own copy.
+	bookState := sampleState()
+	bookState.Title = "Go in Practice"
+	err = second.Save(dir, bookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := first.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Title != "Go in Practice" {
+		t.Fatalf("expected a load to see the other writer, got %q", loaded.Title)
+	}
+	loaded.Chapters = loaded.Chapters[:1]
+	err = first.Save(dir, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err = NewDBStore().Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Title != "Go in Practice" || len(loaded.Chapters) != 1 {
+		t.Errorf("expected both writes to be kept, got %q with %d chapters", loaded.Title, len(loaded.Chapters))
+	}
+}
+
+func TestLoadWritesNothing(t *testing.T) {
+	for _, backend := range []string{BackendYAML, BackendDB} {
+		dir := t.TempDir()
+		s, err := NewStore(backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Exists(dir) {
+			t.Errorf("%s: expected Load not to create state", backend)
+		}
+		_, err = s.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Exists(dir) {
+			t.Errorf("%s: expected Open to create state", backend)
+		}
+	}
+}
+
+func TestAutoStoreConvert(t *testing.T) {
+	dir := t.TempDir()
+	auto, err := NewAutoStore(BackendYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = auto.Save(dir, sampleState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = auto.Convert(dir, BackendDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auto.Backend(dir) != BackendDB {
+		t.Fatalf("expected db backend after convert, got %s", auto.Backend(dir))
+	}
+	if _, err := os.Stat(filepath.Join(dir, YAMLFileName+".migrated.bak")); err != nil {
+		t.Errorf("old state file was not kept: %v", err)
+	}
+
+	loaded, err := auto.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Chapters) != 2 || loaded.Chapters[1].Title != "Concurrency" {
+		t.Fatalf("state changed during convert: %+v", loaded.Chapters)
+	}
+}
@@ -0,0 +1,133 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-book-ai/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the transcript file inside a book directory.
+const FileName = "transcript.jsonl"
+
+// Stages of book generation recorded in the transcript.
+const (
+	StageBookOutline    = "book_outline"
+	StageChapterOutline = "chapter_outline"
+	StageDraft          = "draft"
+	StageMatter         = "matter"
+	StageGlossary       = "glossary"
+	StageIndex          = "index"
+)
+
+// Entry is one call to the language model.
+type Entry struct {
+	Time        time.Time              `json:"time"`
+	RunID       string                 `json:"run_id,omitempty"`
+	Stage       string                 `json:"stage"`
+	ItemID      string                 `json:"item_id,omitempty"`
+	Provider    string                 `json:"provider,omitempty"`
+	Model       string                 `json:"model,omitempty"`
+	Messages    []models.Message       `json:"messages"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Response    string                 `json:"response,omitempty"`
+	RawResponse string                 `json:"raw_response,omitempty"`
+	Usage       models.Usage           `json:"usage"`
+	LatencyMS   int64                  `json:"latency_ms"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+// appendMu serializes appends, so entries written by concurrent calls never
+// interleave.
+var appendMu sync.Mutex
+
+// Append adds an entry to the transcript of the book in bookPath.
+func Append(bookPath string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transcript entry: %w", err)
+	}
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(bookPath, FileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// Filter selects transcript entries. Zero fields match everything.
+type Filter struct {
+	Stage string
+	// ItemID matches the item and anything under it, so "ch2" also matches
+	// the drafts of chapter 2.
+	ItemID     string
+	ErrorsOnly bool
+	Since      time.Time
+	// Last keeps only the last n matching entries.
+	Last int
+}
+
+// Match reports whether an entry passes the filter, ignoring Last.
+func (f Filter) Match(entry Entry) bool {
+	if f.Stage != "" && entry.Stage != f.Stage {
+		return false
+	}
+	if f.ItemID != "" && entry.ItemID != f.ItemID && !strings.HasPrefix(entry.ItemID, f.ItemID+"/") {
+		return false
+	}
+	if f.ErrorsOnly && entry.Error == "" {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Read returns the entries of the book's transcript that match the filter,
+// oldest first. A missing transcript has no entries, and lines that cannot be
+// decoded, such as one cut short by a crash, are skipped.
+func Read(bookPath string, filter Filter) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(bookPath, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	if filter.Last > 0 && len(entries) > filter.Last {
+		entries = entries[len(entries)-filter.Last:]
+	}
+	return entries, nil
+}
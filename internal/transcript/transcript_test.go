@@ -0,0 +1,51 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadFilters(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now()
+	entries := []Entry{
+		{Time: start, Stage: StageBookOutline},
+		{Time: start.Add(time.Second), Stage: StageChapterOutline, ItemID: "ch1"},
+		{Time: start.Add(2 * time.Second), Stage: StageDraft, ItemID: "ch1/section1", Error: "timeout"},
+		{Time: start.Add(3 * time.Second), Stage: StageDraft, ItemID: "ch10/section1"},
+	}
+	for _, entry := range entries {
+		if err := Append(dir, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A line cut short by a crash is skipped.
+	f, _ := os.OpenFile(filepath.Join(dir, FileName), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"stage":"dra`)
+	f.Close()
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 4},
+		{"stage", Filter{Stage: StageDraft}, 2},
+		{"item and children", Filter{ItemID: "ch1"}, 2},
+		{"errors", Filter{ErrorsOnly: true}, 1},
+		{"since", Filter{Since: start.Add(2 * time.Second)}, 2},
+		{"last", Filter{Stage: StageDraft, Last: 1}, 1},
+	}
+	for _, tt := range tests {
+		got, err := Read(dir, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %d entries, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to path so that readers and crashes only ever
+// see the old or the new content: the data is written to a temporary file in
+// the same directory, synced, and renamed over path. When path already exists
+// its previous content is kept in path.bak.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if previous, err := os.ReadFile(path); err == nil {
+		err = writeAndRename(path+".bak", previous, perm)
+		if err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+	return writeAndRename(path, data, perm)
+}
+
+func writeAndRename(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a crash. Not every
+	// platform supports this, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadYAML reads the YAML file at path into v. A missing file leaves v as it
+// is.
+func LoadYAML(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// SaveYAML writes v to path as YAML with WriteFileAtomic.
+func SaveYAML(path string, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, 0644)
+}